@@ -0,0 +1,307 @@
+// Copyright 2025 Juan Martín Pérez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build js && wasm
+
+// Command labeling-wasm expone el lado del cliente de labeling (generación de claves, cifrado y
+// descifrado) a JavaScript, para que un navegador cifre los datos localmente y envíe al servicio de
+// evaluación solo labeled ciphertexts. Se compila con:
+//
+//	GOOS=js GOARCH=wasm go build -o labeling.wasm ./cmd/labeling-wasm
+//
+// y se carga con el wasm_exec.js de la distribución de Go. Al arrancar define el objeto global
+// labeling con las funciones:
+//
+//	init(paramsJSON)           carga los parámetros, en el JSON de labeling.Parameters
+//	parametersHash()           hash de los parámetros en hexadecimal
+//	keygen()                   {secretKey, keys}: la clave secreta y el KeyBundle público, como Uint8Array
+//	encrypt(keys, values)      labeled ciphertext en un contenedor de MarshalWire, como Uint8Array
+//	encryptJSON(keys, values)  labeled ciphertext en JSON, como las entradas de labelinghttp
+//	decrypt(secretKey, ct)     valores de un labeled ciphertext en contenedor (Uint8Array) o en JSON
+//
+// Cada función devuelve {result} o {error}. Los valores son enteros en [0, t) y se representan
+// como Number, así que solo son exactos si t < 2^53. La clave secreta no sale del navegador salvo
+// que la aplicación la guarde.
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"syscall/js"
+
+	"github.com/tuneinsight/lattigo/v6/core/rlwe"
+
+	"main.go/labeling"
+)
+
+// params son los parámetros cargados con init
+var params *labeling.Parameters
+
+func main() {
+	api := map[string]any{
+		"init":           wrap(initParameters),
+		"parametersHash": wrap(parametersHash),
+		"keygen":         wrap(keygen),
+		"encrypt":        wrap(encrypt),
+		"encryptJSON":    wrap(encryptJSON),
+		"decrypt":        wrap(decrypt),
+	}
+	js.Global().Set("labeling", js.ValueOf(api))
+
+	// El módulo debe seguir vivo para atender las llamadas
+	select {}
+}
+
+// wrap adapta una función de Go a JavaScript, devolviendo {result} o {error}
+func wrap(f func(args []js.Value) (any, error)) js.Func {
+	return js.FuncOf(func(this js.Value, args []js.Value) any {
+		result, err := f(args)
+		if err != nil {
+			return js.ValueOf(map[string]any{"error": err.Error()})
+		}
+		return js.ValueOf(map[string]any{"result": result})
+	})
+}
+
+// checkArgs comprueba el número de argumentos y que los parámetros estén cargados
+func checkArgs(args []js.Value, n int) error {
+	if len(args) != n {
+		return fmt.Errorf("se esperaban %d argumentos y se recibieron %d", n, len(args))
+	}
+	if params == nil {
+		return fmt.Errorf("los parámetros no están cargados; debe llamarse antes a init")
+	}
+	return nil
+}
+
+// bytesFromJS copia un Uint8Array
+func bytesFromJS(v js.Value) ([]byte, error) {
+	if !v.InstanceOf(js.Global().Get("Uint8Array")) {
+		return nil, fmt.Errorf("se esperaba un Uint8Array")
+	}
+	data := make([]byte, v.Length())
+	js.CopyBytesToGo(data, v)
+	return data, nil
+}
+
+// bytesToJS copia data en un Uint8Array nuevo
+func bytesToJS(data []byte) js.Value {
+	array := js.Global().Get("Uint8Array").New(len(data))
+	js.CopyBytesToJS(array, data)
+	return array
+}
+
+// valuesFromJS lee un array de enteros no negativos
+func valuesFromJS(v js.Value) ([]uint64, error) {
+	if v.Type() != js.TypeObject || v.Get("length").Type() != js.TypeNumber {
+		return nil, fmt.Errorf("se esperaba un array de enteros")
+	}
+
+	values := make([]uint64, v.Length())
+	for i := range values {
+		element := v.Index(i)
+		if element.Type() != js.TypeNumber || element.Float() < 0 || element.Float() != float64(uint64(element.Float())) {
+			return nil, fmt.Errorf("el elemento %d no es un entero no negativo", i)
+		}
+		values[i] = uint64(element.Float())
+	}
+
+	return values, nil
+}
+
+func initParameters(args []js.Value) (any, error) {
+	if len(args) != 1 || args[0].Type() != js.TypeString {
+		return nil, fmt.Errorf("se esperaba el JSON de los parámetros")
+	}
+
+	loaded := new(labeling.Parameters)
+	if err := json.Unmarshal([]byte(args[0].String()), loaded); err != nil {
+		return nil, err
+	}
+	params = loaded
+
+	return nil, nil
+}
+
+func parametersHash(args []js.Value) (any, error) {
+	if err := checkArgs(args, 0); err != nil {
+		return nil, err
+	}
+
+	hash, err := params.Hash()
+	if err != nil {
+		return nil, err
+	}
+	return hex.EncodeToString(hash[:]), nil
+}
+
+func keygen(args []js.Value) (any, error) {
+	if err := checkArgs(args, 0); err != nil {
+		return nil, err
+	}
+
+	sk, pk := labeling.GenerateKeyPair(*params)
+	bundle := &labeling.KeyBundle{
+		PublicKey:          pk.(*rlwe.PublicKey),
+		RelinearizationKey: labeling.GenerateRelinearizationKey(*params, sk),
+	}
+
+	secretKey, err := sk.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	keys, err := labeling.MarshalWire(*params, bundle)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]any{"secretKey": bytesToJS(secretKey), "keys": bytesToJS(keys)}, nil
+}
+
+// encryptArgs cifra el array de valores con la clave pública del paquete de claves
+func encryptArgs(args []js.Value) (labeling.PlaintextLabeledciphertext, error) {
+	if err := checkArgs(args, 2); err != nil {
+		return labeling.PlaintextLabeledciphertext{}, err
+	}
+
+	data, err := bytesFromJS(args[0])
+	if err != nil {
+		return labeling.PlaintextLabeledciphertext{}, fmt.Errorf("claves: %w", err)
+	}
+	var bundle labeling.KeyBundle
+	if err := labeling.UnmarshalWire(*params, data, &bundle); err != nil {
+		return labeling.PlaintextLabeledciphertext{}, err
+	}
+	if bundle.PublicKey == nil {
+		return labeling.PlaintextLabeledciphertext{}, fmt.Errorf("el paquete no tiene clave pública")
+	}
+
+	values, err := valuesFromJS(args[1])
+	if err != nil {
+		return labeling.PlaintextLabeledciphertext{}, err
+	}
+	if len(values) > params.MaxSlots() {
+		return labeling.PlaintextLabeledciphertext{}, fmt.Errorf("%d valores, máximo %d", len(values), params.MaxSlots())
+	}
+
+	return labeling.Encrypt(*params, bundle.PublicKey, values)
+}
+
+func encrypt(args []js.Value) (any, error) {
+	lc, err := encryptArgs(args)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := labeling.MarshalWire(*params, lc)
+	if err != nil {
+		return nil, err
+	}
+	return bytesToJS(data), nil
+}
+
+func encryptJSON(args []js.Value) (any, error) {
+	lc, err := encryptArgs(args)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(lc)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// operandFromJS lee un labeled ciphertext en contenedor (Uint8Array) o en JSON (string), en
+// cualquiera de las dos formas
+func operandFromJS(v js.Value) (labeling.Operand, error) {
+	if v.Type() == js.TypeString {
+		var form struct {
+			Form string `json:"form"`
+		}
+		data := []byte(v.String())
+		if err := json.Unmarshal(data, &form); err != nil {
+			return nil, err
+		}
+		if form.Form == "overflow" {
+			var lc labeling.CiphertextLabeledciphertext
+			if err := json.Unmarshal(data, &lc); err != nil {
+				return nil, err
+			}
+			return lc, lc.Check(*params)
+		}
+		var lc labeling.PlaintextLabeledciphertext
+		if err := json.Unmarshal(data, &lc); err != nil {
+			return nil, err
+		}
+		return lc, lc.Check(*params)
+	}
+
+	data, err := bytesFromJS(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var container labeling.Container
+	if err := container.UnmarshalBinary(data); err != nil {
+		return nil, err
+	}
+	if container.Type == labeling.PayloadCiphertextLabeledciphertext {
+		var lc labeling.CiphertextLabeledciphertext
+		err := container.Open(*params, &lc)
+		return lc, err
+	}
+	var lc labeling.PlaintextLabeledciphertext
+	err = container.Open(*params, &lc)
+	return lc, err
+}
+
+func decrypt(args []js.Value) (any, error) {
+	if err := checkArgs(args, 2); err != nil {
+		return nil, err
+	}
+
+	data, err := bytesFromJS(args[0])
+	if err != nil {
+		return nil, fmt.Errorf("clave secreta: %w", err)
+	}
+	sk := new(rlwe.SecretKey)
+	if err := sk.UnmarshalBinary(data); err != nil {
+		return nil, err
+	}
+
+	op, err := operandFromJS(args[1])
+	if err != nil {
+		return nil, err
+	}
+
+	var values []uint64
+	switch lc := op.(type) {
+	case labeling.PlaintextLabeledciphertext:
+		values, err = labeling.Decrypt(*params, sk, lc)
+	case labeling.CiphertextLabeledciphertext:
+		values, err = labeling.DecryptOverflow(*params, sk, lc)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]any, len(values))
+	for i, value := range values {
+		result[i] = float64(value)
+	}
+	return result, nil
+}
@@ -0,0 +1,381 @@
+// Copyright 2025 Juan Martín Pérez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Subcomandos de la herramienta.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/tuneinsight/lattigo/v6/core/rlwe"
+
+	"main.go/labeling"
+)
+
+// runKeygen genera los parámetros y las claves de un cliente
+func runKeygen(args []string) error {
+	fs := flag.NewFlagSet("keygen", flag.ExitOnError)
+	paramsPath := fs.String("params", "params.json", "fichero de parámetros que se crea")
+	skPath := fs.String("sk", "sk.bin", "fichero de la clave secreta")
+	keysPath := fs.String("keys", "keys.bin", "fichero del paquete de claves públicas")
+	logN := fs.Int("logn", 14, "logaritmo del grado del anillo")
+	logQ := fs.String("logq", "56,55,55,54,54,54", "bits de los módulos Q")
+	logP := fs.String("logp", "55,55", "bits de los módulos P")
+	plaintextModulus := fs.String("t", "0x3ee0001", "módulo del texto plano")
+	relin := fs.Bool("relin", true, "genera la clave de relinealización")
+	compress := fs.Bool("compress", false, "comprime el paquete de claves con zstd")
+	fs.Parse(args)
+
+	q, err := parseInts(*logQ)
+	if err != nil {
+		return err
+	}
+	p, err := parseInts(*logP)
+	if err != nil {
+		return err
+	}
+	t, err := strconv.ParseUint(*plaintextModulus, 0, 64)
+	if err != nil {
+		return fmt.Errorf("módulo del texto plano %q no válido", *plaintextModulus)
+	}
+
+	params, err := labeling.NewParametersFromLiteral(*logN, q, p, t)
+	if err != nil {
+		return err
+	}
+
+	sk, pk := labeling.GenerateKeyPair(params)
+	bundle := &labeling.KeyBundle{PublicKey: pk.(*rlwe.PublicKey)}
+	if *relin {
+		bundle.RelinearizationKey = labeling.GenerateRelinearizationKey(params, sk)
+	}
+
+	if err := writeParameters(*paramsPath, params); err != nil {
+		return err
+	}
+	if err := writeSecretKey(*skPath, sk); err != nil {
+		return err
+	}
+	return writeWire(params, *keysPath, bundle, *compress)
+}
+
+// runGaloisKeys añade al paquete las claves de Galois de las rotaciones pedidas
+func runGaloisKeys(args []string) error {
+	fs := flag.NewFlagSet("galois-keys", flag.ExitOnError)
+	paramsPath := fs.String("params", "params.json", "fichero de parámetros")
+	skPath := fs.String("sk", "sk.bin", "fichero de la clave secreta")
+	keysPath := fs.String("keys", "keys.bin", "fichero del paquete de claves, que se actualiza")
+	rotations := fs.String("rotations", "", "rotaciones de columnas separadas por comas")
+	rows := fs.Bool("rows", false, "añade la rotación de filas")
+	source := fs.String("expr", "", "añade las rotaciones que necesita la expresión")
+	compress := fs.Bool("compress", false, "comprime el paquete de claves con zstd")
+	fs.Parse(args)
+
+	params, err := readParameters(*paramsPath)
+	if err != nil {
+		return err
+	}
+	sk, err := readSecretKey(*skPath)
+	if err != nil {
+		return err
+	}
+	var bundle labeling.KeyBundle
+	if err := readWire(params, *keysPath, &bundle); err != nil {
+		return err
+	}
+
+	ks, err := parseInts(*rotations)
+	if err != nil {
+		return err
+	}
+	galEls := labeling.ColumnRotationGaloisElements(params, ks...)
+	if *rows {
+		galEls = append(galEls, params.GaloisElementForRowRotation())
+	}
+	if *source != "" {
+		expr, err := labeling.ParseExpr(*source)
+		if err != nil {
+			return err
+		}
+		galEls = append(galEls, labeling.ExprGaloisElements(params, expr)...)
+	}
+
+	// Solo generamos las claves que el paquete aún no tiene
+	var missing []uint64
+	for _, galEl := range galEls {
+		present := slices.ContainsFunc(bundle.GaloisKeys, func(gk *rlwe.GaloisKey) bool {
+			return gk.GaloisElement == galEl
+		})
+		if !present && !slices.Contains(missing, galEl) {
+			missing = append(missing, galEl)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	bundle.GaloisKeys = append(bundle.GaloisKeys, labeling.GenerateGaloisKeys(params, sk, missing)...)
+
+	return writeWire(params, *keysPath, bundle, *compress)
+}
+
+// runEncrypt cifra un vector con la clave pública del paquete
+func runEncrypt(args []string) error {
+	fs := flag.NewFlagSet("encrypt", flag.ExitOnError)
+	paramsPath := fs.String("params", "params.json", "fichero de parámetros")
+	keysPath := fs.String("keys", "keys.bin", "fichero del paquete de claves públicas")
+	values := fs.String("values", "", "valores separados por comas")
+	in := fs.String("in", "", "fichero con los valores, o - para la entrada estándar")
+	out := fs.String("out", "", "fichero del labeled ciphertext")
+	compress := fs.Bool("compress", false, "comprime el labeled ciphertext con zstd")
+	fs.Parse(args)
+
+	if *out == "" {
+		return fmt.Errorf("falta -out")
+	}
+
+	params, err := readParameters(*paramsPath)
+	if err != nil {
+		return err
+	}
+	var bundle labeling.KeyBundle
+	if err := readWire(params, *keysPath, &bundle); err != nil {
+		return err
+	}
+	if bundle.PublicKey == nil {
+		return fmt.Errorf("%s: el paquete no tiene clave pública", *keysPath)
+	}
+
+	text := *values
+	if *in != "" {
+		if text, err = readInput(*in); err != nil {
+			return err
+		}
+	}
+	vector, err := parseValues(text)
+	if err != nil {
+		return err
+	}
+	if len(vector) > params.MaxSlots() {
+		return fmt.Errorf("%d valores, máximo %d", len(vector), params.MaxSlots())
+	}
+
+	lc, err := labeling.Encrypt(params, bundle.PublicKey, vector)
+	if err != nil {
+		return err
+	}
+
+	return writeWire(params, *out, lc, *compress)
+}
+
+// inputFlags recoge las entradas name=fichero de eval
+type inputFlags map[string]string
+
+func (f inputFlags) String() string {
+	return fmt.Sprint(map[string]string(f))
+}
+
+func (f inputFlags) Set(value string) error {
+	name, path, ok := strings.Cut(value, "=")
+	if !ok || name == "" || path == "" {
+		return fmt.Errorf("se esperaba nombre=fichero")
+	}
+	f[name] = path
+	return nil
+}
+
+// runEval evalúa una expresión con labeling.EvalWithBundle
+func runEval(args []string) error {
+	fs := flag.NewFlagSet("eval", flag.ExitOnError)
+	paramsPath := fs.String("params", "params.json", "fichero de parámetros")
+	keysPath := fs.String("keys", "keys.bin", "fichero del paquete de claves públicas")
+	source := fs.String("expr", "", "expresión a evaluar")
+	out := fs.String("out", "", "fichero del resultado")
+	overflow := fs.Bool("overflow", false, "fuerza la variante overflow en las multiplicaciones")
+	compress := fs.Bool("compress", false, "comprime el resultado con zstd")
+	inputs := make(inputFlags)
+	fs.Var(inputs, "in", "entrada nombre=fichero; puede repetirse")
+	fs.Parse(args)
+
+	// Las entradas también pueden ir como argumentos posicionales
+	for _, arg := range fs.Args() {
+		if err := inputs.Set(arg); err != nil {
+			return fmt.Errorf("argumento %q: %w", arg, err)
+		}
+	}
+
+	if *source == "" || *out == "" {
+		return fmt.Errorf("faltan -expr o -out")
+	}
+
+	params, err := readParameters(*paramsPath)
+	if err != nil {
+		return err
+	}
+	var bundle labeling.KeyBundle
+	if err := readWire(params, *keysPath, &bundle); err != nil {
+		return err
+	}
+
+	values := make(map[string]labeling.PlaintextLabeledciphertext, len(inputs))
+	for name, path := range inputs {
+		var lc labeling.PlaintextLabeledciphertext
+		if err := readWire(params, path, &lc); err != nil {
+			return err
+		}
+		values[name] = lc
+	}
+
+	var opts []labeling.MultiplyOption
+	if *overflow {
+		opts = append(opts, labeling.WithOverflow())
+	}
+
+	result, err := labeling.EvalWithBundle(params, *source, values, &bundle, opts...)
+	if err != nil {
+		return err
+	}
+
+	return writeOperand(params, *out, result, *compress)
+}
+
+// runRekey aplica una clave de evaluación, leída de un fichero o generada a partir de las dos claves
+// secretas
+func runRekey(args []string) error {
+	fs := flag.NewFlagSet("rekey", flag.ExitOnError)
+	paramsPath := fs.String("params", "params.json", "fichero de parámetros")
+	evkPath := fs.String("evk", "", "fichero de la clave de evaluación")
+	skPath := fs.String("sk", "", "clave secreta de origen, para generar la clave de evaluación")
+	toSkPath := fs.String("to-sk", "", "clave secreta de destino, para generar la clave de evaluación")
+	saveEvk := fs.String("save-evk", "", "guarda la clave de evaluación generada")
+	in := fs.String("in", "", "fichero del labeled ciphertext")
+	out := fs.String("out", "", "fichero del resultado")
+	compress := fs.Bool("compress", false, "comprime el resultado con zstd")
+	fs.Parse(args)
+
+	if *in == "" || *out == "" {
+		return fmt.Errorf("faltan -in o -out")
+	}
+
+	params, err := readParameters(*paramsPath)
+	if err != nil {
+		return err
+	}
+
+	evk := new(rlwe.EvaluationKey)
+	switch {
+	case *evkPath != "":
+		if err := readWire(params, *evkPath, evk); err != nil {
+			return err
+		}
+
+	case *skPath != "" && *toSkPath != "":
+		from, err := readSecretKey(*skPath)
+		if err != nil {
+			return err
+		}
+		to, err := readSecretKey(*toSkPath)
+		if err != nil {
+			return err
+		}
+		evk = labeling.GenerateEvaluationKey(params, from, to)
+		if *saveEvk != "" {
+			if err := writeWire(params, *saveEvk, evk, *compress); err != nil {
+				return err
+			}
+		}
+
+	default:
+		return fmt.Errorf("se necesita -evk, o -sk y -to-sk")
+	}
+
+	op, err := readOperand(params, *in)
+	if err != nil {
+		return err
+	}
+
+	var result labeling.Operand
+	switch lc := op.(type) {
+	case labeling.PlaintextLabeledciphertext:
+		rekeyed, err := labeling.ApplyEvaluationKey(params, *evk, lc)
+		if err != nil {
+			return err
+		}
+		result = *rekeyed
+	case labeling.CiphertextLabeledciphertext:
+		rekeyed, err := labeling.ApplyEvaluationKeyOverflow(params, *evk, lc)
+		if err != nil {
+			return err
+		}
+		result = *rekeyed
+	}
+
+	return writeOperand(params, *out, result, *compress)
+}
+
+// runDecrypt descifra un labeled ciphertext y escribe los valores separados por comas
+func runDecrypt(args []string) error {
+	fs := flag.NewFlagSet("decrypt", flag.ExitOnError)
+	paramsPath := fs.String("params", "params.json", "fichero de parámetros")
+	skPath := fs.String("sk", "sk.bin", "fichero de la clave secreta")
+	in := fs.String("in", "", "fichero del labeled ciphertext")
+	n := fs.Int("n", 0, "número de valores que se muestran; 0 los muestra todos")
+	fs.Parse(args)
+
+	if *in == "" {
+		return fmt.Errorf("falta -in")
+	}
+
+	params, err := readParameters(*paramsPath)
+	if err != nil {
+		return err
+	}
+	sk, err := readSecretKey(*skPath)
+	if err != nil {
+		return err
+	}
+	op, err := readOperand(params, *in)
+	if err != nil {
+		return err
+	}
+
+	var values []uint64
+	switch lc := op.(type) {
+	case labeling.PlaintextLabeledciphertext:
+		values, err = labeling.Decrypt(params, sk, lc)
+	case labeling.CiphertextLabeledciphertext:
+		values, err = labeling.DecryptOverflow(params, sk, lc)
+	}
+	if err != nil {
+		return err
+	}
+
+	if *n > 0 && *n < len(values) {
+		values = values[:*n]
+	}
+
+	fields := make([]string, len(values))
+	for i, value := range values {
+		fields[i] = strconv.FormatUint(value, 10)
+	}
+	_, err = fmt.Fprintln(os.Stdout, strings.Join(fields, ","))
+
+	return err
+}
@@ -0,0 +1,194 @@
+// Copyright 2025 Juan Martín Pérez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Lectura y escritura de los ficheros de la herramienta.
+
+package main
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/tuneinsight/lattigo/v6/core/rlwe"
+
+	"main.go/labeling"
+)
+
+// readParameters lee los parámetros en JSON
+func readParameters(path string) (labeling.Parameters, error) {
+	var params labeling.Parameters
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return params, err
+	}
+	if err := json.Unmarshal(data, &params); err != nil {
+		return params, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return params, nil
+}
+
+// writeParameters guarda los parámetros en JSON
+func writeParameters(path string, params labeling.Parameters) error {
+	data, err := json.MarshalIndent(params, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// readSecretKey lee una clave secreta
+func readSecretKey(path string) (*rlwe.SecretKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	sk := new(rlwe.SecretKey)
+	if err := sk.UnmarshalBinary(data); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return sk, nil
+}
+
+// writeSecretKey guarda una clave secreta legible solo por su propietario
+func writeSecretKey(path string, sk *rlwe.SecretKey) error {
+	data, err := sk.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o600)
+}
+
+// readWire lee en v un contenedor de labeling.MarshalWire generado con params
+func readWire(params labeling.Parameters, path string, v encoding.BinaryUnmarshaler) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := labeling.UnmarshalWire(params, data, v); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// writeWire guarda v en un contenedor de labeling.MarshalWire para params
+func writeWire(params labeling.Parameters, path string, v encoding.BinaryMarshaler, compress bool) error {
+	var opts []labeling.WireOption
+	if compress {
+		opts = append(opts, labeling.WithCompression(labeling.CompressionZstd))
+	}
+
+	data, err := labeling.MarshalWire(params, v, opts...)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// readOperand lee un labeled ciphertext en la forma que indique su contenedor
+func readOperand(params labeling.Parameters, path string) (labeling.Operand, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var container labeling.Container
+	if err := container.UnmarshalBinary(data); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	switch container.Type {
+	case labeling.PayloadPlaintextLabeledciphertext:
+		var lc labeling.PlaintextLabeledciphertext
+		if err := container.Open(params, &lc); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		return lc, nil
+
+	case labeling.PayloadCiphertextLabeledciphertext:
+		var lc labeling.CiphertextLabeledciphertext
+		if err := container.Open(params, &lc); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		return lc, nil
+	}
+
+	return nil, fmt.Errorf("%s: se esperaba un labeled ciphertext y el fichero contiene %v", path, container.Type)
+}
+
+// writeOperand guarda un labeled ciphertext en cualquiera de las dos formas
+func writeOperand(params labeling.Parameters, path string, op labeling.Operand, compress bool) error {
+	switch lc := op.(type) {
+	case labeling.PlaintextLabeledciphertext:
+		return writeWire(params, path, lc, compress)
+	case labeling.CiphertextLabeledciphertext:
+		return writeWire(params, path, lc, compress)
+	}
+	return fmt.Errorf("tipo de resultado %T no soportado", op)
+}
+
+// parseValues interpreta una lista de enteros separados por comas o espacios
+func parseValues(text string) ([]uint64, error) {
+	fields := strings.FieldsFunc(text, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t' || r == '\n' || r == '\r'
+	})
+
+	values := make([]uint64, len(fields))
+	for i, field := range fields {
+		value, err := strconv.ParseUint(field, 0, 64)
+		if err != nil {
+			return nil, fmt.Errorf("valor %q no válido", field)
+		}
+		values[i] = value
+	}
+
+	return values, nil
+}
+
+// parseInts interpreta una lista de enteros con signo separados por comas
+func parseInts(text string) ([]int, error) {
+	if text == "" {
+		return nil, nil
+	}
+
+	fields := strings.Split(text, ",")
+	values := make([]int, len(fields))
+	for i, field := range fields {
+		value, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil {
+			return nil, fmt.Errorf("entero %q no válido", field)
+		}
+		values[i] = value
+	}
+
+	return values, nil
+}
+
+// readInput lee el contenido de path, o la entrada estándar si path es "-"
+func readInput(path string) (string, error) {
+	if path == "-" {
+		data, err := io.ReadAll(os.Stdin)
+		return string(data), err
+	}
+	data, err := os.ReadFile(path)
+	return string(data), err
+}
@@ -0,0 +1,95 @@
+//go:build ignore
+
+// Ejemplo de envoltura de claves con AWS KMS.
+//
+// Requiere el SDK de AWS, que no es una dependencia de labeling:
+//
+//	go get github.com/aws/aws-sdk-go-v2/config github.com/aws/aws-sdk-go-v2/service/kms
+//	LABELING_KMS_KEY=arn:aws:kms:... go run aws.go
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"log"
+	"os"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/tuneinsight/lattigo/v6/core/rlwe"
+	"main.go/labeling"
+)
+
+// awsKeyWrapper implementa labeling.KeyWrapper con una clave de AWS KMS
+type awsKeyWrapper struct {
+	client *kms.Client
+	keyID  string
+}
+
+// encryptionContext lleva los datos adicionales autenticados, ya que AWS KMS no admite AAD binarios
+func encryptionContext(aad []byte) map[string]string {
+	if len(aad) == 0 {
+		return nil
+	}
+	return map[string]string{"labeling-aad": hex.EncodeToString(aad)}
+}
+
+func (w awsKeyWrapper) WrapKey(ctx context.Context, key, aad []byte) ([]byte, error) {
+	out, err := w.client.Encrypt(ctx, &kms.EncryptInput{
+		KeyId:             aws.String(w.keyID),
+		Plaintext:         key,
+		EncryptionContext: encryptionContext(aad),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out.CiphertextBlob, nil
+}
+
+func (w awsKeyWrapper) UnwrapKey(ctx context.Context, wrapped, aad []byte) ([]byte, error) {
+	out, err := w.client.Decrypt(ctx, &kms.DecryptInput{
+		KeyId:             aws.String(w.keyID),
+		CiphertextBlob:    wrapped,
+		EncryptionContext: encryptionContext(aad),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out.Plaintext, nil
+}
+
+func main() {
+	ctx := context.Background()
+
+	// Cargamos la configuración y las credenciales por defecto de AWS
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		log.Fatalf("Error al cargar la configuración de AWS: %v", err)
+	}
+	wrapper := awsKeyWrapper{client: kms.NewFromConfig(cfg), keyID: os.Getenv("LABELING_KMS_KEY")}
+
+	params, err := labeling.NewParametersFromPreset(labeling.ParamsPN13QP218)
+	if err != nil {
+		log.Fatalf("Error al crear los parámetros: %v", err)
+	}
+	sk, _ := labeling.GenerateKeyPair(params)
+
+	// Guardamos la clave secreta en un almacén en disco, envuelta por el KMS
+	kv, err := labeling.NewFileKV("keys")
+	if err != nil {
+		log.Fatalf("Error al crear el almacén: %v", err)
+	}
+	store := labeling.NewKMSKeyStore(kv, wrapper)
+	if err := store.Put("cliente", sk); err != nil {
+		log.Fatalf("Error al guardar la clave: %v", err)
+	}
+
+	// La recuperamos, lo que requiere permiso kms:Decrypt sobre la clave maestra
+	recovered, err := labeling.GetKey[*rlwe.SecretKey](store, "cliente")
+	if err != nil {
+		log.Fatalf("Error al recuperar la clave: %v", err)
+	}
+
+	log.Println("Clave recuperada correctamente: ", recovered.Equal(sk))
+}
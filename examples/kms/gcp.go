@@ -0,0 +1,82 @@
+//go:build ignore
+
+// Ejemplo de envoltura de claves con Google Cloud KMS.
+//
+// Requiere el cliente de Google Cloud KMS, que no es una dependencia de labeling:
+//
+//	go get cloud.google.com/go/kms
+//	LABELING_KMS_KEY=projects/.../locations/.../keyRings/.../cryptoKeys/... go run gcp.go
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+
+	kms "cloud.google.com/go/kms/apiv1"
+	"cloud.google.com/go/kms/apiv1/kmspb"
+	"github.com/tuneinsight/lattigo/v6/core/rlwe"
+	"main.go/labeling"
+)
+
+// gcpKeyWrapper implementa labeling.KeyWrapper con una clave de Google Cloud KMS
+type gcpKeyWrapper struct {
+	client  *kms.KeyManagementClient
+	keyName string
+}
+
+func (w gcpKeyWrapper) WrapKey(ctx context.Context, key, aad []byte) ([]byte, error) {
+	resp, err := w.client.Encrypt(ctx, &kmspb.EncryptRequest{
+		Name:                        w.keyName,
+		Plaintext:                   key,
+		AdditionalAuthenticatedData: aad,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Ciphertext, nil
+}
+
+func (w gcpKeyWrapper) UnwrapKey(ctx context.Context, wrapped, aad []byte) ([]byte, error) {
+	resp, err := w.client.Decrypt(ctx, &kmspb.DecryptRequest{
+		Name:                        w.keyName,
+		Ciphertext:                  wrapped,
+		AdditionalAuthenticatedData: aad,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Plaintext, nil
+}
+
+func main() {
+	ctx := context.Background()
+
+	// Creamos el cliente con las credenciales por defecto de la aplicación
+	client, err := kms.NewKeyManagementClient(ctx)
+	if err != nil {
+		log.Fatalf("Error al crear el cliente de Cloud KMS: %v", err)
+	}
+	defer client.Close()
+	wrapper := gcpKeyWrapper{client: client, keyName: os.Getenv("LABELING_KMS_KEY")}
+
+	params, err := labeling.NewParametersFromPreset(labeling.ParamsPN13QP218)
+	if err != nil {
+		log.Fatalf("Error al crear los parámetros: %v", err)
+	}
+	sk, _ := labeling.GenerateKeyPair(params)
+
+	// Envolvemos la clave secreta para guardarla fuera del almacén
+	sealed, err := labeling.WrapSecretKey(ctx, wrapper, sk)
+	if err != nil {
+		log.Fatalf("Error al envolver la clave: %v", err)
+	}
+
+	// La recuperamos, lo que requiere el permiso cloudkms.cryptoKeyVersions.useToDecrypt
+	recovered, err := labeling.UnwrapSecretKey(ctx, wrapper, sealed)
+	if err != nil {
+		log.Fatalf("Error al desenvolver la clave: %v", err)
+	}
+
+	log.Println("Clave recuperada correctamente: ", recovered.Equal(sk))
+}
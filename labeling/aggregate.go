@@ -0,0 +1,241 @@
+// Copyright 2025 Juan Martín Pérez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package labeling
+
+import (
+	"fmt"
+
+	"github.com/tuneinsight/lattigo/v6/core/rlwe"
+)
+
+// InnerSumGaloisElements devuelve los elementos de Galois que necesitan InnerSum e InnerSumOverflow:
+// las rotaciones de columnas por potencias de dos y el intercambio de filas. Las claves se generan con
+// GenerateGaloisKeys(params, sk, InnerSumGaloisElements(params)).
+func InnerSumGaloisElements(params Parameters) []uint64 {
+	var galEls []uint64
+	for k := 1; k < params.MaxSlots()/2; k <<= 1 {
+		galEls = append(galEls, params.GaloisElementForColRotation(k))
+	}
+	return append(galEls, params.GaloisElementForRowRotation())
+}
+
+// innerSum suma todos los slots de un PlaintextLabeledciphertext y deja el total replicado en
+// todos ellos: log2(N/2) rotaciones de columnas seguidas de un intercambio de filas
+func innerSum(params Parameters, labeledciphertext PlaintextLabeledciphertext, evk *rlwe.MemEvaluationKeySet) (PlaintextLabeledciphertext, error) {
+	result := labeledciphertext
+
+	for k := 1; k < params.MaxSlots()/2; k <<= 1 {
+		rotated, err := RotateColumns(params, result, k, evk)
+		if err != nil {
+			return labeledciphertext, err
+		}
+		if result, err = Sum(params.Parameters, result, rotated); err != nil {
+			return labeledciphertext, err
+		}
+	}
+
+	// Sumamos las dos filas de slots
+	swapped, err := RotateRows(params, result, evk)
+	if err != nil {
+		return labeledciphertext, err
+	}
+
+	return Sum(params.Parameters, result, swapped)
+}
+
+// InnerSum suma todos los slots de un PlaintextLabeledciphertext y deja el total replicado en todos
+// ellos con log2(N) rotaciones. evk debe incluir las claves de InnerSumGaloisElements.
+func InnerSum(params Parameters, labeledciphertext PlaintextLabeledciphertext, evk *rlwe.MemEvaluationKeySet) (PlaintextLabeledciphertext, error) {
+	result, err := innerSum(params, labeledciphertext, evk)
+	if err != nil {
+		return result, err
+	}
+
+	result.meta = deriveMetadata("InnerSum", false, labeledciphertext.meta)
+
+	return result, nil
+}
+
+// InnerSumOverflow suma todos los slots de un CiphertextLabeledciphertext.
+// Los productos de βs no pueden sumarse sin descifrar y cada rotación duplicaría el número de términos,
+// así que antes de rotar los pliega en α con Compact: las rotaciones operan sobre un único cifrado y
+// el resultado no tiene elementos B. evk debe incluir la clave de relinealización además de las claves
+// de InnerSumGaloisElements, y en BGV Compact consume ⌈log2 d⌉ niveles, siendo d el grado de la entrada.
+func InnerSumOverflow(params Parameters, labeledciphertext CiphertextLabeledciphertext, evk *rlwe.MemEvaluationKeySet) (CiphertextLabeledciphertext, error) {
+	result := labeledciphertext
+	if len(result.elementsB) > 0 {
+		var err error
+		if result, err = Compact(params, labeledciphertext, evk); err != nil {
+			return labeledciphertext, err
+		}
+	}
+
+	for k := 1; k < params.MaxSlots()/2; k <<= 1 {
+		rotated, err := RotateColumnsOverflow(params, result, k, evk)
+		if err != nil {
+			return labeledciphertext, err
+		}
+		if result, err = SumOverflowCiphertext(params, result, rotated); err != nil {
+			return labeledciphertext, err
+		}
+	}
+
+	// Sumamos las dos filas de slots
+	swapped, err := RotateRowsOverflow(params, result, evk)
+	if err != nil {
+		return labeledciphertext, err
+	}
+
+	if result, err = SumOverflowCiphertext(params, result, swapped); err != nil {
+		return labeledciphertext, err
+	}
+
+	result.meta = deriveMetadata("InnerSumOverflow", false, labeledciphertext.meta)
+
+	return result, nil
+}
+
+// InnerProduct calcula el producto escalar de dos vectores cifrados: multiplica slot a slot y
+// suma todos los slots con rotaciones, dejando el resultado replicado en todos ellos.
+// evk debe incluir la clave de relinealización y las claves de Galois de las rotaciones.
+func InnerProduct(params Parameters, labeledciphertext1, labeledciphertext2 PlaintextLabeledciphertext, key rlwe.EncryptionKey, evk *rlwe.MemEvaluationKeySet) (PlaintextLabeledciphertext, error) {
+	product, err := Mult(params, labeledciphertext1, labeledciphertext2, key, evk)
+	if err != nil {
+		return product, err
+	}
+
+	result, err := innerSum(params, product, evk)
+	if err != nil {
+		return result, err
+	}
+
+	result.meta = deriveMetadata("InnerProduct", true, labeledciphertext1.meta, labeledciphertext2.meta)
+
+	return result, nil
+}
+
+// PlainDotProduct calcula el producto escalar con un vector público de pesos (MulPlain seguido de
+// InnerSum), por ejemplo para puntuar un modelo lineal. El resultado queda replicado en todos los slots.
+func PlainDotProduct(params Parameters, labeledciphertext PlaintextLabeledciphertext, weights []uint64, evk *rlwe.MemEvaluationKeySet) (PlaintextLabeledciphertext, error) {
+	weighted, err := mulPlain(params, labeledciphertext, weights)
+	if err != nil {
+		return weighted, err
+	}
+
+	result, err := innerSum(params, weighted, evk)
+	if err != nil {
+		return result, err
+	}
+
+	result.meta = deriveMetadata("PlainDotProduct", false, labeledciphertext.meta)
+
+	return result, nil
+}
+
+// Replicate copia el valor del slot slotIndex en todos los slots: lo aísla con MulPlain y, como el
+// resto de slots queda a 0, InnerSum lo replica. Usa las mismas claves que InnerSum.
+func Replicate(params Parameters, labeledciphertext PlaintextLabeledciphertext, slotIndex int, evk *rlwe.MemEvaluationKeySet) (PlaintextLabeledciphertext, error) {
+	if slotIndex < 0 || slotIndex >= params.MaxSlots() {
+		return labeledciphertext, fmt.Errorf("labeling: slot %d fuera de rango [0, %d)", slotIndex, params.MaxSlots())
+	}
+
+	isolated, err := mulPlain(params, labeledciphertext, unitVector(params, slotIndex))
+	if err != nil {
+		return isolated, err
+	}
+
+	result, err := innerSum(params, isolated, evk)
+	if err != nil {
+		return result, err
+	}
+
+	result.meta = deriveMetadata("Replicate", false, labeledciphertext.meta)
+
+	return result, nil
+}
+
+// slidingSumShifts devuelve los desplazamientos que usa SlidingSum para una ventana dada
+func slidingSumShifts(window int) []int {
+	var shifts []int
+	offset := 0
+	for block := 1; block <= window; block <<= 1 {
+		if window&block != 0 {
+			if offset != 0 {
+				shifts = append(shifts, offset)
+			}
+			offset += block
+		}
+		if block<<1 <= window {
+			shifts = append(shifts, block)
+		}
+	}
+	return shifts
+}
+
+// SlidingSumGaloisElements devuelve los elementos de Galois que necesita SlidingSum para una ventana dada
+func SlidingSumGaloisElements(params Parameters, window int) []uint64 {
+	return ColumnRotationGaloisElements(params, slidingSumShifts(window)...)
+}
+
+// SlidingSum calcula sumas móviles: el slot i del resultado es la suma de los slots i, …, i + window − 1
+// de su fila, de forma circular. Usa O(log window) rotaciones, duplicando bloques de sumas y combinando
+// los que corresponden a los bits de window.
+func SlidingSum(params Parameters, labeledciphertext PlaintextLabeledciphertext, window int, evk *rlwe.MemEvaluationKeySet) (PlaintextLabeledciphertext, error) {
+	if window < 1 || window > params.MaxSlots()/2 {
+		return labeledciphertext, fmt.Errorf("labeling: ventana %d fuera de rango [1, %d]", window, params.MaxSlots()/2)
+	}
+
+	var result PlaintextLabeledciphertext
+	hasResult := false
+	offset := 0
+
+	// block contiene en cada slot la suma de block slots consecutivos
+	block := labeledciphertext
+	for size := 1; size <= window; size <<= 1 {
+		if window&size != 0 {
+			shifted := block
+			if offset != 0 {
+				var err error
+				if shifted, err = RotateColumns(params, block, offset, evk); err != nil {
+					return labeledciphertext, err
+				}
+			}
+
+			if !hasResult {
+				result, hasResult = shifted, true
+			} else {
+				var err error
+				if result, err = Sum(params.Parameters, result, shifted); err != nil {
+					return labeledciphertext, err
+				}
+			}
+			offset += size
+		}
+
+		if size<<1 <= window {
+			rotated, err := RotateColumns(params, block, size, evk)
+			if err != nil {
+				return labeledciphertext, err
+			}
+			if block, err = Sum(params.Parameters, block, rotated); err != nil {
+				return labeledciphertext, err
+			}
+		}
+	}
+
+	result.meta = deriveMetadata("SlidingSum", false, labeledciphertext.meta)
+
+	return result, nil
+}
@@ -0,0 +1,233 @@
+// Copyright 2025 Juan Martín Pérez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package labeling
+
+import (
+	"fmt"
+
+	"github.com/tuneinsight/lattigo/v6/core/rlwe"
+	"github.com/tuneinsight/lattigo/v6/schemes/bgv"
+)
+
+// addConstVector suma (o resta si negate) un vector público a un labeled ciphertext.
+// En modo texto plano solo cambian los elementos A; en forma overflow se suma a α.
+func addConstVector[T PlaintextElements | *CiphertextElement](params Parameters, labeledciphertext Labeledciphertext[T], constant []uint64, negate bool, operation string) (Labeledciphertext[T], error) {
+	t := params.PlaintextModulus()
+
+	reduced := make([]uint64, len(constant))
+	for i, c := range constant {
+		reduced[i] = c % t
+		if negate {
+			reduced[i] = (t - reduced[i]) % t
+		}
+	}
+
+	result := Labeledciphertext[T]{elementsB: labeledciphertext.elementsB}
+
+	switch elementsA := any(labeledciphertext.elementsA).(type) {
+	case PlaintextElements:
+		// a ← a + c
+		sum := make(PlaintextElements, len(elementsA))
+		for i, elementA := range elementsA {
+			sum[i] = elementA
+			if i < len(reduced) {
+				sum[i] = (elementA + reduced[i]) % t
+			}
+		}
+		result.elementsA = any(sum).(T)
+	case *CiphertextElement:
+		// α ← α + c
+		alpha, err := bgv.NewEvaluator(params.Parameters, nil).AddNew((*rlwe.Ciphertext)(elementsA), reduced)
+		if err != nil {
+			return labeledciphertext, err
+		}
+		result.elementsA = any((*CiphertextElement)(alpha)).(T)
+	}
+
+	result.meta = deriveMetadata(operation, false, labeledciphertext.meta)
+
+	return result, nil
+}
+
+// broadcast devuelve un vector con el escalar c en todos los slots
+func broadcast(params Parameters, c uint64) []uint64 {
+	vector := make([]uint64, params.MaxSlots())
+	for i := range vector {
+		vector[i] = c
+	}
+	return vector
+}
+
+// AddConst suma un escalar público a todos los slots sin cifrarlo ni consumir ruido
+func AddConst[T PlaintextElements | *CiphertextElement](params Parameters, labeledciphertext Labeledciphertext[T], c uint64) (Labeledciphertext[T], error) {
+	return addConstVector(params, labeledciphertext, broadcast(params, c), false, "AddConst")
+}
+
+// AddConstVector suma un vector público slot a slot sin cifrarlo ni consumir ruido
+func AddConstVector[T PlaintextElements | *CiphertextElement](params Parameters, labeledciphertext Labeledciphertext[T], constant []uint64) (Labeledciphertext[T], error) {
+	return addConstVector(params, labeledciphertext, constant, false, "AddConstVector")
+}
+
+// SubConst resta un escalar público a todos los slots sin cifrarlo ni consumir ruido
+func SubConst[T PlaintextElements | *CiphertextElement](params Parameters, labeledciphertext Labeledciphertext[T], c uint64) (Labeledciphertext[T], error) {
+	return addConstVector(params, labeledciphertext, broadcast(params, c), true, "SubConst")
+}
+
+// SubConstVector resta un vector público slot a slot sin cifrarlo ni consumir ruido
+func SubConstVector[T PlaintextElements | *CiphertextElement](params Parameters, labeledciphertext Labeledciphertext[T], constant []uint64) (Labeledciphertext[T], error) {
+	return addConstVector(params, labeledciphertext, constant, true, "SubConstVector")
+}
+
+// scaleCiphertext devuelve ct · v, con v un vector público
+func scaleCiphertext(evaluator *bgv.Evaluator, ct *rlwe.Ciphertext, vector []uint64) (*rlwe.Ciphertext, error) {
+	return evaluator.MulNew(ct, vector)
+}
+
+// MulScalar multiplica un PlaintextLabeledciphertext por un escalar público k: (k·a, k·β)
+func MulScalar(params Parameters, labeledciphertext PlaintextLabeledciphertext, k uint64) (PlaintextLabeledciphertext, error) {
+	result, err := mulPlain(params, labeledciphertext, broadcast(params, k%params.PlaintextModulus()))
+	if err != nil {
+		return labeledciphertext, err
+	}
+
+	result.meta = deriveMetadata("MulScalar", false, labeledciphertext.meta)
+
+	return result, nil
+}
+
+// MulScalarOverflow multiplica un CiphertextLabeledciphertext por un escalar público k:
+// α se multiplica por k y en cada término de β basta con multiplicar el primer factor
+func MulScalarOverflow(params Parameters, labeledciphertext CiphertextLabeledciphertext, k uint64) (CiphertextLabeledciphertext, error) {
+	result, err := mulPlainOverflow(params, labeledciphertext, broadcast(params, k%params.PlaintextModulus()))
+	if err != nil {
+		return result, err
+	}
+
+	result.meta = deriveMetadata("MulScalarOverflow", false, labeledciphertext.meta)
+
+	return result, nil
+}
+
+// mulPlainOverflow multiplica slot a slot un CiphertextLabeledciphertext por un vector público v:
+// α se multiplica por v y en cada término de β basta con multiplicar el primer factor
+func mulPlainOverflow(params Parameters, labeledciphertext CiphertextLabeledciphertext, vector []uint64) (CiphertextLabeledciphertext, error) {
+	var result CiphertextLabeledciphertext
+
+	evaluator := bgv.NewEvaluator(params.Parameters, nil)
+
+	// α ← v·α
+	alpha, err := scaleCiphertext(evaluator, (*rlwe.Ciphertext)(labeledciphertext.elementsA), vector)
+	if err != nil {
+		return result, err
+	}
+	result.elementsA = (*CiphertextElement)(alpha)
+
+	// βᵢ₀ ← v·βᵢ₀
+	result.elementsB = make([][]rlwe.Ciphertext, len(labeledciphertext.elementsB))
+	for i := range labeledciphertext.elementsB {
+		result.elementsB[i] = make([]rlwe.Ciphertext, len(labeledciphertext.elementsB[i]))
+		copy(result.elementsB[i], labeledciphertext.elementsB[i])
+		if len(result.elementsB[i]) == 0 {
+			continue
+		}
+
+		first, err := scaleCiphertext(evaluator, &labeledciphertext.elementsB[i][0], vector)
+		if err != nil {
+			return result, err
+		}
+		result.elementsB[i][0] = *first
+	}
+
+	return result, nil
+}
+
+// mulPlain multiplica slot a slot un PlaintextLabeledciphertext por un vector público
+func mulPlain(params Parameters, labeledciphertext PlaintextLabeledciphertext, vector []uint64) (PlaintextLabeledciphertext, error) {
+	var result PlaintextLabeledciphertext
+
+	// a ← v·a, con mulMod para que el producto no desborde con t de más de 32 bits
+	t := params.PlaintextModulus()
+	result.elementsA = make(PlaintextElements, len(labeledciphertext.elementsA))
+	for i, elementA := range labeledciphertext.elementsA {
+		if i < len(vector) {
+			result.elementsA[i] = mulMod(elementA, vector[i]%t, t)
+		}
+	}
+
+	// β ← v·β
+	beta, err := scaleCiphertext(bgv.NewEvaluator(params.Parameters, nil), &labeledciphertext.elementsB[0][0], vector)
+	if err != nil {
+		return result, err
+	}
+	result.elementsB = [][]rlwe.Ciphertext{{*beta}}
+
+	return result, nil
+}
+
+// MulPlain multiplica slot a slot un PlaintextLabeledciphertext por un vector público de pesos.
+// No usa máscaras ni cifra nada, y el ruido crece mucho menos que con Mult.
+// Los slots sin peso quedan a 0.
+func MulPlain(params Parameters, labeledciphertext PlaintextLabeledciphertext, vector []uint64) (PlaintextLabeledciphertext, error) {
+	result, err := mulPlain(params, labeledciphertext, vector)
+	if err != nil {
+		return labeledciphertext, err
+	}
+
+	result.meta = deriveMetadata("MulPlain", false, labeledciphertext.meta)
+
+	return result, nil
+}
+
+// LinearCombination calcula Σ wᵢ·ctᵢ en una sola pasada, con un único encoder y evaluador
+// para todos los términos y un único cifrado de salida
+func LinearCombination(params Parameters, labeledciphertexts []PlaintextLabeledciphertext, weights []uint64) (PlaintextLabeledciphertext, error) {
+	var result PlaintextLabeledciphertext
+
+	if len(labeledciphertexts) == 0 || len(labeledciphertexts) != len(weights) {
+		return result, fmt.Errorf("labeling: %d labeled ciphertexts y %d pesos", len(labeledciphertexts), len(weights))
+	}
+
+	t := params.PlaintextModulus()
+	evaluator := bgv.NewEvaluator(params.Parameters, nil)
+
+	// a ← Σ wᵢ·aᵢ, con mulMod para que los productos no desborden con t de más de 32 bits
+	result.elementsA = make(PlaintextElements, len(labeledciphertexts[0].elementsA))
+	for k, labeledciphertext := range labeledciphertexts {
+		w := weights[k] % t
+		for i := range result.elementsA {
+			result.elementsA[i] = (result.elementsA[i] + mulMod(w, labeledciphertext.elementsA[i], t)) % t
+		}
+	}
+
+	// β ← Σ wᵢ·βᵢ, acumulando sobre el primer producto
+	beta, err := evaluator.MulNew(&labeledciphertexts[0].elementsB[0][0], weights[0]%t)
+	if err != nil {
+		return result, err
+	}
+	for k := 1; k < len(labeledciphertexts); k++ {
+		if err := evaluator.MulThenAdd(&labeledciphertexts[k].elementsB[0][0], weights[k]%t, beta); err != nil {
+			return result, err
+		}
+	}
+	result.elementsB = [][]rlwe.Ciphertext{{*beta}}
+
+	metas := make([]metadata, len(labeledciphertexts))
+	for k, labeledciphertext := range labeledciphertexts {
+		metas[k] = labeledciphertext.meta
+	}
+	result.meta = deriveMetadata("LinearCombination", false, metas...)
+
+	return result, nil
+}
@@ -0,0 +1,186 @@
+// Copyright 2025 Juan Martín Pérez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Huellas de claves y atestaciones.
+//
+// La huella de una clave es el SHA-256 de su tipo y su serialización canónica, de modo que dos
+// claves iguales tienen siempre la misma huella y claves de tipos distintos nunca coinciden. Una
+// atestación es un sobre firmado con Ed25519 que vincula la huella de una clave pública, de
+// relinealización, de Galois o de evaluación con una identidad. Un servicio de evaluación que
+// confía en la clave de firma del emisor puede comprobar así de quién es la clave que aplica.
+
+package labeling
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrInvalidAttestation se devuelve cuando una atestación no es válida para la clave o el firmante
+var ErrInvalidAttestation = errors.New("labeling: atestación no válida")
+
+// attestationDomain separa las firmas de atestaciones de cualquier otro uso de la clave de firma
+const attestationDomain = "labeling-attestation-v1"
+
+// Fingerprint es la huella estable de una clave
+type Fingerprint [sha256.Size]byte
+
+// String devuelve la huella en hexadecimal
+func (f Fingerprint) String() string {
+	return hex.EncodeToString(f[:])
+}
+
+// KeyFingerprint calcula la huella de una clave de cualquiera de los tipos de Key o de una clave
+// pública de firma Ed25519
+func KeyFingerprint(key any) (Fingerprint, error) {
+	var tag keyTag
+	var data []byte
+
+	if signer, ok := key.(ed25519.PublicKey); ok {
+		if len(signer) != ed25519.PublicKeySize {
+			return Fingerprint{}, fmt.Errorf("labeling: clave de firma de tamaño %d", len(signer))
+		}
+		tag, data = keyTagSigningKey, signer
+	} else {
+		var err error
+		if tag, err = keyTagOf(key); err != nil {
+			return Fingerprint{}, err
+		}
+		if data, err = key.(encoding.BinaryMarshaler).MarshalBinary(); err != nil {
+			return Fingerprint{}, err
+		}
+	}
+
+	hash := sha256.New()
+	hash.Write([]byte{byte(tag)})
+	hash.Write(data)
+
+	var fingerprint Fingerprint
+	copy(fingerprint[:], hash.Sum(nil))
+	return fingerprint, nil
+}
+
+// Attestation vincula la huella de una clave con una identidad, firmada por un emisor
+type Attestation struct {
+	// Identity es el titular de la clave
+	Identity Identity
+	// Fingerprint es la huella de la clave atestiguada
+	Fingerprint Fingerprint
+	// IssuedAt es el instante de emisión, con precisión de segundos
+	IssuedAt time.Time
+	// Signer es la clave pública de firma del emisor
+	Signer ed25519.PublicKey
+	// Signature es la firma Ed25519 del mensaje canónico
+	Signature []byte
+}
+
+// message devuelve el mensaje canónico que se firma
+func (a Attestation) message() []byte {
+	msg := []byte(attestationDomain)
+	msg = binary.LittleEndian.AppendUint32(msg, uint32(len(a.Identity)))
+	msg = append(msg, a.Identity...)
+	msg = append(msg, a.Fingerprint[:]...)
+	return binary.LittleEndian.AppendUint64(msg, uint64(a.IssuedAt.Unix()))
+}
+
+// Attest emite una atestación que vincula key con identity, firmada con signer
+func Attest(signer ed25519.PrivateKey, identity Identity, key any) (*Attestation, error) {
+	fingerprint, err := KeyFingerprint(key)
+	if err != nil {
+		return nil, err
+	}
+
+	attestation := &Attestation{
+		Identity:    identity,
+		Fingerprint: fingerprint,
+		IssuedAt:    time.Unix(time.Now().Unix(), 0),
+		Signer:      signer.Public().(ed25519.PublicKey),
+	}
+	attestation.Signature = ed25519.Sign(signer, attestation.message())
+
+	return attestation, nil
+}
+
+// Verify comprueba que la atestación está firmada por trusted y corresponde a key.
+// trusted es la clave de firma en la que confía el verificador, nunca la que trae la atestación.
+func (a Attestation) Verify(trusted ed25519.PublicKey, key any) error {
+	if !bytes.Equal(a.Signer, trusted) {
+		return fmt.Errorf("%w: firmante desconocido", ErrInvalidAttestation)
+	}
+
+	if !ed25519.Verify(trusted, a.message(), a.Signature) {
+		return fmt.Errorf("%w: firma incorrecta", ErrInvalidAttestation)
+	}
+
+	fingerprint, err := KeyFingerprint(key)
+	if err != nil {
+		return err
+	}
+	if fingerprint != a.Fingerprint {
+		return fmt.Errorf("%w: la clave no corresponde a %q", ErrInvalidAttestation, a.Identity)
+	}
+
+	return nil
+}
+
+// MarshalBinary serializa la atestación: el mensaje canónico seguido de la clave de firma y la firma
+func (a Attestation) MarshalBinary() ([]byte, error) {
+	if len(a.Signer) != ed25519.PublicKeySize || len(a.Signature) != ed25519.SignatureSize {
+		return nil, fmt.Errorf("labeling: atestación incompleta")
+	}
+
+	data := a.message()
+	data = append(data, a.Signer...)
+	return append(data, a.Signature...), nil
+}
+
+// UnmarshalBinary reconstruye la atestación a partir de su serialización binaria. No comprueba la
+// firma: debe llamarse a Verify.
+func (a *Attestation) UnmarshalBinary(data []byte) error {
+	if !bytes.HasPrefix(data, []byte(attestationDomain)) {
+		return fmt.Errorf("labeling: la serialización no es una atestación")
+	}
+	data = data[len(attestationDomain):]
+
+	if len(data) < 4 {
+		return fmt.Errorf("labeling: atestación truncada")
+	}
+	n := binary.LittleEndian.Uint32(data)
+	data = data[4:]
+
+	if uint64(len(data)) != uint64(n)+sha256.Size+8+ed25519.PublicKeySize+ed25519.SignatureSize {
+		return fmt.Errorf("labeling: atestación truncada")
+	}
+
+	var attestation Attestation
+	attestation.Identity = Identity(data[:n])
+	data = data[n:]
+	copy(attestation.Fingerprint[:], data)
+	data = data[sha256.Size:]
+	attestation.IssuedAt = time.Unix(int64(binary.LittleEndian.Uint64(data)), 0)
+	data = data[8:]
+	attestation.Signer = append(ed25519.PublicKey{}, data[:ed25519.PublicKeySize]...)
+	attestation.Signature = append([]byte{}, data[ed25519.PublicKeySize:]...)
+
+	*a = attestation
+
+	return nil
+}
@@ -0,0 +1,118 @@
+// Copyright 2025 Juan Martín Pérez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package labeling
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/tuneinsight/lattigo/v6/core/rlwe"
+	"github.com/tuneinsight/lattigo/v6/schemes/bgv"
+)
+
+// BatchOption modifica la ejecución de las operaciones por lotes
+type BatchOption func(*batchOptions)
+
+type batchOptions struct {
+	workers int
+}
+
+// WithWorkers fija el número de goroutines que procesan el lote. Por defecto es 1.
+func WithWorkers(workers int) BatchOption {
+	return func(o *batchOptions) {
+		o.workers = workers
+	}
+}
+
+// runBatch llama a work para cada índice de [0, n) repartiendo el trabajo entre los workers.
+// Cada worker recibe su propia copia del evaluador, que no es seguro para uso concurrente.
+// Si alguna llamada falla devuelve el error del menor índice.
+func runBatch(params Parameters, n int, opts []BatchOption, work func(evaluator *bgv.Evaluator, i int) error) error {
+	options := batchOptions{workers: 1}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	workers := max(1, min(options.workers, n))
+
+	evaluator := bgv.NewEvaluator(params.Parameters, nil)
+	errs := make([]error, n)
+
+	var next atomic.Int64
+	var wg sync.WaitGroup
+	for w := range workers {
+		// El primer worker usa el evaluador original y el resto copias que comparten las claves
+		workerEvaluator := evaluator
+		if w > 0 {
+			workerEvaluator = evaluator.ShallowCopy()
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := int(next.Add(1) - 1); i < n; i = int(next.Add(1) - 1) {
+				errs[i] = work(workerEvaluator, i)
+			}
+		}()
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			return fmt.Errorf("labeling: labeled ciphertext %d del lote: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// ApplyEvaluationKeyBatch aplica la misma clave de evaluación a un lote de PlaintextLabeledciphertext,
+// por ejemplo para recifrar un conjunto de datos completo hacia un nuevo destinatario.
+// Con WithWorkers el lote se procesa en paralelo.
+func ApplyEvaluationKeyBatch(params Parameters, evalKey rlwe.EvaluationKey, labeledciphertexts []PlaintextLabeledciphertext, opts ...BatchOption) ([]PlaintextLabeledciphertext, error) {
+	results := make([]PlaintextLabeledciphertext, len(labeledciphertexts))
+
+	err := runBatch(params, len(labeledciphertexts), opts, func(evaluator *bgv.Evaluator, i int) error {
+		result, err := applyEvaluationKey(evaluator, &evalKey, labeledciphertexts[i])
+		if err != nil {
+			return err
+		}
+		results[i] = *result
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// ApplyEvaluationKeyOverflowBatch aplica la misma clave de evaluación a un lote de CiphertextLabeledciphertext
+func ApplyEvaluationKeyOverflowBatch(params Parameters, evalKey rlwe.EvaluationKey, labeledciphertexts []CiphertextLabeledciphertext, opts ...BatchOption) ([]CiphertextLabeledciphertext, error) {
+	results := make([]CiphertextLabeledciphertext, len(labeledciphertexts))
+
+	err := runBatch(params, len(labeledciphertexts), opts, func(evaluator *bgv.Evaluator, i int) error {
+		result, err := applyEvaluationKeyOverflow(evaluator, &evalKey, labeledciphertexts[i])
+		if err != nil {
+			return err
+		}
+		results[i] = *result
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
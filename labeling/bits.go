@@ -0,0 +1,397 @@
+// Copyright 2025 Juan Martín Pérez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package labeling
+
+import (
+	"fmt"
+	"math/bits"
+
+	"github.com/tuneinsight/lattigo/v6/core/rlwe"
+)
+
+// BitLabeledciphertext es un vector de enteros cifrado bit a bit: un PlaintextLabeledciphertext por
+// bit, del menos al más significativo, con cada slot a 0 o 1
+type BitLabeledciphertext struct {
+	bits []PlaintextLabeledciphertext
+}
+
+// Width devuelve el número de bits
+func (b BitLabeledciphertext) Width() int {
+	return len(b.bits)
+}
+
+// Bit devuelve el labeled ciphertext del bit i, empezando por el menos significativo
+func (b BitLabeledciphertext) Bit(i int) PlaintextLabeledciphertext {
+	return b.bits[i]
+}
+
+// EncryptBits cifra cada valor descompuesto en width bits.
+// Los valores deben ser menores que 2^width.
+func EncryptBits(params Parameters, key rlwe.EncryptionKey, values []uint64, width int) (BitLabeledciphertext, error) {
+	if width <= 0 || width > 64 {
+		return BitLabeledciphertext{}, fmt.Errorf("labeling: anchura de %d bits no soportada", width)
+	}
+
+	result := BitLabeledciphertext{bits: make([]PlaintextLabeledciphertext, width)}
+	bit := make([]uint64, len(values))
+	for i := range width {
+		for j, value := range values {
+			if width < 64 && value>>width != 0 {
+				return BitLabeledciphertext{}, fmt.Errorf("labeling: el valor %d no cabe en %d bits", value, width)
+			}
+			bit[j] = (value >> i) & 1
+		}
+
+		var err error
+		if result.bits[i], err = Encrypt(params, key, bit); err != nil {
+			return BitLabeledciphertext{}, err
+		}
+	}
+
+	return result, nil
+}
+
+// DecryptBits descifra cada bit y recompone los valores en claro
+func DecryptBits(params Parameters, key *rlwe.SecretKey, labeledciphertext BitLabeledciphertext) ([]uint64, error) {
+	values := make([]uint64, params.MaxSlots())
+	for i, bit := range labeledciphertext.bits {
+		decrypted, err := Decrypt(params, key, bit)
+		if err != nil {
+			return nil, err
+		}
+		for j := range values {
+			values[j] |= (decrypted[j] & 1) << i
+		}
+	}
+	return values, nil
+}
+
+// Recompose convierte la representación bit a bit en un PlaintextLabeledciphertext con Σ 2^i·bᵢ,
+// sin multiplicaciones entre cifrados. El resultado es exacto si 2^width ≤ t.
+func Recompose(params Parameters, labeledciphertext BitLabeledciphertext) (PlaintextLabeledciphertext, error) {
+	weights := make([]uint64, labeledciphertext.Width())
+	for i := range weights {
+		weights[i] = (uint64(1) << i) % params.PlaintextModulus()
+	}
+
+	result, err := LinearCombination(params, labeledciphertext.bits, weights)
+	if err != nil {
+		return result, err
+	}
+
+	metas := make([]metadata, labeledciphertext.Width())
+	for i, bit := range labeledciphertext.bits {
+		metas[i] = bit.meta
+	}
+	result.meta = deriveMetadata("Recompose", false, metas...)
+
+	return result, nil
+}
+
+// ShiftLeft desplaza k bits a la izquierda conservando la anchura: los k bits altos se descartan y
+// los bajos se rellenan con cifrados de 0. Solo reordena los bits, sin operar sobre los cifrados.
+func ShiftLeft(params Parameters, labeledciphertext BitLabeledciphertext, k int, key rlwe.EncryptionKey) (BitLabeledciphertext, error) {
+	width := labeledciphertext.Width()
+	k = min(max(k, 0), width)
+
+	result := BitLabeledciphertext{bits: make([]PlaintextLabeledciphertext, width)}
+	for i := range width {
+		if i < k {
+			zero, err := Encrypt(params, key, nil)
+			if err != nil {
+				return BitLabeledciphertext{}, err
+			}
+			result.bits[i] = zero
+			continue
+		}
+		result.bits[i] = labeledciphertext.bits[i-k]
+	}
+
+	return result, nil
+}
+
+// ShiftRight desplaza k bits a la derecha, es decir, divide por 2^k truncando. La anchura se reduce
+// en k bits, sin operar sobre los cifrados.
+func ShiftRight(labeledciphertext BitLabeledciphertext, k int) BitLabeledciphertext {
+	k = min(max(k, 0), labeledciphertext.Width())
+	return BitLabeledciphertext{bits: labeledciphertext.bits[k:]}
+}
+
+// Truncate conserva los width bits bajos, es decir, reduce los valores módulo 2^width.
+// Combinado con Recompose permite acotar el rango de un valor sin comparaciones.
+func Truncate(labeledciphertext BitLabeledciphertext, width int) BitLabeledciphertext {
+	width = min(max(width, 0), labeledciphertext.Width())
+	return BitLabeledciphertext{bits: labeledciphertext.bits[:width]}
+}
+
+// Multiplications devuelve la mayor profundidad multiplicativa de los bits
+func (b BitLabeledciphertext) Multiplications() int {
+	multiplications := 0
+	for _, bit := range b.bits {
+		multiplications = max(multiplications, bit.Multiplications())
+	}
+	return multiplications
+}
+
+// bitComparison es la comparación de un tramo de bits: lt vale 1 si a < b en el tramo y eq vale 1
+// si a y b coinciden en él
+type bitComparison struct {
+	lt PlaintextLabeledciphertext
+	eq PlaintextLabeledciphertext
+}
+
+// checkComparisonDepth devuelve ErrInsufficientDepth si comparar width bits con multiplications
+// multiplicaciones acumuladas, más leafDepth para las comparaciones bit a bit, agota la profundidad.
+// El árbol de mergeComparisons añade ⌈log2 width⌉ multiplicaciones. Las puertas usan Mult, que deja
+// el resultado en modo texto plano, así que la última multiplicación debe dejar un nivel libre.
+func checkComparisonDepth(params Parameters, operation string, multiplications, leafDepth, width int) error {
+	required := multiplications + leafDepth + bits.Len(uint(width-1))
+	if available := params.MaxLevel() - 1; required > available {
+		return fmt.Errorf("%w: %s de %d bits requiere profundidad %d y la cadena de módulos solo admite %d", ErrInsufficientDepth, operation, width, required, available)
+	}
+	return nil
+}
+
+// rescaleProduct reescala en BGV el resultado de una multiplicación de la comparación. Mult no
+// reescala, y sin ello el ruido crece con el grado del circuito en lugar de con su profundidad.
+func rescaleProduct(params Parameters, labeledciphertext PlaintextLabeledciphertext) (PlaintextLabeledciphertext, error) {
+	if params.Scheme() != SchemeBGV || labeledciphertext.Level() == 0 {
+		return labeledciphertext, nil
+	}
+	return Rescale(params, labeledciphertext)
+}
+
+// multRescale multiplica con Mult y reescala el producto
+func multRescale(params Parameters, labeledciphertext1, labeledciphertext2 PlaintextLabeledciphertext, key rlwe.EncryptionKey, evk *rlwe.MemEvaluationKeySet) (PlaintextLabeledciphertext, error) {
+	product, err := Mult(params, labeledciphertext1, labeledciphertext2, key, evk)
+	if err != nil {
+		return product, err
+	}
+	return rescaleProduct(params, product)
+}
+
+// mergeComparisons combina por parejas las comparaciones de los bits, del menos al más significativo.
+// Con el tramo alto h y el bajo l, a < b si h.lt, o si h.eq y l.lt; los dos casos son excluyentes, así
+// que basta con sumarlos. Cada nivel del árbol cuesta una multiplicación.
+func mergeComparisons(params Parameters, comparisons []bitComparison, key rlwe.EncryptionKey, evk *rlwe.MemEvaluationKeySet) (bitComparison, error) {
+	for len(comparisons) > 1 {
+		merged := make([]bitComparison, 0, (len(comparisons)+1)/2)
+		for i := 0; i+1 < len(comparisons); i += 2 {
+			low, high := comparisons[i], comparisons[i+1]
+
+			carried, err := multRescale(params, high.eq, low.lt, key, evk)
+			if err != nil {
+				return bitComparison{}, err
+			}
+			lt, err := Sum(params.Parameters, high.lt, carried)
+			if err != nil {
+				return bitComparison{}, err
+			}
+			eq, err := multRescale(params, high.eq, low.eq, key, evk)
+			if err != nil {
+				return bitComparison{}, err
+			}
+
+			merged = append(merged, bitComparison{lt: lt, eq: eq})
+		}
+
+		// El tramo más significativo sin pareja pasa al siguiente nivel
+		if len(comparisons)%2 == 1 {
+			merged = append(merged, comparisons[len(comparisons)-1])
+		}
+		comparisons = merged
+	}
+
+	return comparisons[0], nil
+}
+
+// compareBits compara a y b bit a bit: eqᵢ = ¬(aᵢ ⊕ bᵢ) y ltᵢ = ¬aᵢ·bᵢ, con una multiplicación cada una
+func compareBits(params Parameters, a, b BitLabeledciphertext, operation string, key rlwe.EncryptionKey, evk *rlwe.MemEvaluationKeySet) (bitComparison, error) {
+	if a.Width() == 0 || a.Width() != b.Width() {
+		return bitComparison{}, fmt.Errorf("labeling: %s de %d y %d bits", operation, a.Width(), b.Width())
+	}
+	if err := checkComparisonDepth(params, operation, max(a.Multiplications(), b.Multiplications()), 1, a.Width()); err != nil {
+		return bitComparison{}, err
+	}
+
+	comparisons := make([]bitComparison, a.Width())
+	for i := range comparisons {
+		xor, err := Xor(params, a.bits[i], b.bits[i], key, evk)
+		if err != nil {
+			return bitComparison{}, err
+		}
+		if xor, err = rescaleProduct(params, xor); err != nil {
+			return bitComparison{}, err
+		}
+		if comparisons[i].eq, err = Not(params, xor); err != nil {
+			return bitComparison{}, err
+		}
+
+		notA, err := Not(params, a.bits[i])
+		if err != nil {
+			return bitComparison{}, err
+		}
+		lt, err := And(params, notA, b.bits[i], key, evk)
+		if err != nil {
+			return bitComparison{}, err
+		}
+		if comparisons[i].lt, err = rescaleProduct(params, lt); err != nil {
+			return bitComparison{}, err
+		}
+	}
+
+	return mergeComparisons(params, comparisons, key, evk)
+}
+
+// EqualBits devuelve un cifrado de 1 en los slots en que a = b y de 0 en el resto. La comparación es
+// exacta para cualquier anchura, sin cotas ni polinomios de grado alto: consume 1 + ⌈log2 width⌉
+// multiplicaciones y devuelve ErrInsufficientDepth si la cadena de módulos no las admite.
+func EqualBits(params Parameters, a, b BitLabeledciphertext, key rlwe.EncryptionKey, evk *rlwe.MemEvaluationKeySet) (PlaintextLabeledciphertext, error) {
+	comparison, err := compareBits(params, a, b, "EqualBits", key, evk)
+	if err != nil {
+		return PlaintextLabeledciphertext{}, err
+	}
+
+	result := comparison.eq
+	result.meta = deriveMetadata("EqualBits", false, comparison.eq.meta)
+
+	return result, nil
+}
+
+// LessThanBits devuelve un cifrado de 1 en los slots en que a < b, como enteros sin signo, y de 0 en
+// el resto. Es exacta y consume 1 + ⌈log2 width⌉ multiplicaciones, como EqualBits.
+func LessThanBits(params Parameters, a, b BitLabeledciphertext, key rlwe.EncryptionKey, evk *rlwe.MemEvaluationKeySet) (PlaintextLabeledciphertext, error) {
+	comparison, err := compareBits(params, a, b, "LessThanBits", key, evk)
+	if err != nil {
+		return PlaintextLabeledciphertext{}, err
+	}
+
+	result := comparison.lt
+	result.meta = deriveMetadata("LessThanBits", false, comparison.lt.meta)
+
+	return result, nil
+}
+
+// lessThanConst devuelve un cifrado de x < c. Los bits de c son públicos, así que las comparaciones
+// bit a bit no multiplican: si cᵢ = 1, ltᵢ = ¬xᵢ y eqᵢ = xᵢ; si cᵢ = 0, ltᵢ = 0 y eqᵢ = ¬xᵢ.
+func lessThanConst(params Parameters, x BitLabeledciphertext, c uint64, operation string, key rlwe.EncryptionKey, evk *rlwe.MemEvaluationKeySet) (PlaintextLabeledciphertext, error) {
+	width := x.Width()
+	if width == 0 {
+		return PlaintextLabeledciphertext{}, fmt.Errorf("labeling: %s sin bits", operation)
+	}
+
+	// Todos los valores de width bits son menores que c
+	if width < 64 && c>>width != 0 {
+		return Encrypt(params, key, broadcast(params, 1))
+	}
+
+	if err := checkComparisonDepth(params, operation, x.Multiplications(), 0, width); err != nil {
+		return PlaintextLabeledciphertext{}, err
+	}
+
+	comparisons := make([]bitComparison, width)
+	for i := range comparisons {
+		notX, err := Not(params, x.bits[i])
+		if err != nil {
+			return PlaintextLabeledciphertext{}, err
+		}
+
+		if (c>>i)&1 == 1 {
+			comparisons[i] = bitComparison{lt: notX, eq: x.bits[i]}
+			continue
+		}
+
+		zero, err := Encrypt(params, key, nil)
+		if err != nil {
+			return PlaintextLabeledciphertext{}, err
+		}
+		comparisons[i] = bitComparison{lt: zero, eq: notX}
+	}
+
+	comparison, err := mergeComparisons(params, comparisons, key, evk)
+	if err != nil {
+		return PlaintextLabeledciphertext{}, err
+	}
+
+	return comparison.lt, nil
+}
+
+// LessThanConstBits devuelve un cifrado de 1 en los slots en que x < c, con c público. Consume
+// ⌈log2 width⌉ multiplicaciones, una menos que LessThanBits.
+func LessThanConstBits(params Parameters, x BitLabeledciphertext, c uint64, key rlwe.EncryptionKey, evk *rlwe.MemEvaluationKeySet) (PlaintextLabeledciphertext, error) {
+	result, err := lessThanConst(params, x, c, "LessThanConstBits", key, evk)
+	if err != nil {
+		return result, err
+	}
+
+	result.meta = deriveMetadata("LessThanConstBits", false, result.meta)
+
+	return result, nil
+}
+
+// InRangeBits devuelve un cifrado de 1 en los slots en que lo ≤ x < hi y de 0 en el resto, con lo y
+// hi públicos, para comprobar rangos sin descifrar. Consume ⌈log2 width⌉ multiplicaciones, más una
+// para combinar las dos cotas si ambas acotan.
+func InRangeBits(params Parameters, x BitLabeledciphertext, lo, hi uint64, key rlwe.EncryptionKey, evk *rlwe.MemEvaluationKeySet) (PlaintextLabeledciphertext, error) {
+	if lo >= hi {
+		return PlaintextLabeledciphertext{}, fmt.Errorf("labeling: rango vacío [%d, %d)", lo, hi)
+	}
+
+	// lo ≤ x es ¬(x < lo)
+	atLeastLo := func() (PlaintextLabeledciphertext, error) {
+		below, err := lessThanConst(params, x, lo, "InRangeBits", key, evk)
+		if err != nil {
+			return below, err
+		}
+		return Not(params, below)
+	}
+
+	// Con hi ≥ 2^width la cota superior no descarta ningún valor
+	width := x.Width()
+	boundedAbove := width >= 64 || hi>>width == 0
+
+	var result PlaintextLabeledciphertext
+	var err error
+	switch {
+	case lo == 0:
+		result, err = lessThanConst(params, x, hi, "InRangeBits", key, evk)
+
+	case !boundedAbove:
+		result, err = atLeastLo()
+
+	default:
+		if err := checkComparisonDepth(params, "InRangeBits", x.Multiplications(), 1, width); err != nil {
+			return PlaintextLabeledciphertext{}, err
+		}
+
+		var above, below PlaintextLabeledciphertext
+		if above, err = atLeastLo(); err != nil {
+			return above, err
+		}
+		if below, err = lessThanConst(params, x, hi, "InRangeBits", key, evk); err != nil {
+			return below, err
+		}
+		if result, err = And(params, above, below, key, evk); err == nil {
+			result, err = rescaleProduct(params, result)
+		}
+	}
+	if err != nil {
+		return result, err
+	}
+
+	result.meta = deriveMetadata("InRangeBits", false, result.meta)
+
+	return result, nil
+}
@@ -0,0 +1,214 @@
+// Copyright 2025 Juan Martín Pérez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Comprobación de labeled ciphertexts recibidos.
+//
+// Los decodificadores (UnmarshalBinary, UnmarshalJSON) no conocen los parámetros, así que solo
+// comprueban la estructura de la serialización. El resto de la API supone operandos bien formados
+// para params: un elemento A por slot y reducido módulo t, y cifrados de grado 1 del anillo de
+// params. Check lo comprueba y se aplica en todos los puntos de entrada que conocen los parámetros
+// (Container.Open, UnmarshalWire, LabeledciphertextFromProto y los servicios de evaluación).
+
+package labeling
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/tuneinsight/lattigo/v6/core/rlwe"
+)
+
+// ErrMalformed se devuelve cuando un labeled ciphertext no está bien formado para los parámetros
+var ErrMalformed = errors.New("labeling: labeled ciphertext mal formado")
+
+// Check comprueba que el labeled ciphertext está bien formado para params: en forma plaintext, un
+// elemento A por slot, cada uno menor que t, y un único β; en forma overflow, α presente. El historial
+// de operaciones no puede superar las 64 entradas que guarda deriveMetadata. Todas las componentes
+// cifradas deben ser de grado 1, de un nivel de la cadena de params, con polinomios de N coeficientes
+// reducidos módulo cada primo y en el dominio NTT de params.
+func (lc Labeledciphertext[T]) Check(params Parameters) error {
+	switch elementsA := any(lc.elementsA).(type) {
+	case PlaintextElements:
+		if len(elementsA) != params.MaxSlots() {
+			return fmt.Errorf("%w: %d elementos A para %d slots", ErrMalformed, len(elementsA), params.MaxSlots())
+		}
+		t := params.PlaintextModulus()
+		for i, elementA := range elementsA {
+			if elementA >= t {
+				return fmt.Errorf("%w: el elemento A %d no está reducido módulo t = %d", ErrMalformed, i, t)
+			}
+		}
+		if len(lc.elementsB) != 1 || len(lc.elementsB[0]) != 1 {
+			return fmt.Errorf("%w: un labeled ciphertext en forma plaintext requiere un único β", ErrMalformed)
+		}
+
+	case *CiphertextElement:
+		if elementsA == nil {
+			return fmt.Errorf("%w: labeled ciphertext sin α", ErrMalformed)
+		}
+
+	default:
+		return fmt.Errorf("%w: comprobación de %T", ErrUnsupportedOperands, lc.elementsA)
+	}
+
+	if len(lc.meta.operations) > maxOperations {
+		return fmt.Errorf("%w: historial de %d operaciones, máximo %d", ErrMalformed, len(lc.meta.operations), maxOperations)
+	}
+
+	for i, ct := range lc.ciphertexts() {
+		if err := checkCiphertext(params, ct); err != nil {
+			return fmt.Errorf("%w: componente cifrada %d: %s", ErrMalformed, i, err)
+		}
+	}
+
+	return nil
+}
+
+// CheckOperand aplica Check a un labeled ciphertext de cualquier forma
+func CheckOperand(params Parameters, op Operand) error {
+	switch lc := op.(type) {
+	case PlaintextLabeledciphertext:
+		return lc.Check(params)
+	case CiphertextLabeledciphertext:
+		return lc.Check(params)
+	}
+
+	return fmt.Errorf("%w: comprobación de %T", ErrUnsupportedOperands, op)
+}
+
+// checkCiphertext comprueba que ct es un cifrado de grado 1 del anillo de params
+func checkCiphertext(params Parameters, ct *rlwe.Ciphertext) error {
+	if ct.MetaData == nil {
+		return errors.New("sin metadatos")
+	}
+	if ct.Degree() != 1 {
+		return fmt.Errorf("grado %d, se esperaba 1", ct.Degree())
+	}
+	if ct.IsNTT != params.NTTFlag() {
+		return errors.New("dominio NTT incorrecto")
+	}
+
+	level := ct.Level()
+	if level < 0 || level > params.MaxLevel() {
+		return fmt.Errorf("nivel %d fuera de la cadena de módulos", level)
+	}
+
+	moduli := params.RingQ().ModuliChain()
+	for _, poly := range ct.Value {
+		if poly.Level() != level {
+			return errors.New("polinomios de niveles distintos")
+		}
+		for i, coeffs := range poly.Coeffs {
+			if len(coeffs) != params.N() {
+				return fmt.Errorf("%d coeficientes, se esperaban %d", len(coeffs), params.N())
+			}
+			for _, coeff := range coeffs {
+				if coeff >= moduli[i] {
+					return fmt.Errorf("coeficiente no reducido módulo el primo %d", i)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// Check comprueba que las claves del paquete son del anillo de params: clave pública y claves de
+// evaluación de grado 1, con N coeficientes y en los niveles máximos de Q y P, con la descomposición
+// de la clave que esperan los evaluadores, y elementos de Galois válidos para el anillo. Una clave
+// de otros parámetros haría fallar a lattigo con un pánico al evaluar, así que se rechaza antes con
+// ErrMalformed.
+func (b *KeyBundle) Check(params Parameters) error {
+	if b.PublicKey != nil {
+		if err := checkVectorQP(params, b.PublicKey.Value); err != nil {
+			return fmt.Errorf("%w: clave pública: %s", ErrMalformed, err)
+		}
+	}
+
+	if b.RelinearizationKey != nil {
+		if err := checkGadgetCiphertext(params, &b.RelinearizationKey.GadgetCiphertext); err != nil {
+			return fmt.Errorf("%w: clave de relinealización: %s", ErrMalformed, err)
+		}
+	}
+
+	nthRoot := uint64(params.RingQ().NthRoot())
+	for i, gk := range b.GaloisKeys {
+		if gk == nil {
+			return fmt.Errorf("%w: clave de Galois %d vacía", ErrMalformed, i)
+		}
+		if gk.NthRoot != nthRoot || gk.GaloisElement&1 == 0 || gk.GaloisElement >= nthRoot {
+			return fmt.Errorf("%w: elemento de Galois %d no válido para 2N = %d", ErrMalformed, gk.GaloisElement, nthRoot)
+		}
+		if err := checkGadgetCiphertext(params, &gk.GadgetCiphertext); err != nil {
+			return fmt.Errorf("%w: clave de Galois %d: %s", ErrMalformed, gk.GaloisElement, err)
+		}
+	}
+
+	for id, evk := range b.EvaluationKeys {
+		if evk == nil {
+			return fmt.Errorf("%w: clave de cambio de clave %s vacía", ErrMalformed, id)
+		}
+		if err := checkGadgetCiphertext(params, &evk.GadgetCiphertext); err != nil {
+			return fmt.Errorf("%w: clave de cambio de clave %s: %s", ErrMalformed, id, err)
+		}
+	}
+
+	return nil
+}
+
+// checkGadgetCiphertext comprueba que ct tiene la forma de una clave de evaluación de params generada
+// en los niveles máximos de Q y P
+func checkGadgetCiphertext(params Parameters, ct *rlwe.GadgetCiphertext) error {
+	levelQ, levelP := params.MaxLevelQ(), params.MaxLevelP()
+
+	rows := params.BaseRNSDecompositionVectorSize(levelQ, levelP)
+	if len(ct.Value) != rows {
+		return fmt.Errorf("%d filas de descomposición RNS, se esperaban %d", len(ct.Value), rows)
+	}
+
+	columns := params.BaseTwoDecompositionVectorSize(levelQ, levelP, ct.BaseTwoDecomposition)
+	for i, row := range ct.Value {
+		if len(row) != columns[i] {
+			return fmt.Errorf("%d columnas de descomposición en base 2 en la fila %d, se esperaban %d", len(row), i, columns[i])
+		}
+		for _, v := range row {
+			if err := checkVectorQP(params, v); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// checkVectorQP comprueba que v son dos polinomios de N coeficientes en los niveles máximos de Q y P
+func checkVectorQP(params Parameters, v rlwe.VectorQP) error {
+	if len(v) != 2 {
+		return fmt.Errorf("grado %d, se esperaba 1", len(v)-1)
+	}
+
+	levelQ, levelP := params.MaxLevelQ(), params.MaxLevelP()
+	for _, poly := range v {
+		if poly.LevelQ() != levelQ || poly.LevelP() != levelP {
+			return fmt.Errorf("niveles Q = %d y P = %d, se esperaban %d y %d", poly.LevelQ(), poly.LevelP(), levelQ, levelP)
+		}
+		for _, coeffs := range append(append([][]uint64{}, poly.Q.Coeffs...), poly.P.Coeffs...) {
+			if len(coeffs) != params.N() {
+				return fmt.Errorf("%d coeficientes, se esperaban %d", len(coeffs), params.N())
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,96 @@
+// Copyright 2025 Juan Martín Pérez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package labeling
+
+import (
+	"fmt"
+
+	"github.com/tuneinsight/lattigo/v6/core/rlwe"
+	"github.com/tuneinsight/lattigo/v6/schemes/bgv"
+)
+
+// multiplyTerm calcula el producto de los βs de un término con un árbol equilibrado de
+// multiplicaciones, con la del esquema (ver mulRelin). En BGV reescala cada producto, de modo que un
+// término de grado d consume ⌈log2 d⌉ niveles; si algún producto queda en el nivel 0 sin poder
+// reescalarse devuelve ErrInsufficientDepth. En BFV la multiplicación no consume niveles.
+func multiplyTerm(params Parameters, evaluator *bgv.Evaluator, term []rlwe.Ciphertext) (*rlwe.Ciphertext, error) {
+	factors := make([]*rlwe.Ciphertext, len(term))
+	for i := range term {
+		factors[i] = &term[i]
+	}
+
+	for len(factors) > 1 {
+		next := make([]*rlwe.Ciphertext, 0, (len(factors)+1)/2)
+		for i := 0; i+1 < len(factors); i += 2 {
+			level := min(factors[i].Level(), factors[i+1].Level())
+			if params.Scheme() == SchemeBGV && level == 0 {
+				return nil, fmt.Errorf("%w: Compact necesita reescalar un producto de grado %d y está en el nivel 0", ErrInsufficientDepth, len(term))
+			}
+
+			product := rlwe.NewCiphertext(params, 1, level)
+			if err := params.mulRelin(evaluator, factors[i], factors[i+1], product); err != nil {
+				return nil, err
+			}
+
+			if params.Scheme() == SchemeBGV {
+				rescaled := rlwe.NewCiphertext(params, 1, level-1)
+				if err := evaluator.Rescale(product, rescaled); err != nil {
+					return nil, err
+				}
+				product = rescaled
+			}
+
+			next = append(next, product)
+		}
+		if len(factors)%2 == 1 {
+			next = append(next, factors[len(factors)-1])
+		}
+		factors = next
+	}
+
+	return factors[0].CopyNew(), nil
+}
+
+// Compact pliega homomórficamente todos los términos de β en α: α ← α + Σᵢ Πⱼ βᵢⱼ.
+// El resultado no tiene elementos B, así que su tamaño y el coste de DecryptOverflow dejan de
+// depender del número de sumas y productos acumulados. Requiere la clave de relinealización. En BGV
+// consume ⌈log2 d⌉ niveles, siendo d el grado del labeled ciphertext: los productos de βs se
+// reescalan y α queda en el nivel del término más profundo. En BFV no consume niveles.
+func Compact(params Parameters, labeledciphertext CiphertextLabeledciphertext, evk *rlwe.MemEvaluationKeySet) (CiphertextLabeledciphertext, error) {
+	var result CiphertextLabeledciphertext
+
+	evaluator := bgv.NewEvaluator(params.Parameters, evk)
+
+	alpha := (*rlwe.Ciphertext)(labeledciphertext.elementsA).CopyNew()
+	for _, term := range labeledciphertext.elementsB {
+		if len(term) == 0 {
+			continue
+		}
+
+		product, err := multiplyTerm(params, evaluator, term)
+		if err != nil {
+			return result, err
+		}
+
+		if alpha, err = evaluator.AddNew(alpha, product); err != nil {
+			return result, err
+		}
+	}
+
+	result.elementsA = (*CiphertextElement)(alpha)
+	result.meta = deriveMetadata("Compact", false, labeledciphertext.meta)
+
+	return result, nil
+}
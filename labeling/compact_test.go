@@ -0,0 +1,80 @@
+// Copyright 2025 Juan Martín Pérez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Pruebas de Compact con BGV y BFV.
+
+package labeling
+
+import (
+	"testing"
+)
+
+func TestCompact(t *testing.T) {
+	for _, scheme := range []Scheme{SchemeBGV, SchemeBFV} {
+		t.Run(scheme.String(), func(t *testing.T) {
+			params, err := NewParametersFromLiteral(10, []int{56, 55, 55, 54}, []int{55}, 0x3ee0001, AllowInsecure(), WithScheme(scheme))
+			if err != nil {
+				t.Fatal(err)
+			}
+			sk, pk := GenerateKeyPair(params)
+			evk := GenerateMemEvaluationKeySet(GenerateRelinearizationKey(params, sk))
+
+			// (x·y)·(z·w) tiene un término de grado 4
+			inputs := make([]PlaintextLabeledciphertext, 4)
+			for i := range inputs {
+				if inputs[i], err = Encrypt(params, pk, broadcast(params, uint64(i+2))); err != nil {
+					t.Fatal(err)
+				}
+			}
+			xy, err := MultOverflow(params, inputs[0], inputs[1], pk, evk)
+			if err != nil {
+				t.Fatal(err)
+			}
+			zw, err := MultOverflow(params, inputs[2], inputs[3], pk, evk)
+			if err != nil {
+				t.Fatal(err)
+			}
+			lc, err := MultOverflowCiphertext(params, xy, zw, evk)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if lc.Degree() != 4 {
+				t.Fatalf("se esperaba grado 4, hay %d", lc.Degree())
+			}
+
+			compacted, err := Compact(params, lc, evk)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if compacted.Terms() != 0 {
+				t.Fatalf("quedan %d términos tras Compact", compacted.Terms())
+			}
+
+			// En BGV el término de grado 4 consume dos niveles; en BFV ninguno
+			want := lc.Level()
+			if scheme == SchemeBGV {
+				want = params.MaxLevel() - 2
+			}
+			if compacted.Level() != want {
+				t.Fatalf("nivel %d tras Compact, se esperaba %d", compacted.Level(), want)
+			}
+
+			got, err := DecryptOverflow(params, sk, compacted)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkValues(t, got, broadcast(params, 2*3*4*5))
+		})
+	}
+}
@@ -0,0 +1,269 @@
+// Copyright 2025 Juan Martín Pérez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Comparaciones sobre labeled ciphertexts enteros.
+//
+// Sobre un módulo primo t toda función Z_t → Z_t es un polinomio. Por el pequeño teorema de Fermat,
+// 1 − (x − y)^(t−1) vale 1 si x = y y 0 en otro caso, así que la indicatriz de un conjunto S es
+// Σ_{y∈S} 1 − (x − y)^(t−1). Sobre todo Z_t ese polinomio tiene grado t − 1, inabordable con módulos
+// de 26 bits, por lo que las comparaciones admiten una cota con WithBound: si las entradas están en
+// [0, bound), la diferencia está en (−bound, bound) y basta con el polinomio de grado 2·bound − 2 que
+// coincide con la indicatriz en esos puntos, obtenido por interpolación de Lagrange en Z_t. Sin cota
+// se usa el polinomio de Fermat sobre todo Z_t.
+//
+// EvalPolynomial evalúa un polinomio de grado d con profundidad ⌈log2 d⌉, así que con profundidad D
+// la cota puede llegar a 2^(D−1) + 1. La interpolación cuesta O(bound²), así que la profundidad se
+// comprueba antes de interpolar.
+
+package labeling
+
+import (
+	"errors"
+	"fmt"
+	"math/bits"
+
+	"github.com/tuneinsight/lattigo/v6/core/rlwe"
+)
+
+// ErrInsufficientDepth se devuelve cuando la cadena de módulos no admite la profundidad que requiere una operación
+var ErrInsufficientDepth = errors.New("labeling: profundidad multiplicativa insuficiente")
+
+// mulMod devuelve a·b mod t para a, b < t
+func mulMod(a, b, t uint64) uint64 {
+	hi, lo := bits.Mul64(a, b)
+	_, rem := bits.Div64(hi, lo, t)
+	return rem
+}
+
+// powMod devuelve a^e mod t
+func powMod(a, e, t uint64) uint64 {
+	result := uint64(1) % t
+	for a %= t; e > 0; e >>= 1 {
+		if e&1 == 1 {
+			result = mulMod(result, a, t)
+		}
+		a = mulMod(a, a, t)
+	}
+	return result
+}
+
+// interpolate devuelve los coeficientes, de menor a mayor grado, del polinomio de Lagrange que vale
+// values[k] en points[k] sobre Z_t, con t primo y los puntos distintos
+func interpolate(points, values []uint64, t uint64) []uint64 {
+	n := len(points)
+
+	// Π (x − xⱼ)
+	full := make([]uint64, n+1)
+	full[0] = 1
+	for j, xj := range points {
+		neg := (t - xj) % t
+		for i := j + 1; i >= 1; i-- {
+			full[i] = (full[i-1] + mulMod(full[i], neg, t)) % t
+		}
+		full[0] = mulMod(full[0], neg, t)
+	}
+
+	coeffs := make([]uint64, n)
+	quotient := make([]uint64, n)
+	for k, xk := range points {
+		if values[k]%t == 0 {
+			continue
+		}
+
+		// Π_{j≠k} (x − xⱼ) por división sintética entre (x − xₖ)
+		var carry uint64
+		for i := n; i >= 1; i-- {
+			carry = (full[i] + mulMod(carry, xk, t)) % t
+			quotient[i-1] = carry
+		}
+
+		// Evaluamos el cociente en xₖ para obtener el denominador
+		var denominator uint64
+		for i := n - 1; i >= 0; i-- {
+			denominator = (mulMod(denominator, xk, t) + quotient[i]) % t
+		}
+
+		// yₖ / Π_{j≠k} (xₖ − xⱼ), con el inverso por Fermat
+		scale := mulMod(values[k]%t, powMod(denominator, t-2, t), t)
+		for i := range coeffs {
+			coeffs[i] = (coeffs[i] + mulMod(quotient[i], scale, t)) % t
+		}
+	}
+
+	return coeffs
+}
+
+// signedDomain devuelve los puntos (−bound, bound) reducidos módulo t y la función f evaluada en ellos
+func signedDomain(t, bound uint64, f func(x int64) uint64) ([]uint64, []uint64) {
+	points := make([]uint64, 0, 2*bound-1)
+	values := make([]uint64, 0, 2*bound-1)
+	for x := -int64(bound) + 1; x < int64(bound); x++ {
+		points = append(points, uint64((x%int64(t)+int64(t))%int64(t)))
+		values = append(values, f(x)%t)
+	}
+	return points, values
+}
+
+// WithBound indica que las entradas de las comparaciones (LessThan, Sign, Abs, Min, Max y
+// SortNetwork) están acotadas por bound, lo que reduce el polinomio de grado t − 1 a uno de grado
+// 2·bound − 2. Cada función documenta el intervalo que cubre la cota.
+func WithBound(bound uint64) MultiplyOption {
+	return func(o *multiplyOptions) {
+		o.bound = bound
+	}
+}
+
+// evalOnDomain evalúa sobre x el polinomio que coincide con f en (−bound, bound), con bound la cota
+// de WithBound o (t + 1)/2 para cubrir todo Z_t
+func evalOnDomain(params Parameters, x PlaintextLabeledciphertext, f func(x int64) uint64, operation string, key rlwe.EncryptionKey, evk *rlwe.MemEvaluationKeySet, opts ...MultiplyOption) (Operand, error) {
+	options := multiplyOptions{depth: params.MaxLevel()}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	t := params.PlaintextModulus()
+	bound := options.bound
+	if bound == 0 {
+		bound = (t + 1) / 2
+	}
+	if 2*bound-1 > t {
+		return nil, fmt.Errorf("labeling: cota %d fuera de rango para t = %d", bound, t)
+	}
+
+	// El polinomio tiene grado 2·bound − 2, o 2·bound − 3 si f es impar como en Sign. Rechazamos con
+	// el menor de los dos antes de interpolar; evalInterpolated comprueba después el grado exacto.
+	degree := int(2*bound) - 3
+	if required := x.Multiplications() + polynomialDepth(degree); required > options.depth {
+		return nil, fmt.Errorf("%w: %s con cota %d requiere profundidad %d y la cadena de módulos solo admite %d; la cota máxima es %d",
+			ErrInsufficientDepth, operation, bound, required, options.depth, maxBound(options.depth-x.Multiplications()))
+	}
+
+	points, values := signedDomain(t, bound, f)
+
+	return evalInterpolated(params, x, interpolate(points, values, t), fmt.Sprintf("%s con cota %d", operation, bound), key, evk, opts...)
+}
+
+// maxBound devuelve la mayor cota de WithBound cuyo polinomio de grado 2·bound − 2 cabe en depth
+// niveles, o 0 si no cabe ninguna
+func maxBound(depth int) uint64 {
+	degree := maxPolynomialDegree(depth)
+	if degree < 0 {
+		return 0
+	}
+	return uint64(degree)/2 + 1
+}
+
+// evalInterpolated evalúa sobre x un polinomio interpolado, comprobando antes que cabe en el
+// presupuesto de profundidad
+func evalInterpolated(params Parameters, x PlaintextLabeledciphertext, coeffs []uint64, operation string, key rlwe.EncryptionKey, evk *rlwe.MemEvaluationKeySet, opts ...MultiplyOption) (Operand, error) {
+	options := multiplyOptions{depth: params.MaxLevel()}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	degree := len(coeffs) - 1
+	for degree > 0 && coeffs[degree] == 0 {
+		degree--
+	}
+	if required := x.Multiplications() + polynomialDepth(degree); required > options.depth {
+		return nil, fmt.Errorf("%w: %s requiere profundidad %d y la cadena de módulos solo admite %d", ErrInsufficientDepth, operation, required, options.depth)
+	}
+
+	return EvalPolynomial(params, x, coeffs[:degree+1], key, evk, opts...)
+}
+
+// LessThan devuelve un cifrado de 1 en los slots en que a < b y de 0 en el resto, con a − b
+// interpretado con signo en (−t/2, t/2). Sin opciones evalúa el polinomio de Fermat de grado t − 1;
+// con WithBound(bound) las entradas deben estar en [0, bound) y el polinomio tiene grado 2·bound − 2,
+// así que la cota debe ser pequeña. Si la cadena de módulos es demasiado corta devuelve
+// ErrInsufficientDepth sin llegar a interpolar.
+func LessThan(params Parameters, labeledciphertext1, labeledciphertext2 PlaintextLabeledciphertext, key rlwe.EncryptionKey, evk *rlwe.MemEvaluationKeySet, opts ...MultiplyOption) (Operand, error) {
+	diff, err := Sub(params, labeledciphertext1, labeledciphertext2)
+	if err != nil {
+		return nil, err
+	}
+
+	return evalOnDomain(params, diff, func(x int64) uint64 {
+		if x < 0 {
+			return 1
+		}
+		return 0
+	}, "LessThan", key, evk, opts...)
+}
+
+// Sign devuelve el signo de cada slot en la codificación con signo de IntEncoder: 1, 0 o −1 (t − 1).
+// Con WithBound(bound) las entradas deben estar en (−bound, bound).
+func Sign(params Parameters, labeledciphertext PlaintextLabeledciphertext, key rlwe.EncryptionKey, evk *rlwe.MemEvaluationKeySet, opts ...MultiplyOption) (Operand, error) {
+	t := params.PlaintextModulus()
+	return evalOnDomain(params, labeledciphertext, func(x int64) uint64 {
+		switch {
+		case x > 0:
+			return 1
+		case x < 0:
+			return t - 1
+		}
+		return 0
+	}, "Sign", key, evk, opts...)
+}
+
+// Abs devuelve el valor absoluto de cada slot en la codificación con signo de IntEncoder.
+// Con WithBound(bound) las entradas deben estar en (−bound, bound).
+func Abs(params Parameters, labeledciphertext PlaintextLabeledciphertext, key rlwe.EncryptionKey, evk *rlwe.MemEvaluationKeySet, opts ...MultiplyOption) (Operand, error) {
+	return evalOnDomain(params, labeledciphertext, func(x int64) uint64 {
+		if x < 0 {
+			return uint64(-x)
+		}
+		return uint64(x)
+	}, "Abs", key, evk, opts...)
+}
+
+// Min devuelve el mínimo slot a slot de dos labeled ciphertexts, con entradas en [0, bound) si se
+// indica WithBound(bound). En lugar de comparar y seleccionar, que costaría una multiplicación más,
+// evalúa sobre d = a − b el polinomio de min(d, 0) y suma b: min(a, b) = b + min(a − b, 0).
+func Min(params Parameters, labeledciphertext1, labeledciphertext2 PlaintextLabeledciphertext, key rlwe.EncryptionKey, evk *rlwe.MemEvaluationKeySet, opts ...MultiplyOption) (Operand, error) {
+	return minMax(params, labeledciphertext1, labeledciphertext2, false, key, evk, opts...)
+}
+
+// Max devuelve el máximo slot a slot de dos labeled ciphertexts, con entradas en [0, bound) si se
+// indica WithBound(bound), como
+// max(a, b) = b + max(a − b, 0). Con b = 0 es la activación ReLU.
+func Max(params Parameters, labeledciphertext1, labeledciphertext2 PlaintextLabeledciphertext, key rlwe.EncryptionKey, evk *rlwe.MemEvaluationKeySet, opts ...MultiplyOption) (Operand, error) {
+	return minMax(params, labeledciphertext1, labeledciphertext2, true, key, evk, opts...)
+}
+
+func minMax(params Parameters, labeledciphertext1, labeledciphertext2 PlaintextLabeledciphertext, isMax bool, key rlwe.EncryptionKey, evk *rlwe.MemEvaluationKeySet, opts ...MultiplyOption) (Operand, error) {
+	diff, err := Sub(params, labeledciphertext1, labeledciphertext2)
+	if err != nil {
+		return nil, err
+	}
+
+	t := params.PlaintextModulus()
+	operation := "Min"
+	if isMax {
+		operation = "Max"
+	}
+
+	// min(d, 0) o max(d, 0)
+	clipped, err := evalOnDomain(params, diff, func(x int64) uint64 {
+		if (x < 0) == isMax {
+			return 0
+		}
+		return uint64((x%int64(t) + int64(t)) % int64(t))
+	}, operation, key, evk, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return Add(params, clipped, labeledciphertext2)
+}
@@ -0,0 +1,240 @@
+// Copyright 2025 Juan Martín Pérez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package labeling
+
+import (
+	"fmt"
+)
+
+// Cost es la estimación del coste de evaluar una expresión con EvalExpr
+type Cost struct {
+	// Multiplications es el número de multiplicaciones entre cifrados: Mult y las variantes overflow
+	Multiplications int
+	// OverflowMultiplications es cuántas de ellas dejan el resultado en forma overflow
+	OverflowMultiplications int
+	// Depth es la profundidad multiplicativa del resultado
+	Depth int
+	// Overflow indica si el resultado queda en forma overflow
+	Overflow bool
+	// Degree es el número de βs que se multiplican al descifrar cada término del resultado
+	Degree int
+	// Terms es el número de términos de los elementos B del resultado
+	Terms int
+	// GaloisElements son los elementos de Galois de las rotaciones de la expresión
+	GaloisElements []uint64
+	// Levels es el número de niveles que consumen las componentes cifradas, uno por cada Mult o
+	// MultOverflowCiphertext del camino más largo
+	Levels int
+	// RemainingLevels es el margen de niveles que queda tras la evaluación. Cada nivel consumido
+	// reduce el presupuesto de ruido en el tamaño de un primo de la cadena, así que un valor
+	// negativo indica que los parámetros no admiten la expresión.
+	RemainingLevels int
+}
+
+// Feasible indica si la expresión cabe en los niveles de los parámetros
+func (c Cost) Feasible() bool {
+	return c.RemainingLevels >= 0
+}
+
+// exprShape describe la forma de un resultado parcial sin calcularlo
+type exprShape struct {
+	constant        bool
+	overflow        bool
+	multiplications int
+	levels          int
+	degree          int
+	terms           int
+}
+
+// costEstimator recorre el árbol tomando las mismas decisiones que exprEvaluator
+type costEstimator struct {
+	options multiplyOptions
+	cost    Cost
+	cache   map[exprCacheKey]exprShape
+}
+
+// EstimateCost simula la evaluación de la expresión con EvalExpr sin operar con cifrados y devuelve
+// el número de multiplicaciones, la profundidad, el crecimiento de los elementos B, las claves de Galois
+// necesarias y el margen de niveles. Todas las entradas se suponen recién cifradas. Devuelve el mismo
+// error que EvalExpr si la expresión requiere una combinación de operandos no soportada, y
+// ErrExprTooLarge si tiene más de MaxExprNodes nodos.
+func EstimateCost(expr Expr, params Parameters, opts ...MultiplyOption) (Cost, error) {
+	options := multiplyOptions{depth: params.MaxLevel()}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	node, err := indexExpr(expr)
+	if err != nil {
+		return Cost{}, err
+	}
+
+	estimator := costEstimator{options: options, cache: make(map[exprCacheKey]exprShape)}
+
+	shape, err := estimator.estimate(expr, node, true)
+	if err != nil {
+		return Cost{}, err
+	}
+
+	cost := estimator.cost
+	if shape.constant {
+		// EvalExpr cifra la constante
+		shape = exprShape{degree: 1, terms: 1}
+	}
+	cost.Depth = shape.multiplications
+	cost.Overflow = shape.overflow
+	cost.Degree = shape.degree
+	cost.Terms = shape.terms
+	cost.Levels = shape.levels
+	cost.RemainingLevels = params.MaxLevel() - shape.levels
+	cost.GaloisElements = ExprGaloisElements(params, expr)
+
+	return cost, nil
+}
+
+func (ce *costEstimator) estimate(expr Expr, node *exprNode, final bool) (exprShape, error) {
+	key := exprCacheKey{id: node.id, final: final}
+	if shape, ok := ce.cache[key]; ok {
+		return shape, nil
+	}
+
+	shape, err := ce.estimateNode(expr, node, final)
+	if err != nil {
+		return exprShape{}, err
+	}
+	ce.cache[key] = shape
+
+	return shape, nil
+}
+
+func (ce *costEstimator) estimateNode(expr Expr, node *exprNode, final bool) (exprShape, error) {
+	switch e := expr.(type) {
+	case ConstExpr:
+		return exprShape{constant: true}, nil
+
+	case VarExpr:
+		return exprShape{degree: 1, terms: 1}, nil
+
+	case AddExpr:
+		left, err := ce.estimate(e.Left, node.operands[0], final)
+		if err != nil {
+			return exprShape{}, err
+		}
+		right, err := ce.estimate(e.Right, node.operands[1], final)
+		if err != nil {
+			return exprShape{}, err
+		}
+
+		switch {
+		case left.constant:
+			return right, nil
+		case right.constant:
+			return left, nil
+		case !left.overflow && !right.overflow:
+			return exprShape{
+				multiplications: max(left.multiplications, right.multiplications),
+				levels:          max(left.levels, right.levels),
+				degree:          1,
+				terms:           1,
+			}, nil
+		}
+		// SumOverflow y SumOverflowCiphertext concatenan los términos
+		return exprShape{
+			overflow:        true,
+			multiplications: max(left.multiplications, right.multiplications),
+			levels:          max(left.levels, right.levels),
+			degree:          max(left.degree, right.degree),
+			terms:           left.terms + right.terms,
+		}, nil
+
+	case MulExpr:
+		inner := final && (node.operands[0].constant || node.operands[1].constant)
+
+		left, err := ce.estimate(e.Left, node.operands[0], inner)
+		if err != nil {
+			return exprShape{}, err
+		}
+		right, err := ce.estimate(e.Right, node.operands[1], inner)
+		if err != nil {
+			return exprShape{}, err
+		}
+
+		switch {
+		case left.constant && right.constant:
+			return left, nil
+		case left.constant:
+			return right, nil
+		case right.constant:
+			return left, nil
+		case (left.overflow || right.overflow) && !final:
+			return exprShape{}, fmt.Errorf("%w: producto de un operando en forma overflow en %s", ErrUnsupportedOperands, e)
+		}
+
+		multiplications := max(left.multiplications, right.multiplications)
+		ce.cost.Multiplications++
+
+		if left.overflow && right.overflow {
+			// MultOverflowCiphertext relinealiza α1·α2 y combina los términos
+			ce.cost.OverflowMultiplications++
+			return exprShape{
+				overflow:        true,
+				multiplications: multiplications + 1,
+				levels:          max(left.levels, right.levels) + 1,
+				degree:          max(left.degree+right.degree, left.degree+1, right.degree+1),
+				terms:           left.terms + right.terms + left.terms*right.terms,
+			}, nil
+		}
+
+		if left.overflow || right.overflow {
+			// MultOverflowMixed desdobla cada término y añade [α1, β2]
+			overflowed := left
+			if right.overflow {
+				overflowed = right
+			}
+			ce.cost.OverflowMultiplications++
+			return exprShape{
+				overflow:        true,
+				multiplications: multiplications + 1,
+				levels:          max(left.levels, right.levels),
+				degree:          max(overflowed.degree+1, 2),
+				terms:           2*overflowed.terms + 1,
+			}, nil
+		}
+
+		if final && ce.options.overflow(multiplications) {
+			// MultOverflow no consume niveles en los βs
+			ce.cost.OverflowMultiplications++
+			return exprShape{
+				overflow:        true,
+				multiplications: multiplications + 1,
+				levels:          max(left.levels, right.levels),
+				degree:          2,
+				terms:           1,
+			}, nil
+		}
+
+		return exprShape{
+			multiplications: multiplications + 1,
+			levels:          max(left.levels, right.levels) + 1,
+			degree:          1,
+			terms:           1,
+		}, nil
+
+	case RotateExpr:
+		return ce.estimate(e.Operand, node.operands[0], final)
+	}
+
+	return exprShape{}, fmt.Errorf("labeling: nodo de expresión desconocido %T", expr)
+}
@@ -0,0 +1,71 @@
+// Copyright 2025 Juan Martín Pérez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package labeling
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEstimateCostLongSums(t *testing.T) {
+	params, err := InsecureTestParameters()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Con claves de caché construidas con String() esta suma tardaba minutos
+	terms := MaxExprLength / 4
+	expr, err := ParseExpr("x*y" + strings.Repeat("+x*y", terms-1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	cost, err := EstimateCost(expr, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("la estimación de %d términos tardó %v", terms, elapsed)
+	}
+
+	// x*y se repite y EvalExpr lo calcula una sola vez
+	if cost.Multiplications != 1 {
+		t.Fatalf("se esperaba una multiplicación, se obtuvieron %d", cost.Multiplications)
+	}
+}
+
+func TestEstimateCostTooLarge(t *testing.T) {
+	params, err := InsecureTestParameters()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Un árbol construido en memoria puede compartir subárboles y tener muchos más nodos de los
+	// que ocupa
+	var expr Expr = VarExpr{Name: "x"}
+	for range 20 {
+		expr = AddExpr{Left: expr, Right: expr}
+	}
+
+	if _, err := EstimateCost(expr, params); !errors.Is(err, ErrExprTooLarge) {
+		t.Fatalf("se esperaba ErrExprTooLarge, se obtuvo %v", err)
+	}
+	if _, err := EvalExpr(params, expr, nil, nil, nil); !errors.Is(err, ErrExprTooLarge) {
+		t.Fatalf("EvalExpr: se esperaba ErrExprTooLarge, se obtuvo %v", err)
+	}
+}
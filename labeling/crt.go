@@ -0,0 +1,182 @@
+// Copyright 2025 Juan Martín Pérez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package labeling
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/tuneinsight/lattigo/v6/core/rlwe"
+)
+
+// CRTParameters son los parámetros del modo compuesto: un juego de parámetros por cada módulo de
+// texto plano, todos con el mismo anillo. El espacio de texto plano es Z_T con T = ∏ tᵢ, lo que
+// permite operar con enteros de 64 a 128 bits.
+//
+// Las claves solo dependen del anillo, así que un mismo par de claves y un mismo conjunto de claves
+// de evaluación sirven para todas las componentes.
+type CRTParameters struct {
+	Moduli []Parameters
+}
+
+// CRTLabeledciphertext es un labeled ciphertext por cada módulo de texto plano
+type CRTLabeledciphertext struct {
+	components []PlaintextLabeledciphertext
+}
+
+// NewCRTParameters crea los parámetros del modo compuesto con los módulos de texto plano indicados,
+// que deben ser coprimos dos a dos (véase GeneratePlaintextModuli)
+func NewCRTParameters(logN int, LogQ []int, LogP []int, moduli []uint64, opts ...ParametersOption) (CRTParameters, error) {
+	if len(moduli) == 0 {
+		return CRTParameters{}, fmt.Errorf("labeling: el modo CRT requiere al menos un módulo de texto plano")
+	}
+
+	for i := range moduli {
+		for j := i + 1; j < len(moduli); j++ {
+			if new(big.Int).GCD(nil, nil, new(big.Int).SetUint64(moduli[i]), new(big.Int).SetUint64(moduli[j])).Cmp(big.NewInt(1)) != 0 {
+				return CRTParameters{}, fmt.Errorf("labeling: los módulos %d y %d no son coprimos", moduli[i], moduli[j])
+			}
+		}
+	}
+
+	params := CRTParameters{Moduli: make([]Parameters, len(moduli))}
+	for i, t := range moduli {
+		var err error
+		if params.Moduli[i], err = NewParametersFromLiteral(logN, LogQ, LogP, t, opts...); err != nil {
+			return CRTParameters{}, err
+		}
+	}
+
+	return params, nil
+}
+
+// PlaintextModulus devuelve el módulo compuesto T = ∏ tᵢ
+func (p CRTParameters) PlaintextModulus() *big.Int {
+	T := big.NewInt(1)
+	for _, params := range p.Moduli {
+		T.Mul(T, new(big.Int).SetUint64(params.PlaintextModulus()))
+	}
+	return T
+}
+
+// MaxSlots devuelve el número de slots, común a todas las componentes
+func (p CRTParameters) MaxSlots() int {
+	return p.Moduli[0].MaxSlots()
+}
+
+// checkComponents comprueba que los labeled ciphertexts tienen una componente por módulo
+func (p CRTParameters) checkComponents(labeledciphertexts ...CRTLabeledciphertext) error {
+	for _, labeledciphertext := range labeledciphertexts {
+		if len(labeledciphertext.components) != len(p.Moduli) {
+			return fmt.Errorf("labeling: el labeled ciphertext CRT tiene %d componentes, se esperaban %d", len(labeledciphertext.components), len(p.Moduli))
+		}
+	}
+	return nil
+}
+
+// EncryptCRT cifra un vector de enteros módulo T, reduciendo cada valor módulo cada tᵢ
+func EncryptCRT(params CRTParameters, key rlwe.EncryptionKey, values []*big.Int) (CRTLabeledciphertext, error) {
+	labeledciphertext := CRTLabeledciphertext{components: make([]PlaintextLabeledciphertext, len(params.Moduli))}
+
+	residues := make([]uint64, len(values))
+	ti := new(big.Int)
+	r := new(big.Int)
+	for i, componentParams := range params.Moduli {
+		ti.SetUint64(componentParams.PlaintextModulus())
+		for j, value := range values {
+			residues[j] = r.Mod(value, ti).Uint64()
+		}
+
+		var err error
+		if labeledciphertext.components[i], err = Encrypt(componentParams, key, residues); err != nil {
+			return labeledciphertext, err
+		}
+	}
+
+	return labeledciphertext, nil
+}
+
+// DecryptCRT descifra cada componente y recompone los valores módulo T por el teorema chino del resto
+func DecryptCRT(params CRTParameters, key *rlwe.SecretKey, labeledciphertext CRTLabeledciphertext) ([]*big.Int, error) {
+	if err := params.checkComponents(labeledciphertext); err != nil {
+		return nil, err
+	}
+
+	T := params.PlaintextModulus()
+
+	values := make([]*big.Int, params.MaxSlots())
+	for j := range values {
+		values[j] = new(big.Int)
+	}
+
+	tmp := new(big.Int)
+	for i, componentParams := range params.Moduli {
+		residues, err := Decrypt(componentParams, key, labeledciphertext.components[i])
+		if err != nil {
+			return nil, err
+		}
+
+		// cᵢ = (T/tᵢ) · ((T/tᵢ)⁻¹ mod tᵢ)
+		ti := new(big.Int).SetUint64(componentParams.PlaintextModulus())
+		Ti := new(big.Int).Quo(T, ti)
+		ci := new(big.Int).Mul(Ti, new(big.Int).ModInverse(Ti, ti))
+
+		for j := range min(len(values), len(residues)) {
+			tmp.SetUint64(residues[j])
+			tmp.Mul(tmp, ci)
+			values[j].Add(values[j], tmp)
+		}
+	}
+
+	for j := range values {
+		values[j].Mod(values[j], T)
+	}
+
+	return values, nil
+}
+
+// SumCRT suma dos CRTLabeledciphertext componente a componente
+func SumCRT(params CRTParameters, labeledciphertext1, labeledciphertext2 CRTLabeledciphertext) (CRTLabeledciphertext, error) {
+	if err := params.checkComponents(labeledciphertext1, labeledciphertext2); err != nil {
+		return CRTLabeledciphertext{}, err
+	}
+
+	result := CRTLabeledciphertext{components: make([]PlaintextLabeledciphertext, len(params.Moduli))}
+	for i, componentParams := range params.Moduli {
+		var err error
+		if result.components[i], err = Sum(componentParams.Parameters, labeledciphertext1.components[i], labeledciphertext2.components[i]); err != nil {
+			return result, err
+		}
+	}
+
+	return result, nil
+}
+
+// MultCRT multiplica dos CRTLabeledciphertext componente a componente
+func MultCRT(params CRTParameters, labeledciphertext1, labeledciphertext2 CRTLabeledciphertext, key rlwe.EncryptionKey, evk *rlwe.MemEvaluationKeySet) (CRTLabeledciphertext, error) {
+	if err := params.checkComponents(labeledciphertext1, labeledciphertext2); err != nil {
+		return CRTLabeledciphertext{}, err
+	}
+
+	result := CRTLabeledciphertext{components: make([]PlaintextLabeledciphertext, len(params.Moduli))}
+	for i, componentParams := range params.Moduli {
+		var err error
+		if result.components[i], err = Mult(componentParams, labeledciphertext1.components[i], labeledciphertext2.components[i], key, evk); err != nil {
+			return result, err
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,96 @@
+// Copyright 2025 Juan Martín Pérez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package labeling
+
+import (
+	"crypto/sha256"
+
+	"github.com/tuneinsight/lattigo/v6/core/rlwe"
+)
+
+// BetaTable es la representación deduplicada de los elementos B: cada β distinto se guarda una sola vez
+// con su número de referencias, y los términos se expresan como índices en la tabla. Los circuitos que
+// reutilizan mucho sus entradas repiten los mismos βs en muchos términos.
+type BetaTable struct {
+	// Betas son los βs distintos, en orden de primera aparición
+	Betas []rlwe.Ciphertext
+	// RefCounts es el número de apariciones de cada β en los términos
+	RefCounts []int
+	// Terms son los términos como índices en Betas
+	Terms [][]int
+}
+
+// betaDigest identifica un β por el hash de su serialización
+func betaDigest(ct *rlwe.Ciphertext) ([sha256.Size]byte, error) {
+	var digest [sha256.Size]byte
+
+	hash := sha256.New()
+	if _, err := ct.WriteTo(hash); err != nil {
+		return digest, err
+	}
+	copy(digest[:], hash.Sum(nil))
+
+	return digest, nil
+}
+
+// DeduplicateBetas construye la tabla deduplicada de los elementos B del labeled ciphertext
+func DeduplicateBetas[T any](labeledciphertext Labeledciphertext[T]) (BetaTable, error) {
+	var table BetaTable
+	index := make(map[[sha256.Size]byte]int)
+
+	table.Terms = make([][]int, len(labeledciphertext.elementsB))
+	for i := range labeledciphertext.elementsB {
+		table.Terms[i] = make([]int, len(labeledciphertext.elementsB[i]))
+		for j := range labeledciphertext.elementsB[i] {
+			digest, err := betaDigest(&labeledciphertext.elementsB[i][j])
+			if err != nil {
+				return BetaTable{}, err
+			}
+
+			k, ok := index[digest]
+			if !ok {
+				k = len(table.Betas)
+				index[digest] = k
+				table.Betas = append(table.Betas, labeledciphertext.elementsB[i][j])
+				table.RefCounts = append(table.RefCounts, 0)
+			}
+			table.RefCounts[k]++
+			table.Terms[i][j] = k
+		}
+	}
+
+	return table, nil
+}
+
+// Expand reconstruye los elementos B a partir de la tabla
+func (t BetaTable) Expand() [][]rlwe.Ciphertext {
+	elementsB := make([][]rlwe.Ciphertext, len(t.Terms))
+	for i, term := range t.Terms {
+		elementsB[i] = make([]rlwe.Ciphertext, len(term))
+		for j, k := range term {
+			elementsB[i][j] = t.Betas[k]
+		}
+	}
+	return elementsB
+}
+
+// Duplicates devuelve cuántas apariciones de βs se ahorran con la tabla
+func (t BetaTable) Duplicates() int {
+	duplicates := 0
+	for _, count := range t.RefCounts {
+		duplicates += count - 1
+	}
+	return duplicates
+}
@@ -0,0 +1,292 @@
+// Copyright 2025 Juan Martín Pérez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package labeling
+
+import (
+	"errors"
+	"fmt"
+	"math/bits"
+
+	"github.com/tuneinsight/lattigo/v6/core/rlwe"
+)
+
+// ErrUnsupportedOperands se devuelve cuando una operación genérica no admite la combinación de operandos
+var ErrUnsupportedOperands = errors.New("labeling: combinación de operandos no soportada")
+
+// Operand es cualquier labeled ciphertext. Las operaciones genéricas aceptan
+// PlaintextLabeledciphertext y CiphertextLabeledciphertext y devuelven uno de los dos.
+type Operand interface {
+	Level() int
+	MaxLevel() int
+	Degree() int
+	Multiplications() int
+	IsOverflow() bool
+}
+
+// MultiplyOption modifica la elección de la variante de multiplicación en Multiply
+type MultiplyOption func(*multiplyOptions)
+
+type multiplyOptions struct {
+	forceOverflow bool
+	depth         int
+	// bound acota las entradas de las comparaciones; 0 significa todo Z_t
+	bound uint64
+}
+
+// overflow indica si un producto de operandos con multiplications multiplicaciones debe pasar a forma overflow
+func (o multiplyOptions) overflow(multiplications int) bool {
+	return o.forceOverflow || multiplications+1 >= o.depth
+}
+
+// WithOverflow fuerza a Multiply a usar la variante overflow aunque quede profundidad disponible
+func WithOverflow() MultiplyOption {
+	return func(o *multiplyOptions) {
+		o.forceOverflow = true
+	}
+}
+
+// WithDepthBudget fija el número de multiplicaciones entre cifrados que soportan los parámetros.
+// Por defecto es params.MaxLevel().
+func WithDepthBudget(depth int) MultiplyOption {
+	return func(o *multiplyOptions) {
+		o.depth = depth
+	}
+}
+
+// Multiply multiplica dos labeled ciphertexts eligiendo la variante adecuada según su forma y profundidad:
+// Mult mientras el resultado quede por debajo del presupuesto de profundidad, y MultOverflow para la
+// última multiplicación, que no consume profundidad en los βs. Un operando en forma overflow se
+// multiplica por uno en forma plaintext con MultOverflowMixed, y dos en forma overflow con
+// MultOverflowCiphertext.
+func Multiply(params Parameters, a, b Operand, key rlwe.EncryptionKey, evk *rlwe.MemEvaluationKeySet, opts ...MultiplyOption) (Operand, error) {
+	options := multiplyOptions{depth: params.MaxLevel()}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	switch x := a.(type) {
+	case PlaintextLabeledciphertext:
+		switch y := b.(type) {
+		case PlaintextLabeledciphertext:
+			if options.overflow(max(x.Multiplications(), y.Multiplications())) {
+				return MultOverflow(params, x, y, key, evk)
+			}
+			return Mult(params, x, y, key, evk)
+		case CiphertextLabeledciphertext:
+			return MultOverflowMixed(params, y, x)
+		}
+	case CiphertextLabeledciphertext:
+		switch y := b.(type) {
+		case PlaintextLabeledciphertext:
+			return MultOverflowMixed(params, x, y)
+		case CiphertextLabeledciphertext:
+			return MultOverflowCiphertext(params, x, y, evk)
+		}
+	}
+
+	return nil, fmt.Errorf("%w: Multiply(%T, %T)", ErrUnsupportedOperands, a, b)
+}
+
+// MultMany multiplica una lista de PlaintextLabeledciphertext con un árbol binario equilibrado,
+// de modo que la profundidad es ⌈log2 n⌉. Los niveles internos usan Mult y la multiplicación de la
+// raíz pasa por Multiply, que cambia a MultOverflow si se alcanza el presupuesto de profundidad.
+func MultMany(params Parameters, labeledciphertexts []PlaintextLabeledciphertext, key rlwe.EncryptionKey, evk *rlwe.MemEvaluationKeySet, opts ...MultiplyOption) (Operand, error) {
+	if len(labeledciphertexts) == 0 {
+		return nil, fmt.Errorf("labeling: MultMany requiere al menos un labeled ciphertext")
+	}
+
+	options := multiplyOptions{depth: params.MaxLevel()}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	// Comprobamos antes de operar que el árbol completo cabe en el presupuesto
+	depth := 0
+	for n := len(labeledciphertexts); n > 1; n = (n + 1) / 2 {
+		depth++
+	}
+	inputDepth := 0
+	for _, labeledciphertext := range labeledciphertexts {
+		inputDepth = max(inputDepth, labeledciphertext.Multiplications())
+	}
+	if inputDepth+depth > options.depth {
+		return nil, fmt.Errorf("labeling: MultMany requiere profundidad %d y el presupuesto es %d", inputDepth+depth, options.depth)
+	}
+
+	level := labeledciphertexts
+	for len(level) > 2 {
+		next := make([]PlaintextLabeledciphertext, 0, (len(level)+1)/2)
+		for i := 0; i+1 < len(level); i += 2 {
+			product, err := Mult(params, level[i], level[i+1], key, evk)
+			if err != nil {
+				return nil, err
+			}
+			next = append(next, product)
+		}
+		// Con un número impar de operandos, el último pasa directamente al siguiente nivel
+		if len(level)%2 == 1 {
+			next = append(next, level[len(level)-1])
+		}
+		level = next
+	}
+
+	if len(level) == 1 {
+		return level[0], nil
+	}
+
+	return Multiply(params, level[0], level[1], key, evk, opts...)
+}
+
+// Power calcula ct^k por cuadrados sucesivos: eleva al cuadrado con Mult hasta el bit más alto
+// de k y multiplica las potencias necesarias con MultMany, que gestiona el paso a forma overflow
+// en la última multiplicación. Para k = 0 devuelve un cifrado nuevo de unos.
+func Power(params Parameters, labeledciphertext PlaintextLabeledciphertext, k uint64, key rlwe.EncryptionKey, evk *rlwe.MemEvaluationKeySet, opts ...MultiplyOption) (Operand, error) {
+	if k == 0 {
+		return Encrypt(params, key, broadcast(params, 1))
+	}
+
+	options := multiplyOptions{depth: params.MaxLevel()}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	// Profundidad total: los cuadrados más el árbol que multiplica las potencias seleccionadas
+	squarings := bits.Len64(k) - 1
+	required := labeledciphertext.Multiplications() + squarings + bits.Len(uint(bits.OnesCount64(k)-1))
+	if required > options.depth {
+		return nil, fmt.Errorf("labeling: Power(%d) requiere profundidad %d y el presupuesto es %d", k, required, options.depth)
+	}
+
+	// Si k es potencia de dos el último cuadrado es la última multiplicación y pasa por Multiply
+	if bits.OnesCount64(k) == 1 && squarings > 0 {
+		square := labeledciphertext
+		for range squarings - 1 {
+			var err error
+			if square, err = Mult(params, square, square, key, evk); err != nil {
+				return nil, err
+			}
+		}
+		return Multiply(params, square, square, key, evk, opts...)
+	}
+
+	var factors []PlaintextLabeledciphertext
+	square := labeledciphertext
+	for i := 0; ; i++ {
+		if k>>i&1 == 1 {
+			factors = append(factors, square)
+		}
+		if i == squarings {
+			break
+		}
+
+		var err error
+		if square, err = Mult(params, square, square, key, evk); err != nil {
+			return nil, err
+		}
+	}
+
+	return MultMany(params, factors, key, evk, opts...)
+}
+
+// Add suma dos labeled ciphertexts de cualquier forma con la variante de suma que corresponda,
+// sin depender del orden de los argumentos: Sum, SumOverflow, SumPlaintextOverflow o SumOverflowCiphertext
+func Add(params Parameters, a, b Operand) (Operand, error) {
+	switch x := a.(type) {
+	case PlaintextLabeledciphertext:
+		switch y := b.(type) {
+		case PlaintextLabeledciphertext:
+			return Sum(params.Parameters, x, y)
+		case CiphertextLabeledciphertext:
+			return SumPlaintextOverflow(params, x, y)
+		}
+	case CiphertextLabeledciphertext:
+		switch y := b.(type) {
+		case PlaintextLabeledciphertext:
+			return SumOverflow(params, x, y)
+		case CiphertextLabeledciphertext:
+			return SumOverflowCiphertext(params, x, y)
+		}
+	}
+
+	return nil, fmt.Errorf("%w: suma de %T y %T", ErrUnsupportedOperands, a, b)
+}
+
+// scaleOperand multiplica un labeled ciphertext de cualquier forma por un escalar público
+func scaleOperand(params Parameters, a Operand, k uint64) (Operand, error) {
+	switch x := a.(type) {
+	case PlaintextLabeledciphertext:
+		return MulScalar(params, x, k)
+	case CiphertextLabeledciphertext:
+		return MulScalarOverflow(params, x, k)
+	}
+
+	return nil, fmt.Errorf("%w: producto por escalar de %T", ErrUnsupportedOperands, a)
+}
+
+// negateOperand devuelve el inverso aditivo de un labeled ciphertext de cualquier forma
+func negateOperand(params Parameters, a Operand) (Operand, error) {
+	switch x := a.(type) {
+	case PlaintextLabeledciphertext:
+		return Negate(params, x)
+	case CiphertextLabeledciphertext:
+		return Negate(params, x)
+	}
+
+	return nil, fmt.Errorf("%w: negación de %T", ErrUnsupportedOperands, a)
+}
+
+// addConstOperand suma una constante pública a todos los slots de un labeled ciphertext de cualquier forma
+func addConstOperand(params Parameters, a Operand, c uint64) (Operand, error) {
+	switch x := a.(type) {
+	case PlaintextLabeledciphertext:
+		return AddConst(params, x, c)
+	case CiphertextLabeledciphertext:
+		return AddConst(params, x, c)
+	}
+
+	return nil, fmt.Errorf("%w: suma de constante a %T", ErrUnsupportedOperands, a)
+}
+
+// rotateOperand rota k posiciones las columnas de un labeled ciphertext de cualquier forma
+func rotateOperand(params Parameters, a Operand, k int, evk *rlwe.MemEvaluationKeySet) (Operand, error) {
+	switch x := a.(type) {
+	case PlaintextLabeledciphertext:
+		return RotateColumns(params, x, k, evk)
+	case CiphertextLabeledciphertext:
+		return RotateColumnsOverflow(params, x, k, evk)
+	}
+
+	return nil, fmt.Errorf("%w: rotación de %T", ErrUnsupportedOperands, a)
+}
+
+// applyEvaluationKeyOperand cambia de clave un labeled ciphertext de cualquier forma
+func applyEvaluationKeyOperand(params Parameters, evk *rlwe.EvaluationKey, a Operand) (Operand, error) {
+	switch x := a.(type) {
+	case PlaintextLabeledciphertext:
+		switched, err := ApplyEvaluationKey(params, *evk, x)
+		if err != nil {
+			return nil, err
+		}
+		return *switched, nil
+	case CiphertextLabeledciphertext:
+		switched, err := ApplyEvaluationKeyOverflow(params, *evk, x)
+		if err != nil {
+			return nil, err
+		}
+		return *switched, nil
+	}
+
+	return nil, fmt.Errorf("%w: cambio de clave de %T", ErrUnsupportedOperands, a)
+}
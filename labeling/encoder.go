@@ -0,0 +1,182 @@
+// Copyright 2025 Juan Martín Pérez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package labeling
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+
+	"github.com/tuneinsight/lattigo/v6/core/rlwe"
+)
+
+// Encoder traduce los valores de la aplicación a un vector de enteros módulo t y viceversa.
+// Permite definir codificaciones propias (texto empaquetado, categorías one-hot, ...) sin tocar
+// la generación de máscaras de Encrypt y Decrypt.
+type Encoder[V any] interface {
+	// Encode devuelve como mucho params.MaxSlots() enteros en [0, t)
+	Encode(params Parameters, values V) ([]uint64, error)
+	// Decode reconstruye los valores a partir de los params.MaxSlots() enteros descifrados
+	Decode(params Parameters, slots []uint64) (V, error)
+}
+
+// EncryptWith codifica los valores con el encoder y los cifra con Encrypt
+func EncryptWith[V any](params Parameters, key rlwe.EncryptionKey, encoder Encoder[V], values V) (PlaintextLabeledciphertext, error) {
+	slots, err := encoder.Encode(params, values)
+	if err != nil {
+		return PlaintextLabeledciphertext{}, err
+	}
+
+	if len(slots) > params.MaxSlots() {
+		return PlaintextLabeledciphertext{}, fmt.Errorf("labeling: la codificación ocupa %d slots y solo hay %d", len(slots), params.MaxSlots())
+	}
+
+	return Encrypt(params, key, slots)
+}
+
+// DecryptWith descifra con Decrypt y decodifica el resultado con el encoder
+func DecryptWith[V any](params Parameters, key *rlwe.SecretKey, encoder Encoder[V], labeledciphertext PlaintextLabeledciphertext) (V, error) {
+	slots, err := Decrypt(params, key, labeledciphertext)
+	if err != nil {
+		var zero V
+		return zero, err
+	}
+
+	return encoder.Decode(params, slots)
+}
+
+// Uint64Encoder es la codificación por defecto: cada valor se reduce módulo t y ocupa un slot
+type Uint64Encoder struct{}
+
+func (Uint64Encoder) Encode(params Parameters, values []uint64) ([]uint64, error) {
+	slots := make([]uint64, len(values))
+	for i, value := range values {
+		slots[i] = value % params.PlaintextModulus()
+	}
+	return slots, nil
+}
+
+func (Uint64Encoder) Decode(params Parameters, slots []uint64) ([]uint64, error) {
+	return slots, nil
+}
+
+// IntEncoder codifica enteros con signo en representación centrada: los valores deben estar en
+// (-t/2, t/2] y los negativos se guardan como t - |x|
+type IntEncoder struct{}
+
+func (IntEncoder) Encode(params Parameters, values []int64) ([]uint64, error) {
+	t := params.PlaintextModulus()
+	half := int64(t / 2)
+
+	slots := make([]uint64, len(values))
+	for i, value := range values {
+		if value > half || value <= -half {
+			return nil, fmt.Errorf("labeling: el valor %d no cabe en la representación centrada módulo %d", value, t)
+		}
+		if value < 0 {
+			slots[i] = t - uint64(-value)
+		} else {
+			slots[i] = uint64(value)
+		}
+	}
+	return slots, nil
+}
+
+func (IntEncoder) Decode(params Parameters, slots []uint64) ([]int64, error) {
+	t := params.PlaintextModulus()
+
+	values := make([]int64, len(slots))
+	for i, slot := range slots {
+		if slot > t/2 {
+			values[i] = -int64(t - slot)
+		} else {
+			values[i] = int64(slot)
+		}
+	}
+	return values, nil
+}
+
+// FixedPointEncoder codifica reales como enteros con signo x·2^FractionalBits redondeados.
+// Tras una multiplicación los bits fraccionarios se suman, así que el resultado se decodifica
+// con un FixedPointEncoder de 2·FractionalBits.
+type FixedPointEncoder struct {
+	FractionalBits int
+}
+
+func (e FixedPointEncoder) Encode(params Parameters, values []float64) ([]uint64, error) {
+	scaled := make([]int64, len(values))
+	for i, value := range values {
+		scaled[i] = int64(math.Round(math.Ldexp(value, e.FractionalBits)))
+	}
+	return IntEncoder{}.Encode(params, scaled)
+}
+
+func (e FixedPointEncoder) Decode(params Parameters, slots []uint64) ([]float64, error) {
+	scaled, err := IntEncoder{}.Decode(params, slots)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([]float64, len(scaled))
+	for i, value := range scaled {
+		values[i] = math.Ldexp(float64(value), -e.FractionalBits)
+	}
+	return values, nil
+}
+
+// BytesEncoder empaqueta bytes en los slots, tantos por slot como quepan por debajo de t.
+// El primer slot guarda la longitud para poder descartar el relleno al decodificar.
+type BytesEncoder struct{}
+
+// bytesPerSlot devuelve cuántos bytes caben en un entero menor que t
+func (BytesEncoder) bytesPerSlot(params Parameters) int {
+	return (bits.Len64(params.PlaintextModulus()) - 1) / 8
+}
+
+func (e BytesEncoder) Encode(params Parameters, values []byte) ([]uint64, error) {
+	perSlot := e.bytesPerSlot(params)
+	if perSlot == 0 || uint64(len(values)) >= params.PlaintextModulus() {
+		return nil, fmt.Errorf("labeling: no se pueden empaquetar %d bytes con t = %d", len(values), params.PlaintextModulus())
+	}
+
+	slots := make([]uint64, 1, 1+(len(values)+perSlot-1)/perSlot)
+	slots[0] = uint64(len(values))
+	for i := 0; i < len(values); i += perSlot {
+		var slot uint64
+		for j := i; j < min(i+perSlot, len(values)); j++ {
+			slot |= uint64(values[j]) << (8 * (j - i))
+		}
+		slots = append(slots, slot)
+	}
+	return slots, nil
+}
+
+func (e BytesEncoder) Decode(params Parameters, slots []uint64) ([]byte, error) {
+	perSlot := e.bytesPerSlot(params)
+	if len(slots) == 0 || perSlot == 0 {
+		return nil, fmt.Errorf("labeling: codificación de bytes vacía")
+	}
+
+	length := slots[0]
+	if length > uint64((len(slots)-1)*perSlot) {
+		return nil, fmt.Errorf("labeling: longitud %d incompatible con %d slots", length, len(slots)-1)
+	}
+
+	values := make([]byte, length)
+	for i := range values {
+		values[i] = byte(slots[1+i/perSlot] >> (8 * (i % perSlot)))
+	}
+	return values, nil
+}
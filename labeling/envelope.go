@@ -0,0 +1,157 @@
+// Copyright 2025 Juan Martín Pérez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Sobres firmados.
+//
+// Un sobre es un contenedor de MarshalWire firmado con Ed25519 por quien lo produce, junto con la
+// huella (KeyFingerprint) de su clave de firma. El destinatario busca la clave por la huella entre
+// las que conoce y comprueba la firma antes de decodificar el contenido, de modo que sabe qué
+// cliente produjo cada entrada cifrada o cada resultado. A diferencia de WithMAC, la firma no
+// requiere compartir un secreto y el destinatario no puede falsificarla.
+//
+// El formato es:
+//
+//	"labeling-envelope-v1" | huella del firmante (32 bytes) | instante de firma (8 bytes) |
+//	longitud del contenedor (8 bytes) | contenedor | firma (64 bytes)
+//
+// La firma cubre todo lo anterior a ella.
+
+package labeling
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrInvalidSignature se devuelve cuando un sobre no está firmado por la clave esperada
+var ErrInvalidSignature = errors.New("labeling: firma de sobre no válida")
+
+// envelopeDomain separa las firmas de sobres de cualquier otro uso de la clave de firma
+const envelopeDomain = "labeling-envelope-v1"
+
+// Envelope es un contenedor firmado por su productor
+type Envelope struct {
+	// Signer es la huella de la clave pública de firma del productor
+	Signer Fingerprint
+	// SignedAt es el instante de la firma, con precisión de segundos
+	SignedAt time.Time
+	// Payload es el contenedor serializado con MarshalWire
+	Payload []byte
+	// Signature es la firma Ed25519 del mensaje canónico
+	Signature []byte
+}
+
+// message devuelve el mensaje canónico que se firma
+func (e Envelope) message() []byte {
+	msg := make([]byte, 0, len(envelopeDomain)+sha256.Size+16+len(e.Payload))
+	msg = append(msg, envelopeDomain...)
+	msg = append(msg, e.Signer[:]...)
+	msg = binary.LittleEndian.AppendUint64(msg, uint64(e.SignedAt.Unix()))
+	msg = binary.LittleEndian.AppendUint64(msg, uint64(len(e.Payload)))
+	return append(msg, e.Payload...)
+}
+
+// Seal serializa v con MarshalWire para params y lo firma con signer. Las opciones se aplican al
+// contenedor, de modo que el contenido puede ir además comprimido o con MAC.
+func Seal(params Parameters, signer ed25519.PrivateKey, v encoding.BinaryMarshaler, opts ...WireOption) (*Envelope, error) {
+	fingerprint, err := KeyFingerprint(signer.Public().(ed25519.PublicKey))
+	if err != nil {
+		return nil, err
+	}
+
+	payload, err := MarshalWire(params, v, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	envelope := &Envelope{
+		Signer:   fingerprint,
+		SignedAt: time.Unix(time.Now().Unix(), 0),
+		Payload:  payload,
+	}
+	envelope.Signature = ed25519.Sign(signer, envelope.message())
+
+	return envelope, nil
+}
+
+// Verify comprueba que el sobre está firmado por trusted. trusted es la clave de firma en la que
+// confía el destinatario para la huella Signer, nunca una que venga con el sobre.
+func (e Envelope) Verify(trusted ed25519.PublicKey) error {
+	fingerprint, err := KeyFingerprint(trusted)
+	if err != nil {
+		return err
+	}
+	if fingerprint != e.Signer {
+		return fmt.Errorf("%w: firmante desconocido %v", ErrInvalidSignature, e.Signer)
+	}
+
+	if !ed25519.Verify(trusted, e.message(), e.Signature) {
+		return fmt.Errorf("%w: firma incorrecta", ErrInvalidSignature)
+	}
+
+	return nil
+}
+
+// Open comprueba la firma con trusted y decodifica el contenido en v con UnmarshalWire. Las opciones
+// son las de lectura del contenedor, como WithMAC.
+func (e Envelope) Open(params Parameters, trusted ed25519.PublicKey, v encoding.BinaryUnmarshaler, opts ...WireOption) error {
+	if err := e.Verify(trusted); err != nil {
+		return err
+	}
+	return UnmarshalWire(params, e.Payload, v, opts...)
+}
+
+// MarshalBinary serializa el sobre: el mensaje canónico seguido de la firma
+func (e Envelope) MarshalBinary() ([]byte, error) {
+	if len(e.Signature) != ed25519.SignatureSize {
+		return nil, fmt.Errorf("labeling: sobre sin firmar")
+	}
+	return append(e.message(), e.Signature...), nil
+}
+
+// UnmarshalBinary reconstruye el sobre a partir de su serialización binaria. No comprueba la
+// firma: debe llamarse a Verify u Open.
+func (e *Envelope) UnmarshalBinary(data []byte) error {
+	if !bytes.HasPrefix(data, []byte(envelopeDomain)) {
+		return fmt.Errorf("labeling: la serialización no es un sobre")
+	}
+	data = data[len(envelopeDomain):]
+
+	if len(data) < sha256.Size+16 {
+		return fmt.Errorf("labeling: sobre truncado")
+	}
+
+	var envelope Envelope
+	copy(envelope.Signer[:], data)
+	data = data[sha256.Size:]
+	envelope.SignedAt = time.Unix(int64(binary.LittleEndian.Uint64(data)), 0)
+	length := binary.LittleEndian.Uint64(data[8:])
+	data = data[16:]
+
+	if len(data) < ed25519.SignatureSize || length != uint64(len(data)-ed25519.SignatureSize) {
+		return fmt.Errorf("labeling: sobre truncado")
+	}
+	envelope.Payload = append([]byte{}, data[:length]...)
+	envelope.Signature = append([]byte{}, data[length:]...)
+
+	*e = envelope
+
+	return nil
+}
@@ -0,0 +1,330 @@
+// Copyright 2025 Juan Martín Pérez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package labeling
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/tuneinsight/lattigo/v6/core/rlwe"
+)
+
+// MaxExprNodes es el número máximo de nodos de una expresión que aceptan EvalExpr y EstimateCost.
+// Cada nodo ocupa al menos un símbolo del texto, así que cualquier expresión aceptada por ParseExpr
+// está por debajo del límite.
+const MaxExprNodes = MaxExprLength
+
+// ErrExprTooLarge se devuelve cuando una expresión supera MaxExprNodes nodos
+var ErrExprTooLarge = errors.New("labeling: expresión demasiado grande")
+
+// Expr es un nodo del árbol de sintaxis de una expresión aritmética sobre Z_t.
+// Los nodos son AddExpr, MulExpr, ConstExpr, VarExpr y RotateExpr.
+type Expr interface {
+	String() string
+	isExpr()
+}
+
+// AddExpr es la suma de dos subexpresiones
+type AddExpr struct {
+	Left, Right Expr
+}
+
+// MulExpr es el producto de dos subexpresiones
+type MulExpr struct {
+	Left, Right Expr
+}
+
+// ConstExpr es una constante pública, que se aplica a todos los slots
+type ConstExpr struct {
+	Value uint64
+}
+
+// VarExpr es una entrada cifrada identificada por su nombre
+type VarExpr struct {
+	Name string
+}
+
+// RotateExpr rota K posiciones a la izquierda las columnas de una subexpresión; K negativo rota a la derecha
+type RotateExpr struct {
+	Operand Expr
+	K       int
+}
+
+func (AddExpr) isExpr()    {}
+func (MulExpr) isExpr()    {}
+func (ConstExpr) isExpr()  {}
+func (VarExpr) isExpr()    {}
+func (RotateExpr) isExpr() {}
+
+func (e AddExpr) String() string   { return "(" + e.Left.String() + " + " + e.Right.String() + ")" }
+func (e MulExpr) String() string   { return "(" + e.Left.String() + " * " + e.Right.String() + ")" }
+func (e ConstExpr) String() string { return strconv.FormatUint(e.Value, 10) }
+func (e VarExpr) String() string   { return e.Name }
+func (e RotateExpr) String() string {
+	return "rot(" + e.Operand.String() + ", " + strconv.Itoa(e.K) + ")"
+}
+
+// isConstant indica si la expresión no depende de ninguna entrada cifrada
+func isConstant(expr Expr) bool {
+	switch e := expr.(type) {
+	case AddExpr:
+		return isConstant(e.Left) && isConstant(e.Right)
+	case MulExpr:
+		return isConstant(e.Left) && isConstant(e.Right)
+	case RotateExpr:
+		return isConstant(e.Operand)
+	case ConstExpr:
+		return true
+	}
+	return false
+}
+
+// exprNode acompaña a un nodo del árbol con su identificador estructural, igual para todos los
+// subárboles idénticos, y con la indicación de si no depende de ninguna entrada cifrada
+type exprNode struct {
+	id       int
+	constant bool
+	operands []*exprNode
+}
+
+// exprKey describe un nodo a partir de los identificadores de sus operandos
+type exprKey struct {
+	kind        byte
+	left, right int
+	value       uint64
+	name        string
+}
+
+// exprCacheKey identifica un resultado parcial en las cachés de EvalExpr y EstimateCost
+type exprCacheKey struct {
+	id    int
+	final bool
+}
+
+// indexExpr recorre el árbol una sola vez y asigna a cada nodo su identificador estructural, de
+// modo que las cachés comparan subexpresiones en tiempo constante. Devuelve ErrExprTooLarge si la
+// expresión tiene más de MaxExprNodes nodos.
+func indexExpr(expr Expr) (*exprNode, error) {
+	ids := make(map[exprKey]int)
+	count := 0
+
+	var index func(Expr) (*exprNode, error)
+	binary := func(kind byte, left, right Expr) (*exprNode, exprKey, error) {
+		l, err := index(left)
+		if err != nil {
+			return nil, exprKey{}, err
+		}
+		r, err := index(right)
+		if err != nil {
+			return nil, exprKey{}, err
+		}
+		node := &exprNode{constant: l.constant && r.constant, operands: []*exprNode{l, r}}
+		return node, exprKey{kind: kind, left: l.id, right: r.id}, nil
+	}
+
+	index = func(expr Expr) (*exprNode, error) {
+		if count++; count > MaxExprNodes {
+			return nil, fmt.Errorf("%w: más de %d nodos", ErrExprTooLarge, MaxExprNodes)
+		}
+
+		var node *exprNode
+		var key exprKey
+		var err error
+		switch e := expr.(type) {
+		case ConstExpr:
+			node, key = &exprNode{constant: true}, exprKey{kind: 'c', value: e.Value}
+		case VarExpr:
+			node, key = &exprNode{}, exprKey{kind: 'v', name: e.Name}
+		case AddExpr:
+			node, key, err = binary('+', e.Left, e.Right)
+		case MulExpr:
+			node, key, err = binary('*', e.Left, e.Right)
+		case RotateExpr:
+			var operand *exprNode
+			if operand, err = index(e.Operand); err == nil {
+				node = &exprNode{constant: operand.constant, operands: []*exprNode{operand}}
+				key = exprKey{kind: 'r', left: operand.id, value: uint64(e.K)}
+			}
+		default:
+			err = fmt.Errorf("labeling: nodo de expresión desconocido %T", expr)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		id, ok := ids[key]
+		if !ok {
+			id = len(ids)
+			ids[key] = id
+		}
+		node.id = id
+
+		return node, nil
+	}
+
+	return index(expr)
+}
+
+// exprValue es el resultado parcial de evaluar un nodo: una constante pública o un labeled ciphertext
+type exprValue struct {
+	constant uint64
+	operand  Operand
+}
+
+// exprEvaluator recorre el árbol con los parámetros y claves de la evaluación
+type exprEvaluator struct {
+	params Parameters
+	inputs map[string]PlaintextLabeledciphertext
+	key    rlwe.EncryptionKey
+	evk    *rlwe.MemEvaluationKeySet
+	opts   []MultiplyOption
+	// cache guarda los nodos ya evaluados para no repetir las subexpresiones comunes
+	cache map[exprCacheKey]exprValue
+}
+
+// EvalExpr evalúa la expresión completa sobre las entradas cifradas y devuelve un único labeled ciphertext.
+// Las constantes se pliegan y se aplican con AddConst y MulScalar, que no consumen profundidad. Los
+// productos entre cifrados que aún deben multiplicarse de nuevo usan Mult, y el resto pasa por Multiply,
+// que cambia a MultOverflow al agotar el presupuesto de profundidad. Las expresiones de más de
+// MaxExprNodes nodos se rechazan con ErrExprTooLarge.
+func EvalExpr(params Parameters, expr Expr, inputs map[string]PlaintextLabeledciphertext, key rlwe.EncryptionKey, evk *rlwe.MemEvaluationKeySet, opts ...MultiplyOption) (Operand, error) {
+	node, err := indexExpr(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	// Comprobamos las claves de Galois antes de operar para no fallar a mitad de la evaluación
+	if missing := MissingGaloisKeys(evk, ExprGaloisElements(params, expr)); len(missing) > 0 {
+		return nil, fmt.Errorf("%w: elementos %v", ErrMissingGaloisKey, missing)
+	}
+
+	evaluator := exprEvaluator{params: params, inputs: inputs, key: key, evk: evk, opts: opts, cache: make(map[exprCacheKey]exprValue)}
+
+	value, err := evaluator.eval(expr, node, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if value.operand == nil {
+		return Encrypt(params, key, broadcast(params, value.constant))
+	}
+
+	return value.operand, nil
+}
+
+// eval evalúa un nodo. final indica que el resultado no vuelve a multiplicarse por otro cifrado,
+// así que puede quedar en forma overflow.
+func (ev exprEvaluator) eval(expr Expr, node *exprNode, final bool) (exprValue, error) {
+	key := exprCacheKey{id: node.id, final: final}
+	if value, ok := ev.cache[key]; ok {
+		return value, nil
+	}
+
+	value, err := ev.evalNode(expr, node, final)
+	if err != nil {
+		return exprValue{}, err
+	}
+	ev.cache[key] = value
+
+	return value, nil
+}
+
+func (ev exprEvaluator) evalNode(expr Expr, node *exprNode, final bool) (exprValue, error) {
+	t := ev.params.PlaintextModulus()
+
+	switch e := expr.(type) {
+	case ConstExpr:
+		return exprValue{constant: e.Value % t}, nil
+
+	case VarExpr:
+		input, ok := ev.inputs[e.Name]
+		if !ok {
+			return exprValue{}, fmt.Errorf("labeling: la variable %q no tiene entrada", e.Name)
+		}
+		return exprValue{operand: input}, nil
+
+	case AddExpr:
+		left, err := ev.eval(e.Left, node.operands[0], final)
+		if err != nil {
+			return exprValue{}, err
+		}
+		right, err := ev.eval(e.Right, node.operands[1], final)
+		if err != nil {
+			return exprValue{}, err
+		}
+
+		switch {
+		case left.operand == nil && right.operand == nil:
+			return exprValue{constant: (left.constant + right.constant) % t}, nil
+		case left.operand == nil:
+			operand, err := addConstOperand(ev.params, right.operand, left.constant)
+			return exprValue{operand: operand}, err
+		case right.operand == nil:
+			operand, err := addConstOperand(ev.params, left.operand, right.constant)
+			return exprValue{operand: operand}, err
+		}
+		operand, err := Add(ev.params, left.operand, right.operand)
+		return exprValue{operand: operand}, err
+
+	case MulExpr:
+		// Un producto por una constante no consume profundidad y conserva la forma del otro factor
+		inner := final && (node.operands[0].constant || node.operands[1].constant)
+
+		left, err := ev.eval(e.Left, node.operands[0], inner)
+		if err != nil {
+			return exprValue{}, err
+		}
+		right, err := ev.eval(e.Right, node.operands[1], inner)
+		if err != nil {
+			return exprValue{}, err
+		}
+
+		switch {
+		case left.operand == nil && right.operand == nil:
+			return exprValue{constant: mulMod(left.constant, right.constant, t)}, nil
+		case left.operand == nil:
+			operand, err := scaleOperand(ev.params, right.operand, left.constant)
+			return exprValue{operand: operand}, err
+		case right.operand == nil:
+			operand, err := scaleOperand(ev.params, left.operand, right.constant)
+			return exprValue{operand: operand}, err
+		}
+
+		if final {
+			operand, err := Multiply(ev.params, left.operand, right.operand, ev.key, ev.evk, ev.opts...)
+			return exprValue{operand: operand}, err
+		}
+
+		x, okLeft := left.operand.(PlaintextLabeledciphertext)
+		y, okRight := right.operand.(PlaintextLabeledciphertext)
+		if !okLeft || !okRight {
+			return exprValue{}, fmt.Errorf("%w: producto intermedio de %T y %T", ErrUnsupportedOperands, left.operand, right.operand)
+		}
+		operand, err := Mult(ev.params, x, y, ev.key, ev.evk)
+		return exprValue{operand: operand}, err
+
+	case RotateExpr:
+		value, err := ev.eval(e.Operand, node.operands[0], final)
+		if err != nil || value.operand == nil {
+			// Una constante es igual en todos los slots y no cambia al rotar
+			return value, err
+		}
+		operand, err := rotateOperand(ev.params, value.operand, e.K, ev.evk)
+		return exprValue{operand: operand}, err
+	}
+
+	return exprValue{}, fmt.Errorf("labeling: nodo de expresión desconocido %T", expr)
+}
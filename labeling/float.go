@@ -0,0 +1,283 @@
+// Copyright 2025 Juan Martín Pérez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package labeling
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+
+	"github.com/tuneinsight/lattigo/v6/core/rlwe"
+	"github.com/tuneinsight/lattigo/v6/schemes/ckks"
+	"github.com/tuneinsight/lattigo/v6/utils/sampling"
+)
+
+// FloatElements contiene los elementos A en texto plano para datos reales
+type FloatElements []float64
+
+// FloatLabeledciphertext es el labeled ciphertext aproximado construido sobre CKKS
+type FloatLabeledciphertext = Labeledciphertext[FloatElements]
+
+// FloatParameters son los parámetros del modo aproximado, basado en CKKS
+type FloatParameters struct {
+	ckks.Parameters
+}
+
+// NewFloatParametersFromLiteral crea los parámetros CKKS para el modo aproximado.
+// LogDefaultScale fija la precisión de la codificación y debe ser del orden de los módulos de LogQ.
+// Las máscaras ocupan los bits que la escala deja libres en un float64 y en el módulo (ver
+// MaskBits), así que LogDefaultScale debe ser menor que 52 y que la mitad de LogQ.
+func NewFloatParametersFromLiteral(logN int, LogQ []int, LogP []int, LogDefaultScale int, opts ...ParametersOption) (FloatParameters, error) {
+	options := newParametersOptions(opts)
+
+	params, err := ckks.NewParametersFromLiteral(ckks.ParametersLiteral{
+		LogN:            logN,
+		LogQ:            LogQ,
+		LogP:            LogP,
+		LogDefaultScale: LogDefaultScale,
+	})
+	if err != nil {
+		return FloatParameters{}, err
+	}
+
+	if !options.allowInsecure {
+		if err := validateSecurity(params.LogN(), params.LogQP(), options.minSecurity); err != nil {
+			return FloatParameters{}, err
+		}
+	}
+
+	floatParams := FloatParameters{params}
+	if floatParams.MaskBits() < 1 {
+		return FloatParameters{}, fmt.Errorf("labeling: LogDefaultScale %d no deja bits para las máscaras con LogQ %.0f", LogDefaultScale, floatParams.LogQ())
+	}
+
+	return floatParams, nil
+}
+
+// SecurityLevel estima los bits de seguridad de los parámetros CKKS
+func (p FloatParameters) SecurityLevel() int {
+	return securityLevel(p.LogN(), p.LogQP())
+}
+
+// Validate comprueba que los parámetros CKKS ofrecen al menos minBits bits de seguridad
+func (p FloatParameters) Validate(minBits int) error {
+	return validateSecurity(p.LogN(), p.LogQP(), minBits)
+}
+
+// MaskBits devuelve log2 de la cota B de las máscaras del modo aproximado, que se toman uniformes en
+// [−B, B). B es el mayor rango codificable sin perder precisión: sus valores deben representarse
+// en float64 con la resolución de la escala, |b|·Δ < 2^52, y los tres términos de MultFloat, de
+// hasta B²·Δ², deben caber en el módulo del nivel máximo. Como a = m − b es público, b oculta m con
+// una distancia estadística |m|/(2B), es decir, unos MaskBits − log2|m| bits de seguridad
+// estadística.
+func (p FloatParameters) MaskBits() int {
+	return min(52-p.LogDefaultScale(), (int(p.LogQ())-2*p.LogDefaultScale()-4)/2)
+}
+
+// logQLevel devuelve log2 del módulo Q al nivel level
+func logQLevel(params FloatParameters, level int) float64 {
+	logQ := 0.0
+	for _, qi := range params.Q()[:level+1] {
+		logQ += math.Log2(float64(qi))
+	}
+	return logQ
+}
+
+// checkFloatProduct comprueba que β1·β2 + a1·β2 + a2·β1 cabe en el módulo del nivel de los
+// operandos. Como b oculta m, |b| es del orden de |a|, así que la cota se toma de los elementos A
+// públicos y de la de las máscaras.
+func checkFloatProduct(params FloatParameters, labeledciphertext1, labeledciphertext2 FloatLabeledciphertext) error {
+	bound := math.Ldexp(1, params.MaskBits())
+	for _, elementsA := range []FloatElements{labeledciphertext1.elementsA, labeledciphertext2.elementsA} {
+		for _, elementA := range elementsA {
+			bound = max(bound, math.Abs(elementA))
+		}
+	}
+
+	beta1 := &labeledciphertext1.elementsB[0][0]
+	beta2 := &labeledciphertext2.elementsB[0][0]
+	level := min(beta1.Level(), beta2.Level())
+
+	// Tres términos de hasta bound²·Δ1·Δ2 y el bit de signo
+	required := 2*math.Log2(bound) + math.Log2(beta1.Scale.Float64()) + math.Log2(beta2.Scale.Float64()) + 3
+	if available := logQLevel(params, level); required >= available {
+		return fmt.Errorf("labeling: el producto necesita %.0f bits y el módulo del nivel %d tiene %.0f", required, level, available)
+	}
+
+	return nil
+}
+
+// sampleFloatMask genera una máscara real uniforme en [−2^maskBits, 2^maskBits)
+func sampleFloatMask(prng sampling.PRNG, maskBits int) (float64, error) {
+	var buf [8]byte
+	if _, err := prng.Read(buf[:]); err != nil {
+		return 0, err
+	}
+	// Usamos los 53 bits de mayor peso como mantisa; escalar por una potencia de dos es exacto
+	return math.Ldexp(float64(binary.LittleEndian.Uint64(buf[:])>>11)/(1<<52)-1, maskBits), nil
+}
+
+// EncryptFloat cifra un vector de valores reales con la técnica de labeling sobre CKKS
+func EncryptFloat(params FloatParameters, key rlwe.EncryptionKey, value []float64) (FloatLabeledciphertext, error) {
+	// Instanciamos el generador de numeros aleatorios
+	prng, err := sampling.NewPRNG()
+	if err != nil {
+		return FloatLabeledciphertext{}, err
+	}
+
+	var labeledciphertext FloatLabeledciphertext
+	masks := make([]float64, params.MaxSlots())
+
+	labeledciphertext.elementsA = make(FloatElements, params.MaxSlots())
+	for i := range params.MaxSlots() {
+		// Generamos una mascara aleatoria para cada elemento del vector
+		mask, err := sampleFloatMask(prng, params.MaskBits())
+		if err != nil {
+			return labeledciphertext, err
+		}
+
+		// a ← m − b
+		if i < len(value) {
+			labeledciphertext.elementsA[i] = value[i] - mask
+		} else {
+			labeledciphertext.elementsA[i] = -mask
+		}
+		masks[i] = mask
+	}
+
+	// Codificamos y ciframos las mascaras
+	// β ← Enc(b)
+	maskPlaintext := ckks.NewPlaintext(params.Parameters, params.MaxLevel())
+	if err := ckks.NewEncoder(params.Parameters).Encode(masks, maskPlaintext); err != nil {
+		return labeledciphertext, err
+	}
+
+	ciphertextMask, err := rlwe.NewEncryptor(params, key).EncryptNew(maskPlaintext)
+	if err != nil {
+		return labeledciphertext, err
+	}
+
+	labeledciphertext.elementsB = [][]rlwe.Ciphertext{{*ciphertextMask}}
+	labeledciphertext.meta = deriveMetadata("EncryptFloat", false)
+
+	return labeledciphertext, nil
+}
+
+// DecryptFloat descifra un FloatLabeledciphertext
+func DecryptFloat(params FloatParameters, key *rlwe.SecretKey, labeledciphertext FloatLabeledciphertext) ([]float64, error) {
+	// m ← a + Dec(sk, β)
+	maskResult := make([]float64, params.MaxSlots())
+	if err := ckks.NewEncoder(params.Parameters).Decode(rlwe.NewDecryptor(params, key).DecryptNew(&labeledciphertext.elementsB[0][0]), maskResult); err != nil {
+		return nil, err
+	}
+
+	value := make([]float64, len(labeledciphertext.elementsA))
+	for i, elementA := range labeledciphertext.elementsA {
+		value[i] = elementA + maskResult[i]
+	}
+
+	return value, nil
+}
+
+// MultFloat multiplica dos FloatLabeledciphertext.
+// Todos los términos de β se calculan a escala Δ² para poder sumarlos y se reescala una sola vez al final.
+// Devuelve un error si esos términos no caben en el módulo del nivel de los operandos, en lugar de
+// dejar que se reduzcan módulo Q. El error absoluto del producto es del orden de 2^MaskBits/Δ por el
+// ruido de cifrado, así que la escala debe superar holgadamente a las máscaras y ser del tamaño del
+// último primo, que es el que elimina el reescalado.
+func MultFloat(params FloatParameters, labeledciphertext1, labeledciphertext2 FloatLabeledciphertext, key rlwe.EncryptionKey, evk *rlwe.MemEvaluationKeySet) (FloatLabeledciphertext, error) {
+	if err := checkFloatProduct(params, labeledciphertext1, labeledciphertext2); err != nil {
+		return FloatLabeledciphertext{}, err
+	}
+
+	prng, err := sampling.NewPRNG()
+	if err != nil {
+		return FloatLabeledciphertext{}, err
+	}
+
+	// a ← a1 × a2 − r
+	var labeledciphertextProduct FloatLabeledciphertext
+	randomVector := make([]float64, params.MaxSlots())
+	labeledciphertextProduct.elementsA = make(FloatElements, len(labeledciphertext1.elementsA))
+	for i := range labeledciphertext1.elementsA {
+		if randomVector[i], err = sampleFloatMask(prng, params.MaskBits()); err != nil {
+			return labeledciphertextProduct, err
+		}
+		labeledciphertextProduct.elementsA[i] = labeledciphertext1.elementsA[i]*labeledciphertext2.elementsA[i] - randomVector[i]
+	}
+
+	encoder := ckks.NewEncoder(params.Parameters)
+	evaluator := ckks.NewEvaluator(params.Parameters, evk)
+
+	beta1 := &labeledciphertext1.elementsB[0][0]
+	beta2 := &labeledciphertext2.elementsB[0][0]
+
+	// β1 × β2
+	product, err := evaluator.MulRelinNew(beta1, beta2)
+	if err != nil {
+		return labeledciphertextProduct, err
+	}
+
+	// a1β2
+	a1Plaintext := ckks.NewPlaintext(params.Parameters, beta2.Level())
+	a1Plaintext.Scale = beta1.Scale
+	if err := encoder.Encode([]float64(labeledciphertext1.elementsA), a1Plaintext); err != nil {
+		return labeledciphertextProduct, err
+	}
+	a1beta2, err := evaluator.MulNew(beta2, a1Plaintext)
+	if err != nil {
+		return labeledciphertextProduct, err
+	}
+
+	// a2β1
+	a2Plaintext := ckks.NewPlaintext(params.Parameters, beta1.Level())
+	a2Plaintext.Scale = beta2.Scale
+	if err := encoder.Encode([]float64(labeledciphertext2.elementsA), a2Plaintext); err != nil {
+		return labeledciphertextProduct, err
+	}
+	a2beta1, err := evaluator.MulNew(beta1, a2Plaintext)
+	if err != nil {
+		return labeledciphertextProduct, err
+	}
+
+	// Enc(pk, r) a la misma escala que el producto
+	randomPlaintext := ckks.NewPlaintext(params.Parameters, product.Level())
+	randomPlaintext.Scale = product.Scale
+	if err := encoder.Encode(randomVector, randomPlaintext); err != nil {
+		return labeledciphertextProduct, err
+	}
+	ciphertextRandomVector, err := rlwe.NewEncryptor(params, key).EncryptNew(randomPlaintext)
+	if err != nil {
+		return labeledciphertextProduct, err
+	}
+
+	// (β1 X β2) + a1β2 + a2β1 + Enc(pk, r)
+	for _, term := range []*rlwe.Ciphertext{a1beta2, a2beta1, ciphertextRandomVector} {
+		if err := evaluator.Add(product, term, product); err != nil {
+			return labeledciphertextProduct, err
+		}
+	}
+
+	// Reescalamos para volver a la escala por defecto
+	result := ckks.NewCiphertext(params.Parameters, 1, product.Level())
+	if err := evaluator.Rescale(product, result); err != nil {
+		return labeledciphertextProduct, err
+	}
+
+	labeledciphertextProduct.elementsB = [][]rlwe.Ciphertext{{*result}}
+	labeledciphertextProduct.meta = deriveMetadata("MultFloat", true, labeledciphertext1.meta, labeledciphertext2.meta)
+
+	return labeledciphertextProduct, nil
+}
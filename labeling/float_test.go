@@ -0,0 +1,101 @@
+// Copyright 2025 Juan Martín Pérez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Pruebas del modo aproximado sobre CKKS.
+
+package labeling
+
+import (
+	"math"
+	"testing"
+)
+
+// multFloatParameters devuelve parámetros CKKS inseguros con dos niveles de 40 bits, uno por
+// reescalado y otro de margen
+func multFloatParameters(t *testing.T) FloatParameters {
+	t.Helper()
+
+	params, err := NewFloatParametersFromLiteral(10, []int{60, 40, 40}, []int{60}, 40, AllowInsecure())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return params
+}
+
+func TestMaskBitsFitProduct(t *testing.T) {
+	// Los términos de MultFloat, de hasta 2^(2·MaskBits)·Δ², deben caber en Q
+	for _, logQ := range [][]int{{55, 40}, {60, 40, 40}} {
+		for _, logScale := range []int{20, 30, 40} {
+			params, err := NewFloatParametersFromLiteral(12, logQ, []int{55}, logScale, AllowInsecure())
+			if err != nil {
+				continue
+			}
+			if required := 2*(params.MaskBits()+logScale) + 3; float64(required) >= params.LogQ() {
+				t.Fatalf("LogQ %v, escala 2^%d: máscaras de %d bits necesitan %d bits en el producto", logQ, logScale, params.MaskBits(), required)
+			}
+		}
+	}
+}
+
+func TestMultFloat(t *testing.T) {
+	params := multFloatParameters(t)
+	sk, pk := GenerateKeyPair(params)
+	evk := GenerateMemEvaluationKeySetWithGalois(GenerateRelinearizationKey(params, sk))
+
+	values := []float64{1.5, 2.5, 3.5, -0.75}
+	x, err := EncryptFloat(params, pk, values)
+	if err != nil {
+		t.Fatal(err)
+	}
+	product, err := MultFloat(params, x, x, pk, evk)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := DecryptFloat(params, sk, product)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, value := range values {
+		if want := value * value; math.Abs(got[i]-want) > 1e-3 {
+			t.Fatalf("slot %d: se esperaba %f y se obtuvo %f", i, want, got[i])
+		}
+	}
+	for i := len(values); i < params.MaxSlots(); i++ {
+		if math.Abs(got[i]) > 1e-3 {
+			t.Fatalf("slot %d: se esperaba 0 y se obtuvo %f", i, got[i])
+		}
+	}
+}
+
+func TestMultFloatRejectsOverflow(t *testing.T) {
+	params := multFloatParameters(t)
+	sk, pk := GenerateKeyPair(params)
+	evk := GenerateMemEvaluationKeySetWithGalois(GenerateRelinearizationKey(params, sk))
+
+	x, err := EncryptFloat(params, pk, []float64{1.5, 2.5, 3.5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	product, err := MultFloat(params, x, x, pk, evk)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Los elementos A del producto son del orden de B², y su cuadrado a escala Δ² ya no cabe en el
+	// módulo del nivel que queda
+	if _, err := MultFloat(params, product, product, pk, evk); err == nil {
+		t.Fatal("MultFloat aceptó un producto que no cabe en el módulo")
+	}
+}
@@ -0,0 +1,74 @@
+// Copyright 2025 Juan Martín Pérez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package labeling
+
+import (
+	"errors"
+
+	"github.com/tuneinsight/lattigo/v6/core/rlwe"
+)
+
+// ErrMissingGaloisKey se devuelve cuando el conjunto de claves de evaluación no contiene una clave de Galois necesaria
+var ErrMissingGaloisKey = errors.New("labeling: falta una clave de Galois")
+
+// ExprGaloisElements devuelve, sin repetir y en orden de aparición, los elementos de Galois exactos que
+// necesita EvalExpr para las rotaciones de la expresión. Las rotaciones de constantes no requieren clave.
+// El propietario de la clave secreta puede generar solo estas claves en lugar de adivinarlas.
+func ExprGaloisElements(params Parameters, expr Expr) []uint64 {
+	var ks []int
+
+	var visit func(Expr)
+	visit = func(expr Expr) {
+		switch e := expr.(type) {
+		case AddExpr:
+			visit(e.Left)
+			visit(e.Right)
+		case MulExpr:
+			visit(e.Left)
+			visit(e.Right)
+		case RotateExpr:
+			visit(e.Operand)
+			if !isConstant(e.Operand) {
+				ks = append(ks, e.K)
+			}
+		}
+	}
+	visit(expr)
+
+	return ColumnRotationGaloisElements(params, ks...)
+}
+
+// MissingGaloisKeys devuelve los elementos de Galois de galEls que no tienen clave en evk
+func MissingGaloisKeys(evk *rlwe.MemEvaluationKeySet, galEls []uint64) []uint64 {
+	var missing []uint64
+	for _, galEl := range galEls {
+		if evk == nil {
+			missing = append(missing, galEl)
+			continue
+		}
+		if _, err := evk.GetGaloisKey(galEl); err != nil {
+			missing = append(missing, galEl)
+		}
+	}
+	return missing
+}
+
+// GenerateExprEvaluationKeySet genera la clave de relinealización y exactamente las claves de Galois
+// que requiere la expresión
+func GenerateExprEvaluationKeySet(params Parameters, sk *rlwe.SecretKey, expr Expr) *rlwe.MemEvaluationKeySet {
+	rlk := GenerateRelinearizationKey(params, sk)
+	galKeys := GenerateGaloisKeys(params, sk, ExprGaloisElements(params, expr))
+	return GenerateMemEvaluationKeySetWithGalois(rlk, galKeys...)
+}
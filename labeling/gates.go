@@ -0,0 +1,88 @@
+// Copyright 2025 Juan Martín Pérez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package labeling
+
+import "github.com/tuneinsight/lattigo/v6/core/rlwe"
+
+// Puertas lógicas sobre labeled ciphertexts cuyos slots valen 0 o 1. Las que multiplican usan Mult,
+// así que el resultado sigue en modo texto plano y puede encadenarse; Multiplications() refleja la
+// profundidad acumulada del circuito.
+
+// Not devuelve 1 − a, sin multiplicaciones
+func Not(params Parameters, labeledciphertext PlaintextLabeledciphertext) (PlaintextLabeledciphertext, error) {
+	negated, err := Negate(params, labeledciphertext)
+	if err != nil {
+		return negated, err
+	}
+
+	result, err := AddConst(params, negated, 1)
+	if err != nil {
+		return result, err
+	}
+
+	result.meta = deriveMetadata("Not", false, labeledciphertext.meta)
+
+	return result, nil
+}
+
+// And devuelve a·b
+func And(params Parameters, labeledciphertext1, labeledciphertext2 PlaintextLabeledciphertext, key rlwe.EncryptionKey, evk *rlwe.MemEvaluationKeySet) (PlaintextLabeledciphertext, error) {
+	result, err := Mult(params, labeledciphertext1, labeledciphertext2, key, evk)
+	if err != nil {
+		return result, err
+	}
+
+	result.meta = deriveMetadata("And", true, labeledciphertext1.meta, labeledciphertext2.meta)
+
+	return result, nil
+}
+
+// Or devuelve a + b − a·b
+func Or(params Parameters, labeledciphertext1, labeledciphertext2 PlaintextLabeledciphertext, key rlwe.EncryptionKey, evk *rlwe.MemEvaluationKeySet) (PlaintextLabeledciphertext, error) {
+	return sumMinusProduct(params, labeledciphertext1, labeledciphertext2, 1, "Or", key, evk)
+}
+
+// Xor devuelve a + b − 2·a·b
+func Xor(params Parameters, labeledciphertext1, labeledciphertext2 PlaintextLabeledciphertext, key rlwe.EncryptionKey, evk *rlwe.MemEvaluationKeySet) (PlaintextLabeledciphertext, error) {
+	return sumMinusProduct(params, labeledciphertext1, labeledciphertext2, 2, "Xor", key, evk)
+}
+
+// sumMinusProduct devuelve a + b − k·a·b
+func sumMinusProduct(params Parameters, labeledciphertext1, labeledciphertext2 PlaintextLabeledciphertext, k uint64, operation string, key rlwe.EncryptionKey, evk *rlwe.MemEvaluationKeySet) (PlaintextLabeledciphertext, error) {
+	product, err := Mult(params, labeledciphertext1, labeledciphertext2, key, evk)
+	if err != nil {
+		return product, err
+	}
+
+	if k != 1 {
+		if product, err = MulScalar(params, product, k); err != nil {
+			return product, err
+		}
+	}
+
+	sum, err := Sum(params.Parameters, labeledciphertext1, labeledciphertext2)
+	if err != nil {
+		return sum, err
+	}
+
+	result, err := Sub(params, sum, product)
+	if err != nil {
+		return result, err
+	}
+
+	result.meta = deriveMetadata(operation, true, labeledciphertext1.meta, labeledciphertext2.meta)
+
+	return result, nil
+}
@@ -0,0 +1,36 @@
+// Copyright 2025 Juan Martín Pérez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Soporte de encoding/gob.
+//
+// gob codifica los tipos de labeling con su MarshalBinary/UnmarshalBinary, así que se pueden
+// transportar directamente con net/rpc o colas de trabajos. Para que también viajen dentro de
+// interfaces, como Operand, se registran con nombres estables que no dependen de la ruta del
+// módulo.
+
+package labeling
+
+import "encoding/gob"
+
+func init() {
+	gob.RegisterName("labeling.PlaintextLabeledciphertext", PlaintextLabeledciphertext{})
+	gob.RegisterName("labeling.CiphertextLabeledciphertext", CiphertextLabeledciphertext{})
+	gob.RegisterName("labeling.SeededLabeledciphertext", SeededLabeledciphertext{})
+	gob.RegisterName("labeling.SeededCiphertext", SeededCiphertext{})
+	gob.RegisterName("labeling.SeededPublicKey", SeededPublicKey{})
+	gob.RegisterName("labeling.KeyBundle", KeyBundle{})
+	gob.RegisterName("labeling.DecryptionProof", DecryptionProof{})
+	gob.RegisterName("labeling.Attestation", Attestation{})
+	gob.RegisterName("labeling.Envelope", Envelope{})
+}
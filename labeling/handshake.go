@@ -0,0 +1,164 @@
+// Copyright 2025 Juan Martín Pérez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package labeling
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// ErrParamsRejected se devuelve cuando un mensaje de negociación no supera la validación
+var ErrParamsRejected = errors.New("labeling: parámetros rechazados")
+
+// KeyRequirements describe las claves de evaluación que necesitará la parte evaluadora
+type KeyRequirements struct {
+	Relinearization bool     `json:"relinearization"`
+	GaloisElements  []uint64 `json:"galois_elements,omitempty"`
+}
+
+// ParamsOffer es el mensaje con el que una parte propone uno o varios conjuntos de parámetros,
+// por orden de preferencia, junto con las claves que requerirá la computación
+type ParamsOffer struct {
+	Candidates   [][]byte        `json:"candidates"`
+	MinSecurity  int             `json:"min_security"`
+	Requirements KeyRequirements `json:"requirements"`
+}
+
+// ParamsAccept es la respuesta que identifica el candidato elegido por su índice y su hash
+type ParamsAccept struct {
+	Index        int               `json:"index"`
+	Hash         [sha256.Size]byte `json:"hash"`
+	Requirements KeyRequirements   `json:"requirements"`
+}
+
+// ParamsPolicy contiene las restricciones locales que el receptor de una oferta exige
+type ParamsPolicy struct {
+	// MinSecurity es el nivel de seguridad mínimo en bits
+	MinSecurity int
+	// PlaintextModulus, si no es 0, es el único módulo de texto plano aceptado
+	PlaintextModulus uint64
+	// MaxLogN, si no es 0, limita el grado del anillo aceptado
+	MaxLogN int
+}
+
+// NewParamsOffer construye una oferta con los candidatos en orden de preferencia
+func NewParamsOffer(minSecurity int, requirements KeyRequirements, candidates ...Parameters) (ParamsOffer, error) {
+	if len(candidates) == 0 {
+		return ParamsOffer{}, fmt.Errorf("%w: la oferta no contiene candidatos", ErrParamsRejected)
+	}
+
+	offer := ParamsOffer{
+		Candidates:   make([][]byte, len(candidates)),
+		MinSecurity:  minSecurity,
+		Requirements: requirements,
+	}
+
+	for i, params := range candidates {
+		if err := params.Validate(minSecurity); err != nil {
+			return ParamsOffer{}, err
+		}
+
+		data, err := params.MarshalBinary()
+		if err != nil {
+			return ParamsOffer{}, err
+		}
+		offer.Candidates[i] = data
+	}
+
+	return offer, nil
+}
+
+// Accept valida la oferta recibida contra la política local y devuelve el primer candidato
+// aceptable junto con el mensaje de aceptación que debe enviarse de vuelta
+func (o ParamsOffer) Accept(policy ParamsPolicy) (Parameters, ParamsAccept, error) {
+	minSecurity := max(o.MinSecurity, policy.MinSecurity)
+
+	var reasons []error
+	for i, data := range o.Candidates {
+		var params Parameters
+		if err := params.UnmarshalBinary(data); err != nil {
+			reasons = append(reasons, fmt.Errorf("candidato %d: %w", i, err))
+			continue
+		}
+
+		if err := o.validateCandidate(params, policy, minSecurity); err != nil {
+			reasons = append(reasons, fmt.Errorf("candidato %d: %w", i, err))
+			continue
+		}
+
+		hash, err := params.Hash()
+		if err != nil {
+			return Parameters{}, ParamsAccept{}, err
+		}
+
+		return params, ParamsAccept{Index: i, Hash: hash, Requirements: o.Requirements}, nil
+	}
+
+	return Parameters{}, ParamsAccept{}, fmt.Errorf("%w: ningún candidato es aceptable: %w", ErrParamsRejected, errors.Join(reasons...))
+}
+
+// validateCandidate comprueba un candidato deserializado frente a la política y los requisitos de claves
+func (o ParamsOffer) validateCandidate(params Parameters, policy ParamsPolicy, minSecurity int) error {
+	if err := params.Validate(minSecurity); err != nil {
+		return err
+	}
+
+	if policy.PlaintextModulus != 0 && params.PlaintextModulus() != policy.PlaintextModulus {
+		return fmt.Errorf("módulo de texto plano %d distinto del exigido %d", params.PlaintextModulus(), policy.PlaintextModulus)
+	}
+
+	if policy.MaxLogN != 0 && params.LogN() > policy.MaxLogN {
+		return fmt.Errorf("LogN=%d supera el máximo %d", params.LogN(), policy.MaxLogN)
+	}
+
+	// Los elementos de Galois válidos son impares y menores que 2N
+	for _, galEl := range o.Requirements.GaloisElements {
+		if galEl&1 == 0 || galEl >= uint64(2*params.N()) {
+			return fmt.Errorf("elemento de Galois %d inválido para N=%d", galEl, params.N())
+		}
+	}
+
+	return nil
+}
+
+// Verify comprueba, en la parte que hizo la oferta, que la aceptación recibida corresponde a uno
+// de los candidatos ofrecidos con los mismos requisitos, y devuelve los parámetros acordados
+func (o ParamsOffer) Verify(accept ParamsAccept) (Parameters, error) {
+	if accept.Index < 0 || accept.Index >= len(o.Candidates) {
+		return Parameters{}, fmt.Errorf("%w: índice %d fuera de rango", ErrParamsRejected, accept.Index)
+	}
+
+	var params Parameters
+	if err := params.UnmarshalBinary(o.Candidates[accept.Index]); err != nil {
+		return Parameters{}, err
+	}
+
+	hash, err := params.Hash()
+	if err != nil {
+		return Parameters{}, err
+	}
+
+	if hash != accept.Hash {
+		return Parameters{}, fmt.Errorf("%w: el hash no coincide con el candidato %d", ErrParamsRejected, accept.Index)
+	}
+
+	if accept.Requirements.Relinearization != o.Requirements.Relinearization || !slices.Equal(accept.Requirements.GaloisElements, o.Requirements.GaloisElements) {
+		return Parameters{}, fmt.Errorf("%w: los requisitos de claves no coinciden con los ofrecidos", ErrParamsRejected)
+	}
+
+	return params, nil
+}
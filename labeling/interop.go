@@ -0,0 +1,125 @@
+// Copyright 2025 Juan Martín Pérez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Interoperabilidad con cifrados BGV de lattigo.
+//
+// Un cifrado BGV ct de m ya es un labeled ciphertext sin volver a cifrar nada: basta con tomarlo
+// como β y elegir elementos A que sumen m al descifrar. Con MaskZero los elementos A son 0 y β es
+// el propio ct; con MaskRandom los elementos A son uniformes y β ← ct − a.
+// En los dos casos b = m − a es secreta porque lo es m.
+//
+// En sentido contrario, ToBGVCiphertexts devuelve cifrados BGV independientes del labeled
+// ciphertext: en forma plaintext un único Enc(m) ← β + a, y en forma overflow α y los βs de cada
+// término, con m = Dec(α) + ∑ ∏ Dec(βj). Multiplicar los βs de cada término exigiría claves de
+// relinealización y profundidad, así que se deja al llamador.
+
+package labeling
+
+import (
+	"fmt"
+
+	"github.com/tuneinsight/lattigo/v6/core/rlwe"
+	"github.com/tuneinsight/lattigo/v6/schemes/bgv"
+)
+
+// MaskMode elige los elementos A al importar un cifrado BGV
+type MaskMode int
+
+const (
+	// MaskZero toma el cifrado como β con elementos A nulos, sin aleatoriedad ni operaciones
+	MaskZero MaskMode = iota
+	// MaskRandom elige elementos A uniformes en [0, t) y toma β ← ct − a, de modo que los elementos A
+	// no delatan que el labeled ciphertext procede de un cifrado BGV
+	MaskRandom
+)
+
+// FromBGVCiphertext envuelve un cifrado BGV de grado 1 de un vector m en un
+// PlaintextLabeledciphertext de m, sin volver a cifrarlo. El cifrado no se modifica.
+func FromBGVCiphertext(params Parameters, ct *rlwe.Ciphertext, maskMode MaskMode) (PlaintextLabeledciphertext, error) {
+	var result PlaintextLabeledciphertext
+
+	if ct == nil {
+		return result, fmt.Errorf("labeling: cifrado BGV nil")
+	}
+	if ct.Degree() != 1 {
+		return result, fmt.Errorf("labeling: se esperaba un cifrado BGV de grado 1 y tiene grado %d; debe relinealizarse antes", ct.Degree())
+	}
+
+	var elementsA PlaintextElements
+	var beta *rlwe.Ciphertext
+
+	switch maskMode {
+	case MaskZero:
+		// a ← 0, β ← ct
+		elementsA = make(PlaintextElements, params.MaxSlots())
+		beta = ct.CopyNew()
+
+	case MaskRandom:
+		offsets, err := sampleOffsets(params.MaxSlots(), params.PlaintextModulus())
+		if err != nil {
+			return result, err
+		}
+
+		// a uniforme, β ← ct − a
+		elementsA = offsets
+		if beta, err = bgv.NewEvaluator(params.Parameters, nil).SubNew(ct, offsets); err != nil {
+			return result, err
+		}
+
+	default:
+		return result, fmt.Errorf("labeling: modo de máscara %d desconocido", maskMode)
+	}
+
+	result.elementsA = elementsA
+	result.elementsB = [][]rlwe.Ciphertext{{*beta}}
+	result.meta = deriveMetadata("FromBGVCiphertext", false)
+
+	return result, nil
+}
+
+// ToBGVCiphertexts extrae cifrados BGV independientes del labeled ciphertext: alpha y los βs de
+// cada término, con m = Dec(alpha) + ∑ ∏ Dec(βj). En forma plaintext alpha es directamente Enc(m)
+// y no hay términos.
+func ToBGVCiphertexts[T PlaintextElements | *CiphertextElement](params Parameters, lc Labeledciphertext[T]) (alpha *rlwe.Ciphertext, terms [][]*rlwe.Ciphertext, err error) {
+	switch elementsA := any(lc.elementsA).(type) {
+	case PlaintextElements:
+		if len(lc.elementsB) != 1 || len(lc.elementsB[0]) != 1 {
+			return nil, nil, fmt.Errorf("labeling: un labeled ciphertext en forma plaintext requiere un único β")
+		}
+
+		// Enc(m) ← β + a
+		a := make([]uint64, params.MaxSlots())
+		copy(a, elementsA)
+		if alpha, err = bgv.NewEvaluator(params.Parameters, nil).AddNew(&lc.elementsB[0][0], a); err != nil {
+			return nil, nil, err
+		}
+		return alpha, nil, nil
+
+	case *CiphertextElement:
+		if elementsA == nil {
+			return nil, nil, fmt.Errorf("labeling: labeled ciphertext sin α")
+		}
+		alpha = (*rlwe.Ciphertext)(elementsA).CopyNew()
+	}
+
+	terms = make([][]*rlwe.Ciphertext, len(lc.elementsB))
+	for i := range lc.elementsB {
+		terms[i] = make([]*rlwe.Ciphertext, len(lc.elementsB[i]))
+		for j := range lc.elementsB[i] {
+			terms[i][j] = lc.elementsB[i][j].CopyNew()
+		}
+	}
+
+	return alpha, terms, nil
+}
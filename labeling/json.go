@@ -0,0 +1,288 @@
+// Copyright 2025 Juan Martín Pérez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Serialización JSON.
+//
+// Pensada para depurar con herramientas estándar y para clientes web. Las partes legibles (forma,
+// elementos A, metadatos, elementos de Galois) van como campos JSON, y cada cifrado o clave como
+// su serialización binaria en base64. Solo se exporta material público: las claves secretas no
+// tienen representación JSON.
+//
+// Los elementos A son enteros en [0, t); JavaScript solo los representa exactamente si t < 2^53.
+
+package labeling
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/tuneinsight/lattigo/v6/core/rlwe"
+	"github.com/tuneinsight/lattigo/v6/multiparty"
+)
+
+// labeledciphertextJSON es la representación JSON de un labeled ciphertext
+type labeledciphertextJSON struct {
+	Form            string     `json:"form"`
+	ElementsA       []uint64   `json:"elementsA,omitempty"`
+	Alpha           []byte     `json:"alpha,omitempty"`
+	ElementsB       [][][]byte `json:"elementsB"`
+	Multiplications int        `json:"multiplications"`
+	MaxDegree       int        `json:"maxDegree,omitempty"`
+	MaxTerms        int        `json:"maxTerms,omitempty"`
+	Operations      []string   `json:"operations,omitempty"`
+	Labels          []Label    `json:"labels,omitempty"`
+}
+
+const (
+	jsonFormPlaintext = "plaintext"
+	jsonFormOverflow  = "overflow"
+)
+
+// MarshalJSON serializa el labeled ciphertext en JSON, con α y cada β en base64
+func (lc Labeledciphertext[T]) MarshalJSON() ([]byte, error) {
+	aux := labeledciphertextJSON{
+		ElementsB:       make([][][]byte, len(lc.elementsB)),
+		Multiplications: lc.meta.multiplications,
+		MaxDegree:       lc.meta.maxDegree,
+		MaxTerms:        lc.meta.maxTerms,
+		Operations:      lc.meta.operations,
+		Labels:          lc.meta.labels,
+	}
+
+	var err error
+	switch elementsA := any(lc.elementsA).(type) {
+	case PlaintextElements:
+		aux.Form = jsonFormPlaintext
+		aux.ElementsA = append([]uint64{}, elementsA...)
+
+	case *CiphertextElement:
+		if elementsA == nil {
+			return nil, fmt.Errorf("labeling: labeled ciphertext sin α")
+		}
+		aux.Form = jsonFormOverflow
+		if aux.Alpha, err = (*rlwe.Ciphertext)(elementsA).MarshalBinary(); err != nil {
+			return nil, err
+		}
+
+	default:
+		return nil, fmt.Errorf("%w: serialización de %T", ErrUnsupportedOperands, lc.elementsA)
+	}
+
+	for i, term := range lc.elementsB {
+		aux.ElementsB[i] = make([][]byte, len(term))
+		for j := range term {
+			if aux.ElementsB[i][j], err = term[j].MarshalBinary(); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return json.Marshal(aux)
+}
+
+// UnmarshalJSON reconstruye el labeled ciphertext a partir de su serialización JSON. La forma debe
+// coincidir con la del tipo, como en UnmarshalBinary, y el resultado debe comprobarse con Check si
+// los datos proceden de una fuente no confiable.
+func (lc *Labeledciphertext[T]) UnmarshalJSON(data []byte) error {
+	var aux labeledciphertextJSON
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	var result Labeledciphertext[T]
+
+	switch any(result.elementsA).(type) {
+	case PlaintextElements:
+		if aux.Form != jsonFormPlaintext {
+			return fmt.Errorf("labeling: se esperaba un labeled ciphertext en forma plaintext")
+		}
+		result.elementsA = any(append(PlaintextElements{}, aux.ElementsA...)).(T)
+
+	case *CiphertextElement:
+		if aux.Form != jsonFormOverflow {
+			return fmt.Errorf("labeling: se esperaba un labeled ciphertext en forma overflow")
+		}
+		alpha := new(rlwe.Ciphertext)
+		if err := alpha.UnmarshalBinary(aux.Alpha); err != nil {
+			return err
+		}
+		result.elementsA = any((*CiphertextElement)(alpha)).(T)
+
+	default:
+		return fmt.Errorf("%w: serialización de %T", ErrUnsupportedOperands, result.elementsA)
+	}
+
+	if aux.Form == jsonFormPlaintext && (len(aux.ElementsB) != 1 || len(aux.ElementsB[0]) != 1) {
+		return fmt.Errorf("labeling: un labeled ciphertext en forma plaintext requiere un único β")
+	}
+
+	result.elementsB = make([][]rlwe.Ciphertext, len(aux.ElementsB))
+	for i, term := range aux.ElementsB {
+		result.elementsB[i] = make([]rlwe.Ciphertext, len(term))
+		for j, beta := range term {
+			if err := result.elementsB[i][j].UnmarshalBinary(beta); err != nil {
+				return err
+			}
+		}
+	}
+
+	result.meta = metadata{
+		multiplications: aux.Multiplications,
+		operations:      aux.Operations,
+		maxDegree:       aux.MaxDegree,
+		maxTerms:        aux.MaxTerms,
+		labels:          aux.Labels,
+	}
+
+	*lc = result
+
+	return nil
+}
+
+// galoisKeyJSON es una clave de Galois con su elemento visible
+type galoisKeyJSON struct {
+	GaloisElement uint64 `json:"galoisElement"`
+	Key           []byte `json:"key"`
+}
+
+// keyBundleJSON es la representación JSON de KeyBundle
+type keyBundleJSON struct {
+	PublicKey          []byte           `json:"publicKey,omitempty"`
+	RelinearizationKey []byte           `json:"relinearizationKey,omitempty"`
+	GaloisKeys         []galoisKeyJSON  `json:"galoisKeys,omitempty"`
+	EvaluationKeys     map[KeyID][]byte `json:"evaluationKeys,omitempty"`
+}
+
+// MarshalJSON serializa el paquete en JSON, con cada clave en base64
+func (b KeyBundle) MarshalJSON() ([]byte, error) {
+	var aux keyBundleJSON
+	var err error
+
+	if b.PublicKey != nil {
+		if aux.PublicKey, err = b.PublicKey.MarshalBinary(); err != nil {
+			return nil, err
+		}
+	}
+
+	if b.RelinearizationKey != nil {
+		if aux.RelinearizationKey, err = b.RelinearizationKey.MarshalBinary(); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, gk := range b.GaloisKeys {
+		key, err := gk.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		aux.GaloisKeys = append(aux.GaloisKeys, galoisKeyJSON{GaloisElement: gk.GaloisElement, Key: key})
+	}
+
+	if len(b.EvaluationKeys) > 0 {
+		aux.EvaluationKeys = make(map[KeyID][]byte, len(b.EvaluationKeys))
+		for id, evk := range b.EvaluationKeys {
+			if aux.EvaluationKeys[id], err = evk.MarshalBinary(); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return json.Marshal(aux)
+}
+
+// UnmarshalJSON reconstruye el paquete a partir de su serialización JSON
+func (b *KeyBundle) UnmarshalJSON(data []byte) error {
+	var aux keyBundleJSON
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	var bundle KeyBundle
+
+	if aux.PublicKey != nil {
+		bundle.PublicKey = new(rlwe.PublicKey)
+		if err := bundle.PublicKey.UnmarshalBinary(aux.PublicKey); err != nil {
+			return err
+		}
+	}
+
+	if aux.RelinearizationKey != nil {
+		bundle.RelinearizationKey = new(rlwe.RelinearizationKey)
+		if err := bundle.RelinearizationKey.UnmarshalBinary(aux.RelinearizationKey); err != nil {
+			return err
+		}
+	}
+
+	for _, entry := range aux.GaloisKeys {
+		gk := new(rlwe.GaloisKey)
+		if err := gk.UnmarshalBinary(entry.Key); err != nil {
+			return err
+		}
+		if gk.GaloisElement != entry.GaloisElement {
+			return fmt.Errorf("labeling: la clave de Galois no corresponde al elemento %d", entry.GaloisElement)
+		}
+		bundle.GaloisKeys = append(bundle.GaloisKeys, gk)
+	}
+
+	if len(aux.EvaluationKeys) > 0 {
+		bundle.EvaluationKeys = make(map[KeyID]*rlwe.EvaluationKey, len(aux.EvaluationKeys))
+		for id, data := range aux.EvaluationKeys {
+			evk := new(rlwe.EvaluationKey)
+			if err := evk.UnmarshalBinary(data); err != nil {
+				return err
+			}
+			bundle.EvaluationKeys[id] = evk
+		}
+	}
+
+	*b = bundle
+
+	return nil
+}
+
+// seededPublicKeyJSON es la representación JSON de SeededPublicKey
+type seededPublicKeyJSON struct {
+	Seed  []byte `json:"seed"`
+	Share []byte `json:"share"`
+}
+
+// MarshalJSON serializa la clave en JSON, con la semilla y −a·s + e en base64
+func (s SeededPublicKey) MarshalJSON() ([]byte, error) {
+	share, err := s.Share.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(seededPublicKeyJSON{Seed: s.Seed, Share: share})
+}
+
+// UnmarshalJSON reconstruye la clave a partir de su serialización JSON
+func (s *SeededPublicKey) UnmarshalJSON(data []byte) error {
+	var aux seededPublicKeyJSON
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	if len(aux.Seed) != SeedSize {
+		return fmt.Errorf("labeling: semilla de %d bytes, se esperaban %d", len(aux.Seed), SeedSize)
+	}
+
+	var share multiparty.PublicKeyGenShare
+	if err := share.UnmarshalBinary(aux.Share); err != nil {
+		return err
+	}
+
+	s.Seed = aux.Seed
+	s.Share = share
+
+	return nil
+}
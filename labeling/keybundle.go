@@ -0,0 +1,291 @@
+// Copyright 2025 Juan Martín Pérez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Formato de paquete de claves.
+//
+// Un KeyBundle reúne en un único blob todas las claves públicas que un servidor necesita para
+// operar con los labeled ciphertexts de un cliente. La serialización empieza por la cabecera
+// "LBKB" y un byte de versión, seguida de una lista de registros:
+//
+//	tipo (1 byte) | longitud (8 bytes, little endian) | contenido
+//
+// Las claves de evaluación llevan además su identificador al principio del contenido, precedido
+// de su longitud en 4 bytes. Los registros de tipo desconocido se ignoran, de modo que versiones
+// anteriores pueden leer paquetes con tipos de clave nuevos.
+
+package labeling
+
+import (
+	"encoding"
+	"encoding/binary"
+	"fmt"
+	"slices"
+
+	"github.com/tuneinsight/lattigo/v6/core/rlwe"
+)
+
+// keyBundleMagic es la cabecera de la serialización de un KeyBundle
+var keyBundleMagic = []byte("LBKB")
+
+// keyBundleVersion es la versión del formato de serialización
+const keyBundleVersion = 1
+
+// keyTag identifica el tipo de clave de un registro del paquete
+type keyTag byte
+
+const (
+	keyTagPublicKey keyTag = iota + 1
+	keyTagRelinearizationKey
+	keyTagGaloisKey
+	keyTagEvaluationKey
+	keyTagSecretKey
+	keyTagEvaluationKeySet
+	// keyTagSigningKey solo se usa en las huellas de las claves de firma Ed25519
+	keyTagSigningKey
+)
+
+// KeyBundle contiene las claves públicas de un cliente. Cualquier campo puede estar vacío.
+type KeyBundle struct {
+	PublicKey          *rlwe.PublicKey
+	RelinearizationKey *rlwe.RelinearizationKey
+	GaloisKeys         []*rlwe.GaloisKey
+	// EvaluationKeys son las claves de cambio de clave, por ejemplo hacia otras identidades
+	EvaluationKeys map[KeyID]*rlwe.EvaluationKey
+}
+
+// NewKeyBundle crea un paquete con la clave pública y las claves de un conjunto de evaluación
+func NewKeyBundle(pk *rlwe.PublicKey, evk *rlwe.MemEvaluationKeySet) *KeyBundle {
+	bundle := &KeyBundle{PublicKey: pk}
+
+	if evk != nil {
+		bundle.RelinearizationKey = evk.RelinearizationKey
+
+		galEls := evk.GetGaloisKeysList()
+		slices.Sort(galEls)
+		for _, galEl := range galEls {
+			if gk, err := evk.GetGaloisKey(galEl); err == nil {
+				bundle.GaloisKeys = append(bundle.GaloisKeys, gk)
+			}
+		}
+	}
+
+	return bundle
+}
+
+// EvaluationKeySet devuelve el conjunto de evaluación con la clave de relinealización y las
+// claves de Galois del paquete, listo para EvalExpr y el resto de operaciones
+func (b *KeyBundle) EvaluationKeySet() *rlwe.MemEvaluationKeySet {
+	return rlwe.NewMemEvaluationKeySet(b.RelinearizationKey, b.GaloisKeys...)
+}
+
+// appendKeyRecord añade un registro de tipo tag con el contenido prefix seguido de la clave
+func appendKeyRecord(data []byte, tag keyTag, prefix []byte, key encoding.BinaryMarshaler) ([]byte, error) {
+	content, err := key.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+
+	data = append(data, byte(tag))
+	data = binary.LittleEndian.AppendUint64(data, uint64(len(prefix)+len(content)))
+	data = append(data, prefix...)
+	return append(data, content...), nil
+}
+
+// MarshalBinary serializa el paquete en el formato descrito en la cabecera del fichero
+func (b KeyBundle) MarshalBinary() ([]byte, error) {
+	data := append(append([]byte{}, keyBundleMagic...), keyBundleVersion)
+
+	var err error
+	if b.PublicKey != nil {
+		if data, err = appendKeyRecord(data, keyTagPublicKey, nil, b.PublicKey); err != nil {
+			return nil, err
+		}
+	}
+
+	if b.RelinearizationKey != nil {
+		if data, err = appendKeyRecord(data, keyTagRelinearizationKey, nil, b.RelinearizationKey); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, gk := range b.GaloisKeys {
+		if data, err = appendKeyRecord(data, keyTagGaloisKey, nil, gk); err != nil {
+			return nil, err
+		}
+	}
+
+	// Ordenamos los identificadores para que la serialización sea determinista
+	ids := make([]KeyID, 0, len(b.EvaluationKeys))
+	for id := range b.EvaluationKeys {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+
+	for _, id := range ids {
+		prefix := binary.LittleEndian.AppendUint32(nil, uint32(len(id)))
+		prefix = append(prefix, id...)
+		if data, err = appendKeyRecord(data, keyTagEvaluationKey, prefix, b.EvaluationKeys[id]); err != nil {
+			return nil, err
+		}
+	}
+
+	return data, nil
+}
+
+// UnmarshalBinary reconstruye el paquete a partir de su serialización binaria
+func (b *KeyBundle) UnmarshalBinary(data []byte) error {
+	header := len(keyBundleMagic) + 1
+	if len(data) < header || string(data[:len(keyBundleMagic)]) != string(keyBundleMagic) {
+		return fmt.Errorf("labeling: la serialización no es un KeyBundle")
+	}
+	if version := data[len(keyBundleMagic)]; version != keyBundleVersion {
+		return fmt.Errorf("labeling: versión de KeyBundle %d no soportada", version)
+	}
+	data = data[header:]
+
+	var bundle KeyBundle
+
+	for len(data) > 0 {
+		if len(data) < 9 {
+			return fmt.Errorf("labeling: registro de KeyBundle truncado")
+		}
+
+		tag := keyTag(data[0])
+		length := binary.LittleEndian.Uint64(data[1:9])
+		data = data[9:]
+		if length > uint64(len(data)) {
+			return fmt.Errorf("labeling: registro de KeyBundle truncado")
+		}
+		content := data[:length]
+		data = data[length:]
+
+		switch tag {
+		case keyTagPublicKey:
+			if bundle.PublicKey != nil {
+				return fmt.Errorf("labeling: KeyBundle con más de una clave pública")
+			}
+			bundle.PublicKey = new(rlwe.PublicKey)
+			if err := bundle.PublicKey.UnmarshalBinary(content); err != nil {
+				return err
+			}
+
+		case keyTagRelinearizationKey:
+			if bundle.RelinearizationKey != nil {
+				return fmt.Errorf("labeling: KeyBundle con más de una clave de relinealización")
+			}
+			bundle.RelinearizationKey = new(rlwe.RelinearizationKey)
+			if err := bundle.RelinearizationKey.UnmarshalBinary(content); err != nil {
+				return err
+			}
+
+		case keyTagGaloisKey:
+			gk := new(rlwe.GaloisKey)
+			if err := gk.UnmarshalBinary(content); err != nil {
+				return err
+			}
+			bundle.GaloisKeys = append(bundle.GaloisKeys, gk)
+
+		case keyTagEvaluationKey:
+			if len(content) < 4 {
+				return fmt.Errorf("labeling: clave de evaluación de KeyBundle truncada")
+			}
+			n := binary.LittleEndian.Uint32(content)
+			if uint64(n) > uint64(len(content)-4) {
+				return fmt.Errorf("labeling: clave de evaluación de KeyBundle truncada")
+			}
+			id := KeyID(content[4 : 4+n])
+
+			evk := new(rlwe.EvaluationKey)
+			if err := evk.UnmarshalBinary(content[4+n:]); err != nil {
+				return err
+			}
+
+			if bundle.EvaluationKeys == nil {
+				bundle.EvaluationKeys = make(map[KeyID]*rlwe.EvaluationKey)
+			}
+			if _, ok := bundle.EvaluationKeys[id]; ok {
+				return fmt.Errorf("labeling: KeyBundle con la clave de evaluación %q repetida", id)
+			}
+			bundle.EvaluationKeys[id] = evk
+
+		default:
+			// Tipo de clave de una versión posterior: lo saltamos
+		}
+	}
+
+	*b = bundle
+
+	return nil
+}
+
+// MergeEvaluationKeySets devuelve un conjunto de evaluación nuevo con la clave de relinealización y
+// la unión de las claves de Galois de a y b, que pueden ser nil. Permite acumular en un único
+// conjunto claves recibidas en momentos distintos. Devuelve un error si ambos contienen claves
+// distintas para el mismo propósito.
+func MergeEvaluationKeySets(a, b *rlwe.MemEvaluationKeySet) (*rlwe.MemEvaluationKeySet, error) {
+	var rlk *rlwe.RelinearizationKey
+	galoisKeys := make(map[uint64]*rlwe.GaloisKey)
+
+	for _, evk := range []*rlwe.MemEvaluationKeySet{a, b} {
+		if evk == nil {
+			continue
+		}
+
+		if evk.RelinearizationKey != nil {
+			if rlk != nil && !rlk.GadgetCiphertext.Equal(&evk.RelinearizationKey.GadgetCiphertext) {
+				return nil, fmt.Errorf("labeling: los conjuntos tienen claves de relinealización distintas")
+			}
+			rlk = evk.RelinearizationKey
+		}
+
+		for _, galEl := range evk.GetGaloisKeysList() {
+			gk, err := evk.GetGaloisKey(galEl)
+			if err != nil {
+				return nil, err
+			}
+			if existing, ok := galoisKeys[galEl]; ok && !existing.GadgetCiphertext.Equal(&gk.GadgetCiphertext) {
+				return nil, fmt.Errorf("labeling: los conjuntos tienen claves de Galois distintas para el elemento %d", galEl)
+			}
+			galoisKeys[galEl] = gk
+		}
+	}
+
+	galEls := make([]uint64, 0, len(galoisKeys))
+	for galEl := range galoisKeys {
+		galEls = append(galEls, galEl)
+	}
+	slices.Sort(galEls)
+
+	galKeys := make([]*rlwe.GaloisKey, len(galEls))
+	for i, galEl := range galEls {
+		galKeys[i] = galoisKeys[galEl]
+	}
+
+	return rlwe.NewMemEvaluationKeySet(rlk, galKeys...), nil
+}
+
+// MarshalEvaluationKeySet serializa un conjunto de evaluación en el formato de KeyBundle
+func MarshalEvaluationKeySet(evk *rlwe.MemEvaluationKeySet) ([]byte, error) {
+	return NewKeyBundle(nil, evk).MarshalBinary()
+}
+
+// UnmarshalEvaluationKeySet reconstruye un conjunto de evaluación serializado con
+// MarshalEvaluationKeySet. Las claves que no forman parte de un conjunto de evaluación se ignoran.
+func UnmarshalEvaluationKeySet(data []byte) (*rlwe.MemEvaluationKeySet, error) {
+	var bundle KeyBundle
+	if err := bundle.UnmarshalBinary(data); err != nil {
+		return nil, err
+	}
+	return bundle.EvaluationKeySet(), nil
+}
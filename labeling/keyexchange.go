@@ -0,0 +1,87 @@
+// Copyright 2025 Juan Martín Pérez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Generación interactiva de la clave de evaluación de A a B.
+//
+// GenerateEvaluationKey requiere que una parte tenga las dos claves secretas. La clave de A a B
+// es, para cada elemento gᵢ del gadget, (−aᵢ·s_B + s_A·P·gᵢ + eᵢ, aᵢ), y puede repartirse en
+// una contribución de cada parte con aᵢ derivado de una semilla pública común:
+//
+//  1. B calcula (−aᵢ·s_B + eᵢ) y se la envía a A. Son muestras RLWE, que no revelan s_B.
+//  2. A suma su contribución s_A·P·gᵢ + e'ᵢ y obtiene la clave de evaluación.
+//
+// La contribución de A nunca sale de A, ya que sin el término −aᵢ·s_B revelaría s_A. La clave
+// resultante puede publicarse para que un evaluador cambie labeled ciphertexts de A a B.
+
+package labeling
+
+import (
+	"github.com/tuneinsight/lattigo/v6/core/rlwe"
+	"github.com/tuneinsight/lattigo/v6/multiparty"
+	"github.com/tuneinsight/lattigo/v6/utils/sampling"
+)
+
+// EvaluationKeyExchange coordina la generación de la clave de evaluación de A a B sin compartir claves secretas
+type EvaluationKeyExchange struct {
+	params   Parameters
+	protocol multiparty.EvaluationKeyGenProtocol
+	crp      multiparty.EvaluationKeyGenCRP
+	zero     *rlwe.SecretKey
+}
+
+// NewEvaluationKeyExchange inicia el protocolo. A y B deben usar la misma semilla pública.
+func NewEvaluationKeyExchange(params Parameters, seed []byte) (*EvaluationKeyExchange, error) {
+	crs, err := sampling.NewKeyedPRNG(seed)
+	if err != nil {
+		return nil, err
+	}
+
+	protocol := multiparty.NewEvaluationKeyGenProtocol(params)
+
+	return &EvaluationKeyExchange{
+		params:   params,
+		protocol: protocol,
+		crp:      protocol.SampleCRP(crs),
+		zero:     rlwe.NewSecretKey(params),
+	}, nil
+}
+
+// TargetShare es el paso de B: calcula con su clave secreta el mensaje que envía a A
+func (e *EvaluationKeyExchange) TargetShare(skB *rlwe.SecretKey) (multiparty.EvaluationKeyGenShare, error) {
+	share := e.protocol.AllocateShare()
+	if err := e.protocol.GenShare(e.zero, skB, e.crp, &share); err != nil {
+		return share, err
+	}
+	return share, nil
+}
+
+// Finish es el paso de A: añade su contribución al mensaje de B y devuelve la clave de evaluación de A a B,
+// equivalente a GenerateEvaluationKey(params, skA, skB)
+func (e *EvaluationKeyExchange) Finish(skA *rlwe.SecretKey, targetShare multiparty.EvaluationKeyGenShare) (*rlwe.EvaluationKey, error) {
+	share := e.protocol.AllocateShare()
+	if err := e.protocol.GenShare(skA, e.zero, e.crp, &share); err != nil {
+		return nil, err
+	}
+
+	if err := e.protocol.AggregateShares(share, targetShare, &share); err != nil {
+		return nil, err
+	}
+
+	evk := rlwe.NewEvaluationKey(e.params)
+	if err := e.protocol.GenEvaluationKey(share, e.crp, evk); err != nil {
+		return nil, err
+	}
+
+	return evk, nil
+}
@@ -0,0 +1,151 @@
+// Copyright 2025 Juan Martín Pérez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package labeling
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/tuneinsight/lattigo/v6/core/rlwe"
+	"github.com/tuneinsight/lattigo/v6/utils/structs"
+)
+
+// GaloisKeyProvider obtiene bajo demanda las claves de Galois que faltan al evaluador.
+// Puede generarlas localmente, pedirlas al cliente que tiene la clave secreta o servirlas desde una caché.
+type GaloisKeyProvider interface {
+	GaloisKey(galEl uint64) (*rlwe.GaloisKey, error)
+}
+
+// GaloisKeyProviderFunc adapta una función, por ejemplo una llamada remota al propietario de la clave,
+// a GaloisKeyProvider
+type GaloisKeyProviderFunc func(galEl uint64) (*rlwe.GaloisKey, error)
+
+// GaloisKey llama a f
+func (f GaloisKeyProviderFunc) GaloisKey(galEl uint64) (*rlwe.GaloisKey, error) {
+	return f(galEl)
+}
+
+// LocalGaloisKeyProvider genera las claves con la clave secreta. Solo tiene sentido cuando el evaluador
+// y el propietario de la clave son la misma parte.
+type LocalGaloisKeyProvider struct {
+	params rlwe.ParameterProvider
+	sk     *rlwe.SecretKey
+}
+
+// NewLocalGaloisKeyProvider crea un proveedor que genera las claves con sk
+func NewLocalGaloisKeyProvider(params rlwe.ParameterProvider, sk *rlwe.SecretKey) *LocalGaloisKeyProvider {
+	return &LocalGaloisKeyProvider{params: params, sk: sk}
+}
+
+// GaloisKey genera la clave de Galois de galEl
+func (p *LocalGaloisKeyProvider) GaloisKey(galEl uint64) (*rlwe.GaloisKey, error) {
+	return GenerateGaloisKeys(p.params, p.sk, []uint64{galEl})[0], nil
+}
+
+// CachedGaloisKeyProvider guarda las claves obtenidas de otro proveedor para no pedirlas dos veces.
+// Es seguro para uso concurrente: las peticiones al proveedor subyacente se hacen sin bloquear la
+// caché, y las que llegan mientras se obtiene una clave esperan a esa misma petición.
+type CachedGaloisKeyProvider struct {
+	provider GaloisKeyProvider
+	mu       sync.Mutex
+	keys     map[uint64]*rlwe.GaloisKey
+	pending  map[uint64]*galoisKeyCall
+}
+
+// galoisKeyCall es una petición en curso al proveedor subyacente; done se cierra al terminar
+type galoisKeyCall struct {
+	done chan struct{}
+	key  *rlwe.GaloisKey
+	err  error
+}
+
+// NewCachedGaloisKeyProvider crea una caché sobre provider
+func NewCachedGaloisKeyProvider(provider GaloisKeyProvider) *CachedGaloisKeyProvider {
+	return &CachedGaloisKeyProvider{
+		provider: provider,
+		keys:     make(map[uint64]*rlwe.GaloisKey),
+		pending:  make(map[uint64]*galoisKeyCall),
+	}
+}
+
+// GaloisKey devuelve la clave de la caché o la pide al proveedor subyacente. Los errores no se
+// guardan: la siguiente petición del mismo elemento vuelve a intentarlo.
+func (p *CachedGaloisKeyProvider) GaloisKey(galEl uint64) (*rlwe.GaloisKey, error) {
+	p.mu.Lock()
+	if key, ok := p.keys[galEl]; ok {
+		p.mu.Unlock()
+		return key, nil
+	}
+	if call, ok := p.pending[galEl]; ok {
+		p.mu.Unlock()
+		<-call.done
+		return call.key, call.err
+	}
+	call := &galoisKeyCall{done: make(chan struct{})}
+	p.pending[galEl] = call
+	p.mu.Unlock()
+
+	// La petición puede ser remota, así que se hace fuera del cerrojo
+	call.key, call.err = p.provider.GaloisKey(galEl)
+
+	p.mu.Lock()
+	delete(p.pending, galEl)
+	if call.err == nil {
+		p.keys[galEl] = call.key
+	}
+	p.mu.Unlock()
+	close(call.done)
+
+	return call.key, call.err
+}
+
+// EnsureGaloisKeys añade a evk las claves de galEls que le faltan, pidiéndolas a provider. Las
+// funciones de rotación no reciben un proveedor: EvalExprWithProvider la llama con
+// ExprGaloisElements, y para rotar directamente hay que llamarla antes con los elementos de
+// ColumnRotationGaloisElements, InnerSumGaloisElements, SlidingSumGaloisElements o
+// TransposeGaloisElements.
+func EnsureGaloisKeys(evk *rlwe.MemEvaluationKeySet, provider GaloisKeyProvider, galEls []uint64) error {
+	missing := MissingGaloisKeys(evk, galEls)
+	if len(missing) == 0 {
+		return nil
+	}
+
+	if evk == nil {
+		return fmt.Errorf("%w: no hay conjunto de claves de evaluación", ErrMissingGaloisKey)
+	}
+	if evk.GaloisKeys == nil {
+		evk.GaloisKeys = make(structs.Map[uint64, rlwe.GaloisKey])
+	}
+
+	for _, galEl := range missing {
+		key, err := provider.GaloisKey(galEl)
+		if err != nil {
+			return fmt.Errorf("labeling: no se pudo obtener la clave de Galois %d: %w", galEl, err)
+		}
+		evk.GaloisKeys[galEl] = key
+	}
+
+	return nil
+}
+
+// EvalExprWithProvider evalúa la expresión como EvalExpr, pidiendo antes a provider las claves de Galois
+// que faltan en evk
+func EvalExprWithProvider(params Parameters, expr Expr, inputs map[string]PlaintextLabeledciphertext, key rlwe.EncryptionKey, evk *rlwe.MemEvaluationKeySet, provider GaloisKeyProvider, opts ...MultiplyOption) (Operand, error) {
+	if err := EnsureGaloisKeys(evk, provider, ExprGaloisElements(params, expr)); err != nil {
+		return nil, err
+	}
+
+	return EvalExpr(params, expr, inputs, key, evk, opts...)
+}
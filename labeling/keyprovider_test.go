@@ -0,0 +1,152 @@
+// Copyright 2025 Juan Martín Pérez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Pruebas de la caché de claves de Galois y de su uso antes de rotar.
+
+package labeling
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/tuneinsight/lattigo/v6/core/rlwe"
+)
+
+func TestCachedGaloisKeyProviderConcurrent(t *testing.T) {
+	params, err := InsecureTestParameters()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sk, _ := GenerateKeyPair(params)
+	local := NewLocalGaloisKeyProvider(params, sk)
+
+	slow := params.GaloisElementForColRotation(1)
+	fast := params.GaloisElementForColRotation(2)
+
+	// El proveedor se bloquea con slow hasta que fast se haya servido
+	release := make(chan struct{})
+	var calls atomic.Int32
+	cached := NewCachedGaloisKeyProvider(GaloisKeyProviderFunc(func(galEl uint64) (*rlwe.GaloisKey, error) {
+		calls.Add(1)
+		if galEl == slow {
+			<-release
+		}
+		return local.GaloisKey(galEl)
+	}))
+
+	var wg sync.WaitGroup
+	keys := make([]*rlwe.GaloisKey, 4)
+	for i := range keys {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			keys[i], _ = cached.GaloisKey(slow)
+		}()
+	}
+
+	done := make(chan error)
+	go func() {
+		_, err := cached.GaloisKey(fast)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("la petición de otra clave esperó a la petición en curso")
+	}
+
+	close(release)
+	wg.Wait()
+
+	for i, key := range keys {
+		if key == nil || key != keys[0] {
+			t.Fatalf("petición %d: se esperaba la misma clave para todas las peticiones concurrentes", i)
+		}
+	}
+	if got := calls.Load(); got != 2 {
+		t.Fatalf("se esperaban 2 peticiones al proveedor y se hicieron %d", got)
+	}
+}
+
+func TestCachedGaloisKeyProviderRetriesErrors(t *testing.T) {
+	fail := errors.New("sin conexión")
+	var calls int
+	cached := NewCachedGaloisKeyProvider(GaloisKeyProviderFunc(func(galEl uint64) (*rlwe.GaloisKey, error) {
+		calls++
+		if calls == 1 {
+			return nil, fail
+		}
+		return &rlwe.GaloisKey{}, nil
+	}))
+
+	if _, err := cached.GaloisKey(5); !errors.Is(err, fail) {
+		t.Fatalf("se esperaba el error del proveedor y se obtuvo %v", err)
+	}
+	if _, err := cached.GaloisKey(5); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 2 {
+		t.Fatalf("se esperaban 2 peticiones al proveedor y se hicieron %d", calls)
+	}
+}
+
+func TestEnsureGaloisKeysBeforeRotation(t *testing.T) {
+	params, err := InsecureTestParameters()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sk, pk := GenerateKeyPair(params)
+	evk := GenerateMemEvaluationKeySet(GenerateRelinearizationKey(params, sk))
+
+	values := make([]uint64, params.MaxSlots())
+	for i := range values {
+		values[i] = uint64(i)
+	}
+	lc, err := Encrypt(params, pk, values)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	galEls := ColumnRotationGaloisElements(params, 3)
+	if missing := MissingGaloisKeys(evk, galEls); len(missing) != 1 {
+		t.Fatalf("se esperaba que faltara una clave y faltan %d", len(missing))
+	}
+	if err := EnsureGaloisKeys(evk, NewLocalGaloisKeyProvider(params, sk), galEls); err != nil {
+		t.Fatal(err)
+	}
+
+	rotated, err := RotateColumns(params, lc, 3, evk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := Decrypt(params, sk, rotated)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Las columnas son dos filas de MaxSlots()/2 slots que rotan por separado
+	half := params.MaxSlots() / 2
+	want := make([]uint64, len(values))
+	for i := range want {
+		row := i / half * half
+		want[i] = values[row+(i-row+3)%half]
+	}
+	checkValues(t, got, want)
+}
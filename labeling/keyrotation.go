@@ -0,0 +1,162 @@
+// Copyright 2025 Juan Martín Pérez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package labeling
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/tuneinsight/lattigo/v6/core/rlwe"
+)
+
+// CiphertextStore guarda labeled ciphertexts de cualquier forma por identificador, cada uno con la
+// huella (KeyFingerprint) de la clave secreta bajo la que está cifrado
+type CiphertextStore interface {
+	// Keys devuelve los identificadores de todos los labeled ciphertexts guardados
+	Keys() ([]string, error)
+	// Get devuelve el labeled ciphertext con el identificador id y la huella de su clave, que es la
+	// huella nula si no se conoce, o ErrKeyNotFound
+	Get(id string) (Operand, Fingerprint, error)
+	// Put guarda el labeled ciphertext con el identificador id y la huella de su clave, sustituyendo
+	// el anterior si existía. Ambos deben guardarse de forma atómica.
+	Put(id string, labeledciphertext Operand, key Fingerprint) error
+}
+
+// storedCiphertext es una entrada de MemCiphertextStore
+type storedCiphertext struct {
+	labeledciphertext Operand
+	key               Fingerprint
+}
+
+// MemCiphertextStore es un CiphertextStore en memoria, seguro para uso concurrente
+type MemCiphertextStore struct {
+	mu                 sync.RWMutex
+	labeledciphertexts map[string]storedCiphertext
+}
+
+// NewMemCiphertextStore crea un CiphertextStore en memoria vacío
+func NewMemCiphertextStore() *MemCiphertextStore {
+	return &MemCiphertextStore{labeledciphertexts: make(map[string]storedCiphertext)}
+}
+
+// Keys devuelve los identificadores en orden
+func (s *MemCiphertextStore) Keys() ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	keys := make([]string, 0, len(s.labeledciphertexts))
+	for id := range s.labeledciphertexts {
+		keys = append(keys, id)
+	}
+	slices.Sort(keys)
+
+	return keys, nil
+}
+
+// Get devuelve el labeled ciphertext con el identificador id y la huella de su clave
+func (s *MemCiphertextStore) Get(id string) (Operand, Fingerprint, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	stored, ok := s.labeledciphertexts[id]
+	if !ok {
+		return nil, Fingerprint{}, fmt.Errorf("%w: %q", ErrKeyNotFound, id)
+	}
+	return stored.labeledciphertext, stored.key, nil
+}
+
+// Put guarda el labeled ciphertext con el identificador id y la huella de su clave
+func (s *MemCiphertextStore) Put(id string, labeledciphertext Operand, key Fingerprint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.labeledciphertexts[id] = storedCiphertext{labeledciphertext: labeledciphertext, key: key}
+	return nil
+}
+
+// KeyRotation es el progreso de RotateKeys
+type KeyRotation struct {
+	// Rotated es el número de labeled ciphertexts recifrados en esta llamada
+	Rotated int
+	// Skipped es el número de labeled ciphertexts que ya estaban cifrados con la clave nueva
+	Skipped int
+	// LastKey es el identificador del último labeled ciphertext recifrado. Si RotateKeys se
+	// interrumpe, basta con volver a llamarla con resumeAfter = LastKey.
+	LastKey string
+}
+
+// RotateKeys genera la clave de evaluación de oldSk a newSk y recifra con ella todos los labeled
+// ciphertexts del almacén, uno a uno y en orden de identificador, sin cargar el corpus en memoria.
+// Cada labeled ciphertext se guarda con la huella de newSk, y los que ya la tienen se omiten, así que
+// volver a llamarla tras una caída no recifra dos veces ninguna entrada aunque se haya perdido
+// LastKey. Una entrada con la huella de una clave distinta de oldSk y newSk es un error; la huella
+// nula se trata como oldSk. Si resumeAfter no está vacío se omiten además los identificadores
+// menores o iguales, para no volver a leer las entradas ya recorridas.
+func RotateKeys(params Parameters, oldSk, newSk *rlwe.SecretKey, store CiphertextStore, resumeAfter string) (KeyRotation, error) {
+	var progress KeyRotation
+
+	oldKey, err := KeyFingerprint(oldSk)
+	if err != nil {
+		return progress, err
+	}
+	newKey, err := KeyFingerprint(newSk)
+	if err != nil {
+		return progress, err
+	}
+
+	keys, err := store.Keys()
+	if err != nil {
+		return progress, err
+	}
+	slices.Sort(keys)
+
+	evk := GenerateEvaluationKey(params, oldSk, newSk)
+
+	for _, id := range keys {
+		if resumeAfter != "" && id <= resumeAfter {
+			continue
+		}
+
+		labeledciphertext, key, err := store.Get(id)
+		if err != nil {
+			return progress, err
+		}
+
+		switch key {
+		case newKey:
+			progress.Skipped++
+			continue
+		case oldKey, Fingerprint{}:
+			// Pendiente de rotar
+		default:
+			return progress, fmt.Errorf("labeling: rotación de %q: cifrado con la clave %s, que no es la antigua ni la nueva", id, key)
+		}
+
+		rotated, err := applyEvaluationKeyOperand(params, evk, labeledciphertext)
+		if err != nil {
+			return progress, fmt.Errorf("labeling: rotación de %q: %w", id, err)
+		}
+
+		if err := store.Put(id, rotated, newKey); err != nil {
+			return progress, err
+		}
+
+		progress.Rotated++
+		progress.LastKey = id
+	}
+
+	return progress, nil
+}
@@ -0,0 +1,119 @@
+// Copyright 2025 Juan Martín Pérez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package labeling
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/tuneinsight/lattigo/v6/core/rlwe"
+)
+
+// ErrKeyNotFound se devuelve cuando no hay ninguna clave con el identificador pedido
+var ErrKeyNotFound = errors.New("labeling: clave no encontrada")
+
+// KeyID identifica una clave en un KeyStore
+type KeyID string
+
+// Key son los tipos de clave que admite un KeyStore
+type Key interface {
+	*rlwe.SecretKey | *rlwe.PublicKey | *rlwe.RelinearizationKey | *rlwe.GaloisKey | *rlwe.EvaluationKey | *rlwe.MemEvaluationKeySet
+}
+
+// KeyStore guarda las claves por identificador, de modo que un servidor con muchos usuarios
+// no tenga que pasar las estructuras de claves de una función a otra
+type KeyStore interface {
+	// Get devuelve la clave con el identificador id, o ErrKeyNotFound
+	Get(id KeyID) (any, error)
+	// Put guarda la clave con el identificador id, sustituyendo la anterior si existía
+	Put(id KeyID, key any) error
+	// Delete elimina la clave con el identificador id, o devuelve ErrKeyNotFound
+	Delete(id KeyID) error
+}
+
+// checkKeyType comprueba que key es de uno de los tipos de Key
+func checkKeyType(key any) error {
+	switch key.(type) {
+	case *rlwe.SecretKey, *rlwe.PublicKey, *rlwe.RelinearizationKey, *rlwe.GaloisKey, *rlwe.EvaluationKey, *rlwe.MemEvaluationKeySet:
+		return nil
+	}
+	return fmt.Errorf("labeling: tipo de clave %T no soportado", key)
+}
+
+// GetKey devuelve la clave con el identificador id comprobando su tipo
+func GetKey[K Key](store KeyStore, id KeyID) (K, error) {
+	var zero K
+
+	key, err := store.Get(id)
+	if err != nil {
+		return zero, err
+	}
+
+	typed, ok := key.(K)
+	if !ok {
+		return zero, fmt.Errorf("labeling: la clave %q es de tipo %T, no %T", id, key, zero)
+	}
+
+	return typed, nil
+}
+
+// MemKeyStore es un KeyStore en memoria, seguro para uso concurrente
+type MemKeyStore struct {
+	mu   sync.RWMutex
+	keys map[KeyID]any
+}
+
+// NewMemKeyStore crea un KeyStore en memoria vacío
+func NewMemKeyStore() *MemKeyStore {
+	return &MemKeyStore{keys: make(map[KeyID]any)}
+}
+
+// Get devuelve la clave con el identificador id
+func (s *MemKeyStore) Get(id KeyID) (any, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	key, ok := s.keys[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrKeyNotFound, id)
+	}
+	return key, nil
+}
+
+// Put guarda la clave con el identificador id
+func (s *MemKeyStore) Put(id KeyID, key any) error {
+	if err := checkKeyType(key); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.keys[id] = key
+	return nil
+}
+
+// Delete elimina la clave con el identificador id
+func (s *MemKeyStore) Delete(id KeyID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.keys[id]; !ok {
+		return fmt.Errorf("%w: %q", ErrKeyNotFound, id)
+	}
+	delete(s.keys, id)
+	return nil
+}
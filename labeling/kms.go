@@ -0,0 +1,201 @@
+// Copyright 2025 Juan Martín Pérez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Envoltura de claves con un KMS externo.
+//
+// Los servicios de gestión de claves (AWS KMS, Google Cloud KMS...) solo cifran unos pocos
+// kilobytes, mientras que una clave secreta RLWE serializada ocupa cientos. Por eso se usa cifrado
+// de sobre: cada clave se cifra con AES-256-GCM bajo una clave de datos aleatoria, y es esa clave
+// de datos la que el KMS envuelve. El resultado es
+//
+//	longitud de la clave envuelta (4 bytes) | clave envuelta | nonce (12 bytes) | texto cifrado
+//
+// La clave maestra nunca sale del KMS, de modo que sus políticas de acceso, auditoría y rotación
+// se aplican también a las claves de labeling. En examples/kms hay implementaciones de KeyWrapper
+// para AWS y Google Cloud.
+
+package labeling
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/tuneinsight/lattigo/v6/core/rlwe"
+)
+
+// dataKeySize es el tamaño en bytes de las claves de datos AES-256
+const dataKeySize = 32
+
+// KeyWrapper envuelve y desenvuelve claves de datos con una clave maestra que no abandona el KMS.
+// aad son datos adicionales autenticados que deben coincidir al desenvolver.
+type KeyWrapper interface {
+	WrapKey(ctx context.Context, key, aad []byte) ([]byte, error)
+	UnwrapKey(ctx context.Context, wrapped, aad []byte) ([]byte, error)
+}
+
+// LocalKeyWrapper es un KeyWrapper con la clave maestra en memoria, para desarrollo y pruebas
+// sin acceso a un KMS
+type LocalKeyWrapper struct {
+	aead cipher.AEAD
+}
+
+// NewLocalKeyWrapper crea un KeyWrapper local con una clave maestra AES de 16, 24 o 32 bytes
+func NewLocalKeyWrapper(masterKey []byte) (*LocalKeyWrapper, error) {
+	block, err := aes.NewCipher(masterKey)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	return &LocalKeyWrapper{aead: aead}, nil
+}
+
+// WrapKey cifra key con la clave maestra
+func (w *LocalKeyWrapper) WrapKey(ctx context.Context, key, aad []byte) ([]byte, error) {
+	nonce := make([]byte, w.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return w.aead.Seal(nonce, nonce, key, aad), nil
+}
+
+// UnwrapKey descifra una clave envuelta con WrapKey
+func (w *LocalKeyWrapper) UnwrapKey(ctx context.Context, wrapped, aad []byte) ([]byte, error) {
+	if len(wrapped) < w.aead.NonceSize() {
+		return nil, fmt.Errorf("labeling: clave envuelta truncada")
+	}
+	return w.aead.Open(nil, wrapped[:w.aead.NonceSize()], wrapped[w.aead.NonceSize():], aad)
+}
+
+// SealWithKMS cifra data con una clave de datos nueva y la envuelve con wrapper
+func SealWithKMS(ctx context.Context, wrapper KeyWrapper, data, aad []byte) ([]byte, error) {
+	dataKey := make([]byte, dataKeySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, err
+	}
+
+	wrapped, err := wrapper.WrapKey(ctx, dataKey, aad)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	sealed := binary.LittleEndian.AppendUint32(nil, uint32(len(wrapped)))
+	sealed = append(sealed, wrapped...)
+	sealed = append(sealed, nonce...)
+	return aead.Seal(sealed, nonce, data, aad), nil
+}
+
+// OpenWithKMS desenvuelve la clave de datos con wrapper y descifra el resultado de SealWithKMS
+func OpenWithKMS(ctx context.Context, wrapper KeyWrapper, sealed, aad []byte) ([]byte, error) {
+	if len(sealed) < 4 {
+		return nil, fmt.Errorf("labeling: sobre de KMS truncado")
+	}
+	n := binary.LittleEndian.Uint32(sealed)
+	sealed = sealed[4:]
+	if uint64(n) > uint64(len(sealed)) {
+		return nil, fmt.Errorf("labeling: sobre de KMS truncado")
+	}
+
+	dataKey, err := wrapper.UnwrapKey(ctx, sealed[:n], aad)
+	if err != nil {
+		return nil, err
+	}
+	sealed = sealed[n:]
+
+	block, err := aes.NewCipher(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sealed) < aead.NonceSize() {
+		return nil, fmt.Errorf("labeling: sobre de KMS truncado")
+	}
+
+	data, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], aad)
+	if err != nil {
+		return nil, fmt.Errorf("labeling: sobre de KMS alterado: %w", err)
+	}
+	return data, nil
+}
+
+// WrapSecretKey serializa la clave secreta y la cifra en un sobre envuelto por el KMS
+func WrapSecretKey(ctx context.Context, wrapper KeyWrapper, sk *rlwe.SecretKey) ([]byte, error) {
+	data, err := sk.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return SealWithKMS(ctx, wrapper, data, nil)
+}
+
+// UnwrapSecretKey recupera una clave secreta cifrada con WrapSecretKey
+func UnwrapSecretKey(ctx context.Context, wrapper KeyWrapper, sealed []byte) (*rlwe.SecretKey, error) {
+	data, err := OpenWithKMS(ctx, wrapper, sealed, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	sk := new(rlwe.SecretKey)
+	if err := sk.UnmarshalBinary(data); err != nil {
+		return nil, err
+	}
+	return sk, nil
+}
+
+// kmsSealer cifra los registros de un PersistentKeyStore con sobres envueltos por un KMS
+type kmsSealer struct {
+	wrapper KeyWrapper
+}
+
+func (k kmsSealer) mode() byte { return 2 }
+
+func (k kmsSealer) seal(aad, content []byte) ([]byte, error) {
+	return SealWithKMS(context.Background(), k.wrapper, content, aad)
+}
+
+func (k kmsSealer) open(aad, sealed []byte) ([]byte, error) {
+	return OpenWithKMS(context.Background(), k.wrapper, sealed, aad)
+}
+
+// NewKMSKeyStore crea un KeyStore sobre kv que cifra las claves secretas en reposo con sobres
+// envueltos por wrapper. Como KeyStore no recibe un contexto, los límites de tiempo de las
+// llamadas al KMS deben configurarse en el propio wrapper.
+func NewKMSKeyStore(kv KV, wrapper KeyWrapper) *PersistentKeyStore {
+	return &PersistentKeyStore{kv: kv, sealer: kmsSealer{wrapper: wrapper}}
+}
@@ -0,0 +1,240 @@
+// Copyright 2025 Juan Martín Pérez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package labeling
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"math/bits"
+	"slices"
+	"sync"
+	"sync/atomic"
+
+	"github.com/tuneinsight/lattigo/v6/core/rlwe"
+	"github.com/tuneinsight/lattigo/v6/ring"
+	"github.com/tuneinsight/lattigo/v6/schemes/bgv"
+	"github.com/tuneinsight/lattigo/v6/utils/sampling"
+)
+
+// ErrLabelReused se devuelve al cifrar por segunda vez con la misma etiqueta
+var ErrLabelReused = errors.New("labeling: etiqueta ya usada")
+
+// Label identifica un dato de entrada del propietario, como en la literatura de labeled HE.
+// Las máscaras son deterministas, b = F_K(label), así que cada etiqueta debe cifrar un único valor
+// con cada LabelKey: con dos cifrados a1 = m1 − b y a2 = m2 − b, cualquiera obtiene a1 − a2 = m1 − m2.
+// LabelRegistry hace cumplir ese requisito.
+type Label string
+
+// LabelKey es la clave de la PRF que deriva las máscaras de las etiquetas: b = F_K(label)
+type LabelKey [32]byte
+
+// GenerateLabelKey genera una clave de PRF aleatoria
+func GenerateLabelKey() (LabelKey, error) {
+	var key LabelKey
+	if _, err := rand.Read(key[:]); err != nil {
+		return key, err
+	}
+	return key, nil
+}
+
+// Masks devuelve las máscaras F_K(label), uniformes en [0, t), una por slot.
+// La semilla de un PRNG con clave se obtiene como HMAC-SHA256(K, label), de modo que el propietario
+// puede recalcular las máscaras en local sin guardarlas ni descifrar β.
+func (k LabelKey) Masks(params Parameters, label Label) ([]uint64, error) {
+	mac := hmac.New(sha256.New, k[:])
+	mac.Write([]byte(label))
+
+	prng, err := sampling.NewKeyedPRNG(mac.Sum(nil))
+	if err != nil {
+		return nil, err
+	}
+
+	t := params.PlaintextModulus()
+	mask := uint64(1)<<bits.Len64(t) - 1
+
+	masks := make([]uint64, params.MaxSlots())
+	for i := range masks {
+		masks[i] = ring.RandUniform(prng, t, mask)
+	}
+
+	return masks, nil
+}
+
+// LabelRegistry lleva las etiquetas ya usadas con una LabelKey, para que ninguna cifre dos valores.
+// Debe haber un único registro por LabelKey; si el propietario cifra desde varios procesos o a lo
+// largo de varias ejecuciones, debe persistir Labels() y restaurarlo con NewLabelRegistry.
+// Es seguro para uso concurrente.
+type LabelRegistry struct {
+	mu   sync.Mutex
+	used map[Label]struct{}
+}
+
+// NewLabelRegistry crea un registro con las etiquetas used ya usadas
+func NewLabelRegistry(used ...Label) *LabelRegistry {
+	registry := &LabelRegistry{used: make(map[Label]struct{}, len(used))}
+	for _, label := range used {
+		registry.used[label] = struct{}{}
+	}
+	return registry
+}
+
+// Reserve marca las etiquetas como usadas. Si alguna ya lo estaba, o se repite en labels, devuelve
+// ErrLabelReused sin marcar ninguna.
+func (r *LabelRegistry) Reserve(labels ...Label) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, label := range labels {
+		if _, ok := r.used[label]; ok || slices.Contains(labels[:i], label) {
+			return fmt.Errorf("%w: %q", ErrLabelReused, label)
+		}
+	}
+	for _, label := range labels {
+		r.used[label] = struct{}{}
+	}
+
+	return nil
+}
+
+// Labels devuelve las etiquetas usadas, para persistirlas
+func (r *LabelRegistry) Labels() []Label {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	labels := make([]Label, 0, len(r.used))
+	for label := range r.used {
+		labels = append(labels, label)
+	}
+	slices.Sort(labels)
+
+	return labels
+}
+
+// PreparedMask es el resultado de la fase offline para una etiqueta: las máscaras y su cifrado β
+type PreparedMask struct {
+	label Label
+	masks []uint64
+	beta  rlwe.Ciphertext
+	used  atomic.Bool
+}
+
+// Label devuelve la etiqueta para la que se preparó la máscara
+func (p *PreparedMask) Label() Label {
+	return p.label
+}
+
+// PrepareMasks es la fase offline del cifrado: deriva las máscaras de cada etiqueta y las cifra.
+// Es la parte costosa y puede ejecutarse por adelantado, antes de conocer los datos. Las etiquetas
+// quedan reservadas en registry, el de labelKey, y falla con ErrLabelReused si alguna ya se usó.
+func PrepareMasks(params Parameters, key rlwe.EncryptionKey, labelKey LabelKey, registry *LabelRegistry, labels ...Label) ([]*PreparedMask, error) {
+	if registry == nil {
+		return nil, fmt.Errorf("labeling: PrepareMasks requiere el registro de etiquetas de la clave")
+	}
+	if err := registry.Reserve(labels...); err != nil {
+		return nil, err
+	}
+
+	encoder := bgv.NewEncoder(params.Parameters)
+	encryptor := rlwe.NewEncryptor(params, key)
+
+	prepared := make([]*PreparedMask, len(labels))
+	for i, label := range labels {
+		masks, err := labelKey.Masks(params, label)
+		if err != nil {
+			return nil, err
+		}
+
+		// β ← Enc(b)
+		maskPlaintext := bgv.NewPlaintext(params.Parameters, params.MaxLevel())
+		if err := encoder.Encode(masks, maskPlaintext); err != nil {
+			return nil, err
+		}
+
+		beta, err := encryptor.EncryptNew(maskPlaintext)
+		if err != nil {
+			return nil, err
+		}
+
+		prepared[i] = &PreparedMask{label: label, masks: masks, beta: *beta}
+	}
+
+	return prepared, nil
+}
+
+// EncryptOnline es la fase online del cifrado: solo calcula a ← m − b con una máscara preparada,
+// sin cifrar nada. Cada máscara preparada solo puede usarse una vez.
+func EncryptOnline(params Parameters, prepared *PreparedMask, value []uint64) (PlaintextLabeledciphertext, error) {
+	if prepared.used.Swap(true) {
+		return PlaintextLabeledciphertext{}, fmt.Errorf("%w: la máscara de la etiqueta %q ya se ha usado", ErrLabelReused, prepared.label)
+	}
+
+	var labeledciphertext PlaintextLabeledciphertext
+
+	// a ← m − b
+	t := params.PlaintextModulus()
+	labeledciphertext.elementsA = make(PlaintextElements, len(prepared.masks))
+	for i, mask := range prepared.masks {
+		var m uint64
+		if i < len(value) {
+			m = value[i] % t
+		}
+		labeledciphertext.elementsA[i] = (m + t - mask) % t
+	}
+
+	labeledciphertext.elementsB = [][]rlwe.Ciphertext{{prepared.beta}}
+	labeledciphertext.meta = deriveMetadata("EncryptLabeled", false)
+	labeledciphertext.meta.labels = []Label{prepared.label}
+
+	return labeledciphertext, nil
+}
+
+// EncryptLabeled cifra un vector con máscaras derivadas de la etiqueta: a ← m − F_K(label) y
+// β ← Enc(F_K(label)). Equivale a PrepareMasks seguido de EncryptOnline, así que cada etiqueta solo
+// puede cifrarse una vez por registro. La etiqueta queda registrada en el labeled ciphertext y en
+// los que se deriven de él.
+func EncryptLabeled(params Parameters, key rlwe.EncryptionKey, labelKey LabelKey, registry *LabelRegistry, label Label, value []uint64) (PlaintextLabeledciphertext, error) {
+	prepared, err := PrepareMasks(params, key, labelKey, registry, label)
+	if err != nil {
+		return PlaintextLabeledciphertext{}, err
+	}
+
+	return EncryptOnline(params, prepared[0], value)
+}
+
+// DecryptLabeled descifra un labeled ciphertext recién cifrado con EncryptLabeled sin la clave secreta:
+// m = a + F_K(label). Solo es válido mientras el labeled ciphertext no se haya operado.
+func DecryptLabeled(params Parameters, labelKey LabelKey, labeledciphertext PlaintextLabeledciphertext) ([]uint64, error) {
+	labels := labeledciphertext.Labels()
+	operations := labeledciphertext.meta.operations
+	if len(labels) != 1 || len(operations) != 1 || operations[0] != "EncryptLabeled" {
+		return nil, fmt.Errorf("labeling: DecryptLabeled solo admite labeled ciphertexts recién cifrados con una etiqueta")
+	}
+
+	masks, err := labelKey.Masks(params, labels[0])
+	if err != nil {
+		return nil, err
+	}
+
+	t := params.PlaintextModulus()
+	value := make([]uint64, len(labeledciphertext.elementsA))
+	for i, elementA := range labeledciphertext.elementsA {
+		value[i] = (elementA + masks[i]) % t
+	}
+
+	return value, nil
+}
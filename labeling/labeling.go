@@ -15,13 +15,13 @@
 package labeling
 
 import (
+	"fmt"
 	"math"
 	"math/bits"
 
 	"github.com/tuneinsight/lattigo/v6/core/rlwe"
 	"github.com/tuneinsight/lattigo/v6/ring"
 	"github.com/tuneinsight/lattigo/v6/schemes/bgv"
-	"github.com/tuneinsight/lattigo/v6/utils"
 	"github.com/tuneinsight/lattigo/v6/utils/sampling"
 )
 
@@ -33,6 +33,7 @@ type CiphertextElement rlwe.Ciphertext
 type Labeledciphertext[T any] struct {
 	elementsA T
 	elementsB [][]rlwe.Ciphertext
+	meta      metadata
 }
 
 // Aliases de tipo para mayor claridad
@@ -42,31 +43,48 @@ type CiphertextLabeledciphertext = Labeledciphertext[*CiphertextElement]
 // Servicio para manejar operaciones con Labeledciphertext
 type Parameters struct {
 	bgv.Parameters
+	scheme Scheme
 }
 
 // Constructor del servicio
-func NewParametersFromLiteral(logN int, LogQ []int, LogP []int, PlaintextModulus uint64) (Parameters, error) {
-	params, err := bgv.NewParametersFromLiteral(bgv.ParametersLiteral{
-		LogN:             14,
-		LogQ:             []int{56, 55, 55, 54, 54, 54},
-		LogP:             []int{55, 55},
-		PlaintextModulus: 0x3ee0001,
-	})
+// Por defecto rechaza parámetros por debajo de 128 bits de seguridad, salvo que se pase AllowInsecure()
+func NewParametersFromLiteral(logN int, LogQ []int, LogP []int, PlaintextModulus uint64, opts ...ParametersOption) (Parameters, error) {
+	options := newParametersOptions(opts)
+
+	literal := bgv.ParametersLiteral{
+		LogN:             logN,
+		LogQ:             LogQ,
+		LogP:             LogP,
+		PlaintextModulus: PlaintextModulus,
+	}
 
+	// BFV comparte los parámetros de BGV; solo cambia la multiplicación (ver mulRelin)
+	if options.scheme != SchemeBGV && options.scheme != SchemeBFV {
+		return Parameters{}, fmt.Errorf("labeling: esquema desconocido %d", options.scheme)
+	}
+
+	bgvParams, err := bgv.NewParametersFromLiteral(literal)
 	if err != nil {
 		return Parameters{}, err
 	}
+	params := Parameters{Parameters: bgvParams, scheme: options.scheme}
 
-	return Parameters{params}, nil
+	if !options.allowInsecure {
+		if err := params.Validate(options.minSecurity); err != nil {
+			return Parameters{}, err
+		}
+	}
+
+	return params, nil
 }
 
-func GenerateKeyPair(params Parameters) (*rlwe.SecretKey, rlwe.EncryptionKey) {
+func GenerateKeyPair(params rlwe.ParameterProvider) (*rlwe.SecretKey, rlwe.EncryptionKey) {
 	kgen := rlwe.NewKeyGenerator(params)
 	sk, pk := kgen.GenKeyPairNew()
 	return sk, pk
 }
 
-func GenerateRelinearizationKey(params Parameters, sk *rlwe.SecretKey) *rlwe.RelinearizationKey {
+func GenerateRelinearizationKey(params rlwe.ParameterProvider, sk *rlwe.SecretKey) *rlwe.RelinearizationKey {
 	kgen := rlwe.NewKeyGenerator(params)
 	return kgen.GenRelinearizationKeyNew(sk)
 }
@@ -75,7 +93,7 @@ func GenerateMemEvaluationKeySet(rlk *rlwe.RelinearizationKey) *rlwe.MemEvaluati
 	return rlwe.NewMemEvaluationKeySet(rlk)
 }
 
-func GenerateGaloisKeys(params Parameters, sk *rlwe.SecretKey, galEls []uint64) []*rlwe.GaloisKey {
+func GenerateGaloisKeys(params rlwe.ParameterProvider, sk *rlwe.SecretKey, galEls []uint64) []*rlwe.GaloisKey {
 	kgen := rlwe.NewKeyGenerator(params)
 	galKeys := make([]*rlwe.GaloisKey, len(galEls))
 	for i, galEl := range galEls {
@@ -84,59 +102,92 @@ func GenerateGaloisKeys(params Parameters, sk *rlwe.SecretKey, galEls []uint64)
 	return galKeys
 }
 
+// GenerateGaloisKeysForRotations genera las claves de Galois de las rotaciones de columnas ks, sin
+// repetir. Admite desplazamientos negativos y omite los que equivalen a no rotar.
+func GenerateGaloisKeysForRotations(params Parameters, sk *rlwe.SecretKey, ks []int) []*rlwe.GaloisKey {
+	return GenerateGaloisKeys(params, sk, ColumnRotationGaloisElements(params, ks...))
+}
+
+// GenerateGaloisKeysForInnerSum genera las claves de Galois para sumar n bloques consecutivos de
+// batch slots. Con batch = 1 y n = params.MaxSlots() son las claves de InnerSum.
+func GenerateGaloisKeysForInnerSum(params Parameters, sk *rlwe.SecretKey, batch, n int) []*rlwe.GaloisKey {
+	return GenerateGaloisKeys(params, sk, params.GaloisElementsForInnerSum(batch, n))
+}
+
 func GenerateMemEvaluationKeySetWithGalois(rlk *rlwe.RelinearizationKey, galKeys ...*rlwe.GaloisKey) *rlwe.MemEvaluationKeySet {
 	return rlwe.NewMemEvaluationKeySet(rlk, galKeys...)
 }
 
-func GenerateEvaluationKey(params Parameters, skA *rlwe.SecretKey, skB *rlwe.SecretKey) *rlwe.EvaluationKey {
+func GenerateEvaluationKey(params rlwe.ParameterProvider, skA *rlwe.SecretKey, skB *rlwe.SecretKey) *rlwe.EvaluationKey {
 	kgen := rlwe.NewKeyGenerator(params)
 
 	return kgen.GenEvaluationKeyNew(skA, skB)
 }
 
 func Encrypt(params Parameters, key rlwe.EncryptionKey, value []uint64) (PlaintextLabeledciphertext, error) {
+	var labeledciphertext PlaintextLabeledciphertext
+
+	elementsA, maskPlaintext, err := maskValue(params, value)
+	if err != nil {
+		return labeledciphertext, err
+	}
+	labeledciphertext.elementsA = elementsA
+
+	// Ciframos las mascaras
+	// β ← Enc(m)
+	ciphertextMask, err := rlwe.NewEncryptor(params, key).EncryptNew(maskPlaintext)
+	if err != nil {
+		return labeledciphertext, err
+	}
+
+	labeledciphertext.elementsB = make([][]rlwe.Ciphertext, 1)
+	labeledciphertext.elementsB[0] = make([]rlwe.Ciphertext, 1)
+	labeledciphertext.elementsB[0][0] = *ciphertextMask
+
+	labeledciphertext.meta = deriveMetadata("Encrypt", false)
+
+	return labeledciphertext, nil
+}
+
+// maskValue genera las mascaras b, calcula los elementos A como a ← (m − b) y devuelve b codificado
+// para cifrarlo
+func maskValue(params Parameters, value []uint64) (PlaintextElements, *rlwe.Plaintext, error) {
 	// Instanciamos el generador de numeros aleatorios
 	prng, err := sampling.NewPRNG()
 	if err != nil {
-		return PlaintextLabeledciphertext{}, err
+		return nil, nil, err
 	}
 
-	var labeledciphertext PlaintextLabeledciphertext
 	var masks []uint64
 
 	// Inicializamos elementsA como un slice vacío
-	labeledciphertext.elementsA = make(PlaintextElements, 0, params.MaxSlots())
+	elementsA := make(PlaintextElements, 0, params.MaxSlots())
 
 	for i := range params.MaxSlots() {
 		// Generamos una mascara aleatoria para cada elemento del vector
 		mask := ring.RandUniform(prng, uint64(math.Sqrt(float64(params.PlaintextModulus()))), uint64(1<<bits.Len64(uint64(math.Sqrt(float64(params.PlaintextModulus()))))-1))
 
+		// Los slots sin valor se cifran como 0
+		var m uint64
+		if i < len(value) {
+			m = value[i]
+		}
+
 		// Asignamos el valor cifrado a la lista de elementos A como a ← (m − b) ∈ M
-		diff := (value[i] - mask + params.PlaintextModulus()) % params.PlaintextModulus()
-		labeledciphertext.elementsA = append(labeledciphertext.elementsA, diff)
+		diff := (m - mask + params.PlaintextModulus()) % params.PlaintextModulus()
+		elementsA = append(elementsA, diff)
 
 		// Añadimos la mascara a la lista de mascaras
 		masks = append(masks, mask)
 	}
 
 	// Creamos el texto plano para las mascaras
-	maskPlaninText := bgv.NewPlaintext(params.Parameters, params.MaxLevel())
-	if err := bgv.NewEncoder(params.Parameters).Encode(masks, maskPlaninText); err != nil {
-		return labeledciphertext, err
+	maskPlaintext := bgv.NewPlaintext(params.Parameters, params.MaxLevel())
+	if err := bgv.NewEncoder(params.Parameters).Encode(masks, maskPlaintext); err != nil {
+		return nil, nil, err
 	}
 
-	// Ciframos las mascaras
-	// β ← Enc(m)
-	ciphertextMask, err := rlwe.NewEncryptor(params, key).EncryptNew(maskPlaninText)
-	if err != nil {
-		return labeledciphertext, err
-	}
-
-	labeledciphertext.elementsB = make([][]rlwe.Ciphertext, 1)
-	labeledciphertext.elementsB[0] = make([]rlwe.Ciphertext, 1)
-	labeledciphertext.elementsB[0][0] = *ciphertextMask
-
-	return labeledciphertext, nil
+	return elementsA, maskPlaintext, nil
 }
 
 // Decrypt para PlaintextLabeledciphertext
@@ -170,28 +221,43 @@ func DecryptOverflow(params Parameters, key *rlwe.SecretKey, labeledciphertext C
 		return nil, err
 	}
 
+	// Desciframos una sola vez cada β distinto, aunque aparezca en varios términos
+	table, err := DeduplicateBetas(labeledciphertext)
+	if err != nil {
+		return nil, err
+	}
+
+	plainBetas := make([][]uint64, len(table.Betas))
+	for k := range table.Betas {
+		plainBetas[k] = make([]uint64, params.MaxSlots())
+		if err := bgv.NewEncoder(params.Parameters).Decode(rlwe.NewDecryptor(params, key).DecryptNew(&table.Betas[k]), plainBetas[k]); err != nil {
+			return nil, err
+		}
+	}
+
+	return combineOverflow(params, plainAlpha, plainBetas, table.Terms), nil
+}
+
+// combineOverflow recompone m = Dec(α) + ∑ ∏ Dec(βj) a partir de los descifrados de α y de cada β
+// distinto, con los términos como índices en plainBetas
+func combineOverflow(params Parameters, plainAlpha []uint64, plainBetas [][]uint64, terms [][]int) []uint64 {
 	sumBetas := make([]uint64, params.MaxSlots())
-	for i := range labeledciphertext.elementsB {
+	for _, term := range terms {
 		multBetas := make([]uint64, params.MaxSlots())
 		// inicializamos el vector multBetas a 1s
 		for j := range multBetas {
 			multBetas[j] = 1
 		}
 
-		for j := range labeledciphertext.elementsB[i] {
-			// Desciframos cada βj
-			plainBeta := make([]uint64, params.MaxSlots())
-			if err := bgv.NewEncoder(params.Parameters).Decode(rlwe.NewDecryptor(params, key).DecryptNew(&labeledciphertext.elementsB[i][j]), plainBeta); err != nil {
-				return nil, err
-			}
+		for _, k := range term {
 			// Acumulamos el producto de los βj
-			for k := range params.MaxSlots() {
-				multBetas[k] = (multBetas[k] * plainBeta[k]) % params.PlaintextModulus()
+			for l := range params.MaxSlots() {
+				multBetas[l] = mulMod(multBetas[l], plainBetas[k][l], params.PlaintextModulus())
 			}
 		}
 		// Sumamos el resultado de los βj al resultado final
-		for k := range params.MaxSlots() {
-			sumBetas[k] = (sumBetas[k] + multBetas[k]) % params.PlaintextModulus()
+		for l := range params.MaxSlots() {
+			sumBetas[l] = (sumBetas[l] + multBetas[l]) % params.PlaintextModulus()
 		}
 	}
 
@@ -202,7 +268,7 @@ func DecryptOverflow(params Parameters, key *rlwe.SecretKey, labeledciphertext C
 		value[i] = (plainAlpha[i] + sumBetas[i] + params.PlaintextModulus()) % params.PlaintextModulus()
 	}
 
-	return value, nil
+	return value
 }
 
 // Sum para PlaintextLabeledciphertext
@@ -219,7 +285,8 @@ func Sum(params bgv.Parameters, labeledciphertext1, labeledciphertext2 Plaintext
 	// Inicializar elementsB
 	labeledciphertextSum.elementsB = make([][]rlwe.Ciphertext, 1)
 	labeledciphertextSum.elementsB[0] = make([]rlwe.Ciphertext, 1)
-	labeledciphertextSum.elementsB[0][0] = *rlwe.NewCiphertext(params, params.MaxLevel(), 1)
+	level := min(labeledciphertext1.elementsB[0][0].Level(), labeledciphertext2.elementsB[0][0].Level())
+	labeledciphertextSum.elementsB[0][0] = *rlwe.NewCiphertext(params, 1, level)
 
 	evaluator := bgv.NewEvaluator(params, nil)
 	err := evaluator.Add(&labeledciphertext1.elementsB[0][0], &labeledciphertext2.elementsB[0][0], &labeledciphertextSum.elementsB[0][0])
@@ -227,6 +294,8 @@ func Sum(params bgv.Parameters, labeledciphertext1, labeledciphertext2 Plaintext
 		return labeledciphertextSum, err
 	}
 
+	labeledciphertextSum.meta = deriveMetadata("Sum", false, labeledciphertext1.meta, labeledciphertext2.meta)
+
 	return labeledciphertextSum, nil
 }
 
@@ -252,10 +321,10 @@ func Mult(params Parameters, labeledciphertext1, labeledciphertext2 PlaintextLab
 	var labeledciphertextProduct PlaintextLabeledciphertext
 
 	// Multiplicamos los elementos A de ambos textos cifrados y restamos el resultado con el vector aleatorio
-	// a = a1 × a2 − r
+	// a = a1 × a2 − r, con mulMod para que el producto no desborde con t de más de 32 bits
 	labeledciphertextProduct.elementsA = make(PlaintextElements, 0, len(labeledciphertext1.elementsA))
 	for i := range len(labeledciphertext1.elementsA) {
-		product := (labeledciphertext1.elementsA[i]*labeledciphertext2.elementsA[i] - randomVector[i] + params.PlaintextModulus()) % params.PlaintextModulus()
+		product := (mulMod(labeledciphertext1.elementsA[i], labeledciphertext2.elementsA[i], params.PlaintextModulus()) + params.PlaintextModulus() - randomVector[i]) % params.PlaintextModulus()
 		labeledciphertextProduct.elementsA = append(labeledciphertextProduct.elementsA, product)
 	}
 
@@ -263,17 +332,18 @@ func Mult(params Parameters, labeledciphertext1, labeledciphertext2 PlaintextLab
 	// (β1 X β2) + a1β2 + a2β1 + Enc(d)(pk, r)
 	labeledciphertextProduct.elementsB = make([][]rlwe.Ciphertext, 1)
 	labeledciphertextProduct.elementsB[0] = make([]rlwe.Ciphertext, 1)
-	labeledciphertextProduct.elementsB[0][0] = *rlwe.NewCiphertext(params, params.MaxLevel(), 1)
+	level := min(labeledciphertext1.elementsB[0][0].Level(), labeledciphertext2.elementsB[0][0].Level())
+	labeledciphertextProduct.elementsB[0][0] = *rlwe.NewCiphertext(params, 1, level)
 
 	// Primero multiplicamos los textos cifrados
 	evaluator := bgv.NewEvaluator(params.Parameters, evk)
-	err = evaluator.MulRelin(&labeledciphertext1.elementsB[0][0], &labeledciphertext2.elementsB[0][0], &labeledciphertextProduct.elementsB[0][0])
+	err = params.mulRelin(evaluator, &labeledciphertext1.elementsB[0][0], &labeledciphertext2.elementsB[0][0], &labeledciphertextProduct.elementsB[0][0])
 	if err != nil {
 		return labeledciphertextProduct, err
 	}
 
 	// Ahora calculamos a1β2
-	labeledciphertext1elementsB := *rlwe.NewCiphertext(params, params.MaxLevel(), 1)
+	labeledciphertext1elementsB := *rlwe.NewCiphertext(params, 1, level)
 	err = evaluator.Mul(&labeledciphertext1.elementsB[0][0], []uint64(labeledciphertext2.elementsA), &labeledciphertext1elementsB)
 	if err != nil {
 		return labeledciphertextProduct, err
@@ -287,7 +357,7 @@ func Mult(params Parameters, labeledciphertext1, labeledciphertext2 PlaintextLab
 	}
 
 	// Ahora calculamos a2β1
-	labeledciphertext2elementsB := *rlwe.NewCiphertext(params, params.MaxLevel(), 1)
+	labeledciphertext2elementsB := *rlwe.NewCiphertext(params, 1, level)
 	err = evaluator.Mul(&labeledciphertext2.elementsB[0][0], []uint64(labeledciphertext1.elementsA), &labeledciphertext2elementsB)
 	if err != nil {
 		return labeledciphertextProduct, err
@@ -319,6 +389,8 @@ func Mult(params Parameters, labeledciphertext1, labeledciphertext2 PlaintextLab
 		return labeledciphertextProduct, err
 	}
 
+	labeledciphertextProduct.meta = deriveMetadata("Mult", true, labeledciphertext1.meta, labeledciphertext2.meta)
+
 	return labeledciphertextProduct, nil
 }
 
@@ -327,10 +399,15 @@ func MultOverflow(params Parameters, labeledciphertext1, labeledciphertext2 Plai
 	// MultOverflow implementa: Enc(pk, a1·a2) + a1β2 + a2β1
 	// El resultado se almacena en elementA
 
+	meta := deriveMetadata("MultOverflow", true, labeledciphertext1.meta, labeledciphertext2.meta)
+	if err := meta.checkLimits(2, 1); err != nil {
+		return CiphertextLabeledciphertext{}, err
+	}
+
 	// Calculamos el producto de los elementos A: a1 · a2 - sin conversiones de tipo!
 	productVector := make([]uint64, len(labeledciphertext1.elementsA))
 	for i := range len(labeledciphertext1.elementsA) {
-		productVector[i] = (labeledciphertext1.elementsA[i] * labeledciphertext2.elementsA[i]) % params.PlaintextModulus()
+		productVector[i] = mulMod(labeledciphertext1.elementsA[i], labeledciphertext2.elementsA[i], params.PlaintextModulus())
 	}
 
 	// Ciframos el vector producto para elementsA
@@ -346,15 +423,18 @@ func MultOverflow(params Parameters, labeledciphertext1, labeledciphertext2 Plai
 
 	evaluator := bgv.NewEvaluator(params.Parameters, evk)
 
+	// α queda al nivel de los βs, que es el de los operandos
+	level := min(labeledciphertext1.elementsB[0][0].Level(), labeledciphertext2.elementsB[0][0].Level())
+
 	// Calculamos a1β2 - sin conversiones de tipo!
-	a1beta2 := *rlwe.NewCiphertext(params, params.MaxLevel(), 1)
+	a1beta2 := *rlwe.NewCiphertext(params, 1, level)
 	err = evaluator.Mul(&labeledciphertext2.elementsB[0][0], []uint64(labeledciphertext1.elementsA), &a1beta2)
 	if err != nil {
 		return CiphertextLabeledciphertext{}, err
 	}
 
 	// Calculamos a2β1 - sin conversiones de tipo!
-	a2beta1 := *rlwe.NewCiphertext(params, params.MaxLevel(), 1)
+	a2beta1 := *rlwe.NewCiphertext(params, 1, level)
 	err = evaluator.Mul(&labeledciphertext1.elementsB[0][0], []uint64(labeledciphertext2.elementsA), &a2beta1)
 	if err != nil {
 		return CiphertextLabeledciphertext{}, err
@@ -362,15 +442,12 @@ func MultOverflow(params Parameters, labeledciphertext1, labeledciphertext2 Plai
 
 	// Calculamos α = Enc(pk, a1·a2) + a1β2 + a2β1
 
-	// Primero asegurémonos de que todos tengan el mismo level
-	minLevel := utils.Min(utils.Min(productCiphertext.Level(), a1beta2.Level()), a2beta1.Level())
-
-	// Crear alpha con el nivel mínimo y degree 1
-	alpha := *rlwe.NewCiphertext(params, minLevel, 1)
+	// Crear alpha con degree 1 al nivel de los operandos
+	alpha := *rlwe.NewCiphertext(params, 1, level)
 
 	// Ajustar levels
-	if productCiphertext.Level() > minLevel {
-		productCiphertext.Resize(productCiphertext.Degree(), minLevel)
+	if productCiphertext.Level() > level {
+		productCiphertext.Resize(productCiphertext.Degree(), level)
 	}
 
 	err = evaluator.Add(productCiphertext, &a1beta2, &alpha)
@@ -394,6 +471,142 @@ func MultOverflow(params Parameters, labeledciphertext1, labeledciphertext2 Plai
 	labeledciphertextProduct.elementsB[0][0] = labeledciphertext1.elementsB[0][0] // β1
 	labeledciphertextProduct.elementsB[0][1] = labeledciphertext2.elementsB[0][0] // β2
 
+	labeledciphertextProduct.meta = meta
+
+	return labeledciphertextProduct, nil
+}
+
+// MultOverflowMixed multiplica un CiphertextLabeledciphertext por un PlaintextLabeledciphertext.
+// Con m1 = Dec(α1) + Σᵢ Πⱼ Dec(β1ᵢⱼ) y m2 = a2 + Dec(β2):
+//
+//	m1·m2 = Dec(a2·α1) + Dec(α1)·Dec(β2) + Σᵢ (Dec(a2·β1ᵢ₀)·Πⱼ≥₁ Dec(β1ᵢⱼ) + Πⱼ Dec(β1ᵢⱼ)·Dec(β2))
+//
+// Solo se multiplica por vectores públicos, así que no consume profundidad ni requiere claves.
+// Cada término de β se desdobla en dos y se añade el término [α1, β2]: el resultado tiene
+// 2·T + 1 términos y su grado crece en uno.
+func MultOverflowMixed(params Parameters, labeledciphertext1 CiphertextLabeledciphertext, labeledciphertext2 PlaintextLabeledciphertext) (CiphertextLabeledciphertext, error) {
+	var labeledciphertextProduct CiphertextLabeledciphertext
+
+	meta := deriveMetadata("MultOverflowMixed", true, labeledciphertext1.meta, labeledciphertext2.meta)
+	if err := meta.checkLimits(max(labeledciphertext1.Degree()+1, 2), 2*len(labeledciphertext1.elementsB)+1); err != nil {
+		return labeledciphertextProduct, err
+	}
+
+	evaluator := bgv.NewEvaluator(params.Parameters, nil)
+	a2 := []uint64(labeledciphertext2.elementsA)
+	alpha1 := (*rlwe.Ciphertext)(labeledciphertext1.elementsA)
+	beta2 := labeledciphertext2.elementsB[0][0]
+
+	// α ← a2·α1
+	alpha, err := scaleCiphertext(evaluator, alpha1, a2)
+	if err != nil {
+		return labeledciphertextProduct, err
+	}
+	labeledciphertextProduct.elementsA = (*CiphertextElement)(alpha)
+
+	labeledciphertextProduct.elementsB = make([][]rlwe.Ciphertext, 0, 2*len(labeledciphertext1.elementsB)+1)
+	for _, term := range labeledciphertext1.elementsB {
+		// [a2·β1ᵢ₀, β1ᵢ₁, ...]
+		scaled := make([]rlwe.Ciphertext, len(term))
+		copy(scaled, term)
+		first, err := scaleCiphertext(evaluator, &term[0], a2)
+		if err != nil {
+			return labeledciphertextProduct, err
+		}
+		scaled[0] = *first
+
+		// [β1ᵢ₀, β1ᵢ₁, ..., β2]
+		extended := make([]rlwe.Ciphertext, len(term), len(term)+1)
+		copy(extended, term)
+		extended = append(extended, beta2)
+
+		labeledciphertextProduct.elementsB = append(labeledciphertextProduct.elementsB, scaled, extended)
+	}
+
+	// [α1, β2]
+	labeledciphertextProduct.elementsB = append(labeledciphertextProduct.elementsB, []rlwe.Ciphertext{*alpha1, beta2})
+
+	labeledciphertextProduct.meta = meta
+
+	return labeledciphertextProduct, nil
+}
+
+// MultOverflowCiphertext multiplica dos CiphertextLabeledciphertext. Con m1 = Dec(α1) + Σᵢ P1ᵢ y
+// m2 = Dec(α2) + Σₖ P2ₖ, siendo P los productos de los βs de cada término:
+//
+//	m1·m2 = Dec(α1·α2) + Σₖ Dec(α1)·P2ₖ + Σᵢ P1ᵢ·Dec(α2) + Σᵢₖ P1ᵢ·P2ₖ
+//
+// α1·α2 se calcula al menor nivel de los dos α con relinealización; en BGV se reescala después, así
+// que α baja un nivel. Si la profundidad acumulada supera la que admite la cadena de módulos, o α
+// ya está en el nivel 0, devuelve ErrInsufficientDepth en lugar de un resultado indescifrable. Los productos
+// cruzados α×β y β×β se guardan como términos nuevos, concatenando los factores, así que el
+// resultado tiene T1 + T2 + T1·T2 términos.
+func MultOverflowCiphertext(params Parameters, labeledciphertext1, labeledciphertext2 CiphertextLabeledciphertext, evk *rlwe.MemEvaluationKeySet) (CiphertextLabeledciphertext, error) {
+	var labeledciphertextProduct CiphertextLabeledciphertext
+
+	terms1, terms2 := labeledciphertext1.elementsB, labeledciphertext2.elementsB
+	degree1, degree2 := labeledciphertext1.Degree(), labeledciphertext2.Degree()
+
+	meta := deriveMetadata("MultOverflowCiphertext", true, labeledciphertext1.meta, labeledciphertext2.meta)
+	if err := meta.checkLimits(max(degree1+degree2, degree1+1, degree2+1), len(terms1)+len(terms2)+len(terms1)*len(terms2)); err != nil {
+		return labeledciphertextProduct, err
+	}
+
+	alpha1 := (*rlwe.Ciphertext)(labeledciphertext1.elementsA)
+	alpha2 := (*rlwe.Ciphertext)(labeledciphertext2.elementsA)
+
+	if meta.multiplications > params.MaxLevel() {
+		return labeledciphertextProduct, fmt.Errorf("%w: MultOverflowCiphertext requiere profundidad %d y la cadena de módulos solo admite %d", ErrInsufficientDepth, meta.multiplications, params.MaxLevel())
+	}
+
+	// α ← α1·α2. Sin reescalar, el ruido del producto agotaría el margen de α en BGV
+	level := min(alpha1.Level(), alpha2.Level())
+	if params.Scheme() == SchemeBGV && level == 0 {
+		return labeledciphertextProduct, fmt.Errorf("%w: MultOverflowCiphertext necesita reescalar α y está en el nivel 0", ErrInsufficientDepth)
+	}
+
+	evaluator := bgv.NewEvaluator(params.Parameters, evk)
+	product := rlwe.NewCiphertext(params, 1, level)
+	if err := params.mulRelin(evaluator, alpha1, alpha2, product); err != nil {
+		return labeledciphertextProduct, err
+	}
+
+	alpha := product
+	if params.Scheme() == SchemeBGV {
+		alpha = rlwe.NewCiphertext(params, 1, level-1)
+		if err := evaluator.Rescale(product, alpha); err != nil {
+			return labeledciphertextProduct, err
+		}
+	}
+	labeledciphertextProduct.elementsA = (*CiphertextElement)(alpha)
+
+	// concat devuelve un término nuevo con los factores de a seguidos de los de b
+	concat := func(a, b []rlwe.Ciphertext) []rlwe.Ciphertext {
+		term := make([]rlwe.Ciphertext, 0, len(a)+len(b))
+		return append(append(term, a...), b...)
+	}
+
+	labeledciphertextProduct.elementsB = make([][]rlwe.Ciphertext, 0, len(terms1)+len(terms2)+len(terms1)*len(terms2))
+
+	// α1·P2ₖ
+	for _, term := range terms2 {
+		labeledciphertextProduct.elementsB = append(labeledciphertextProduct.elementsB, concat([]rlwe.Ciphertext{*alpha1}, term))
+	}
+
+	// P1ᵢ·α2
+	for _, term := range terms1 {
+		labeledciphertextProduct.elementsB = append(labeledciphertextProduct.elementsB, concat(term, []rlwe.Ciphertext{*alpha2}))
+	}
+
+	// P1ᵢ·P2ₖ
+	for _, term1 := range terms1 {
+		for _, term2 := range terms2 {
+			labeledciphertextProduct.elementsB = append(labeledciphertextProduct.elementsB, concat(term1, term2))
+		}
+	}
+
+	labeledciphertextProduct.meta = meta
+
 	return labeledciphertextProduct, nil
 }
 
@@ -401,13 +614,18 @@ func MultOverflow(params Parameters, labeledciphertext1, labeledciphertext2 Plai
 func SumOverflow(params Parameters, labeledciphertext1 CiphertextLabeledciphertext, labeledciphertext2 PlaintextLabeledciphertext) (CiphertextLabeledciphertext, error) {
 	var labeledciphertextSum CiphertextLabeledciphertext
 
+	meta := deriveMetadata("SumOverflow", false, labeledciphertext1.meta, labeledciphertext2.meta)
+	if err := meta.checkLimits(max(labeledciphertext1.Degree(), labeledciphertext2.Degree()), len(labeledciphertext1.elementsB)+len(labeledciphertext2.elementsB)); err != nil {
+		return labeledciphertextSum, err
+	}
+
 	evaluator := bgv.NewEvaluator(params.Parameters, nil)
 
 	// Convert CiphertextElement to *rlwe.Ciphertext
 	ct1 := (*rlwe.Ciphertext)(labeledciphertext1.elementsA)
 
 	// Create output ciphertext
-	result := rlwe.NewCiphertext(params, params.MaxLevel(), 1)
+	result := rlwe.NewCiphertext(params, 1, ct1.Level())
 
 	// Realizamos suma con plaintext - sin conversiones de tipo!
 	err := evaluator.Add(ct1, []uint64(labeledciphertext2.elementsA), result)
@@ -423,6 +641,28 @@ func SumOverflow(params Parameters, labeledciphertext1 CiphertextLabeledcipherte
 	labeledciphertextSum.elementsB = append(labeledciphertextSum.elementsB, labeledciphertext1.elementsB...)
 	labeledciphertextSum.elementsB = append(labeledciphertextSum.elementsB, labeledciphertext2.elementsB...)
 
+	labeledciphertextSum.meta = meta
+
+	return labeledciphertextSum, nil
+}
+
+// SumPlaintextOverflow es SumOverflow con los argumentos en el orden inverso (Plaintext + Ciphertext).
+// Los términos de β conservan el orden de los argumentos.
+func SumPlaintextOverflow(params Parameters, labeledciphertext1 PlaintextLabeledciphertext, labeledciphertext2 CiphertextLabeledciphertext) (CiphertextLabeledciphertext, error) {
+	labeledciphertextSum, err := SumOverflow(params, labeledciphertext2, labeledciphertext1)
+	if err != nil {
+		return labeledciphertextSum, err
+	}
+
+	// β ← [β1, β2]
+	split := len(labeledciphertext2.elementsB)
+	elementsB := make([][]rlwe.Ciphertext, 0, len(labeledciphertextSum.elementsB))
+	elementsB = append(elementsB, labeledciphertextSum.elementsB[split:]...)
+	elementsB = append(elementsB, labeledciphertextSum.elementsB[:split]...)
+	labeledciphertextSum.elementsB = elementsB
+
+	labeledciphertextSum.meta = deriveMetadata("SumPlaintextOverflow", false, labeledciphertext1.meta, labeledciphertext2.meta)
+
 	return labeledciphertextSum, nil
 }
 
@@ -430,6 +670,11 @@ func SumOverflow(params Parameters, labeledciphertext1 CiphertextLabeledcipherte
 func SumOverflowCiphertext(params Parameters, labeledciphertext1, labeledciphertext2 CiphertextLabeledciphertext) (CiphertextLabeledciphertext, error) {
 	var labeledciphertextSum CiphertextLabeledciphertext
 
+	meta := deriveMetadata("SumOverflowCiphertext", false, labeledciphertext1.meta, labeledciphertext2.meta)
+	if err := meta.checkLimits(max(labeledciphertext1.Degree(), labeledciphertext2.Degree()), len(labeledciphertext1.elementsB)+len(labeledciphertext2.elementsB)); err != nil {
+		return labeledciphertextSum, err
+	}
+
 	evaluator := bgv.NewEvaluator(params.Parameters, nil)
 
 	// Convert CiphertextElements to *rlwe.Ciphertext
@@ -437,7 +682,7 @@ func SumOverflowCiphertext(params Parameters, labeledciphertext1, labeledciphert
 	ct2 := (*rlwe.Ciphertext)(labeledciphertext2.elementsA)
 
 	// Create output ciphertext
-	result := rlwe.NewCiphertext(params, params.MaxLevel(), 1)
+	result := rlwe.NewCiphertext(params, 1, min(ct1.Level(), ct2.Level()))
 
 	// Perform addition
 	err := evaluator.Add(ct1, ct2, result)
@@ -453,131 +698,212 @@ func SumOverflowCiphertext(params Parameters, labeledciphertext1, labeledciphert
 	labeledciphertextSum.elementsB = append(labeledciphertextSum.elementsB, labeledciphertext1.elementsB...)
 	labeledciphertextSum.elementsB = append(labeledciphertextSum.elementsB, labeledciphertext2.elementsB...)
 
+	labeledciphertextSum.meta = meta
+
 	return labeledciphertextSum, nil
 }
 
-func RotateColumns(params Parameters, labeledciphertext PlaintextLabeledciphertext, k int, evk *rlwe.MemEvaluationKeySet) (PlaintextLabeledciphertext, error) {
-	var rotatedCiphertext PlaintextLabeledciphertext
+// normalizeRotation lleva un desplazamiento de columnas cualquiera, negativo incluido, a [0, N/2).
+// Un desplazamiento negativo −k rota k posiciones a la derecha.
+func normalizeRotation(params Parameters, k int) int {
+	halfSlots := params.MaxSlots() / 2
+	return (k%halfSlots + halfSlots) % halfSlots
+}
 
+// ColumnRotationGaloisElements devuelve, sin repetir, los elementos de Galois de las rotaciones de
+// columnas indicadas. Admite desplazamientos negativos y los normaliza como RotateColumns.
+func ColumnRotationGaloisElements(params Parameters, ks ...int) []uint64 {
+	var galEls []uint64
+	seen := make(map[int]bool)
+	for _, k := range ks {
+		if k = normalizeRotation(params, k); k != 0 && !seen[k] {
+			seen[k] = true
+			galEls = append(galEls, params.GaloisElementForColRotation(k))
+		}
+	}
+	return galEls
+}
+
+// rotateColumnsElementsA rota k posiciones los elementos A, con k ya normalizado
+func rotateColumnsElementsA(params Parameters, elementsA PlaintextElements, k int) PlaintextElements {
 	// RotateColumns en BGV funciona con dos mitades independientes
 	// Cada mitad rota circularmente dentro de sí misma
 	slots := params.MaxSlots()
 	halfSlots := slots / 2
-	rotatedCiphertext.elementsA = make(PlaintextElements, slots)
+	rotated := make(PlaintextElements, slots)
 
 	// Rotar la primera mitad (0 a halfSlots-1)
 	for i := 0; i < halfSlots; i++ {
 		sourceIndex := (i + k) % halfSlots
-		rotatedCiphertext.elementsA[i] = labeledciphertext.elementsA[sourceIndex]
+		rotated[i] = elementsA[sourceIndex]
 	}
 
 	// Rotar la segunda mitad (halfSlots a slots-1)
 	for i := halfSlots; i < slots; i++ {
 		sourceIndex := halfSlots + ((i - halfSlots + k) % halfSlots)
-		rotatedCiphertext.elementsA[i] = labeledciphertext.elementsA[sourceIndex]
+		rotated[i] = elementsA[sourceIndex]
 	}
 
-	// Copiamos la estructura de elementsB haciendo una copia profunda
-	rotatedCiphertext.elementsB = make([][]rlwe.Ciphertext, len(labeledciphertext.elementsB))
-	for i := range labeledciphertext.elementsB {
-		rotatedCiphertext.elementsB[i] = make([]rlwe.Ciphertext, len(labeledciphertext.elementsB[i]))
-		copy(rotatedCiphertext.elementsB[i], labeledciphertext.elementsB[i])
+	return rotated
+}
+
+// automorphismCiphertext aplica un automorfismo a un cifrado de cualquier grado. Los cifrados de
+// grado mayor que 1 se relinealizan antes, ya que el cambio de clave del automorfismo solo admite
+// grado 1; para ellos evk debe incluir la clave de relinealización.
+func automorphismCiphertext(params Parameters, evaluator *bgv.Evaluator, ct *rlwe.Ciphertext, automorphism func(ctIn, ctOut *rlwe.Ciphertext) error) (*rlwe.Ciphertext, error) {
+	ctIn := ct
+	if ct.Degree() > 1 {
+		ctIn = rlwe.NewCiphertext(params, 1, ct.Level())
+		if err := evaluator.Relinearize(ct, ctIn); err != nil {
+			return nil, err
+		}
 	}
 
-	// Rotamos el elemento B sobre sí mismo
+	ctOut := rlwe.NewCiphertext(params, 1, ctIn.Level())
+	if err := automorphism(ctIn, ctOut); err != nil {
+		return nil, err
+	}
+	return ctOut, nil
+}
+
+// rotateColumnsCiphertexts rota k posiciones, con k ya normalizado, todas las componentes cifradas:
+// cada β de cada término y α si está cifrado. Rotar 0 posiciones no requiere clave.
+func rotateColumnsCiphertexts[T any](params Parameters, labeledciphertext Labeledciphertext[T], k int, evk *rlwe.MemEvaluationKeySet) (Labeledciphertext[T], error) {
+	evaluator := bgv.NewEvaluator(params.Parameters, evk)
+
+	return mapCiphertexts(labeledciphertext, func(ct *rlwe.Ciphertext) (*rlwe.Ciphertext, error) {
+		if k == 0 {
+			return ct.CopyNew(), nil
+		}
+		return automorphismCiphertext(params, evaluator, ct, func(ctIn, ctOut *rlwe.Ciphertext) error {
+			return evaluator.RotateColumns(ctIn, k, ctOut)
+		})
+	})
+}
+
+// rotateRowsCiphertexts intercambia las dos filas de slots de todas las componentes cifradas
+func rotateRowsCiphertexts[T any](params Parameters, labeledciphertext Labeledciphertext[T], evk *rlwe.MemEvaluationKeySet) (Labeledciphertext[T], error) {
 	evaluator := bgv.NewEvaluator(params.Parameters, evk)
-	err := evaluator.RotateColumns(&rotatedCiphertext.elementsB[0][0], k, &rotatedCiphertext.elementsB[0][0])
+
+	return mapCiphertexts(labeledciphertext, func(ct *rlwe.Ciphertext) (*rlwe.Ciphertext, error) {
+		return automorphismCiphertext(params, evaluator, ct, evaluator.RotateRows)
+	})
+}
+
+// RotateColumns rota k posiciones a la izquierda cada fila de slots; k negativo rota a la derecha.
+// Se rota cada uno de los elementos B, sea cual sea su número y grado.
+func RotateColumns(params Parameters, labeledciphertext PlaintextLabeledciphertext, k int, evk *rlwe.MemEvaluationKeySet) (PlaintextLabeledciphertext, error) {
+	k = normalizeRotation(params, k)
+
+	rotatedCiphertext, err := rotateColumnsCiphertexts(params, labeledciphertext, k, evk)
 	if err != nil {
 		return rotatedCiphertext, err
 	}
 
+	rotatedCiphertext.elementsA = rotateColumnsElementsA(params, labeledciphertext.elementsA, k)
+	rotatedCiphertext.meta = deriveMetadata("RotateColumns", false, labeledciphertext.meta)
+
 	return rotatedCiphertext, nil
 }
 
+// RotateColumnsOverflow rota k posiciones α y cada uno de los elementos B; k negativo rota a la derecha
 func RotateColumnsOverflow(params Parameters, labeledciphertext CiphertextLabeledciphertext, k int, evk *rlwe.MemEvaluationKeySet) (CiphertextLabeledciphertext, error) {
-	var rotatedCiphertext CiphertextLabeledciphertext
-
-	evaluator := bgv.NewEvaluator(params.Parameters, evk)
-
-	// Normalizar y rotar elementoA
-	ctA, err := evaluator.AddNew((*rlwe.Ciphertext)(labeledciphertext.elementsA), []uint64{0})
+	rotatedCiphertext, err := rotateColumnsCiphertexts(params, labeledciphertext, normalizeRotation(params, k), evk)
 	if err != nil {
 		return rotatedCiphertext, err
 	}
 
-	rotatedA := rlwe.NewCiphertext(params.Parameters, ctA.Level(), 1)
-	err = evaluator.RotateColumns(ctA, k, rotatedA)
+	rotatedCiphertext.meta = deriveMetadata("RotateColumnsOverflow", false, labeledciphertext.meta)
+
+	return rotatedCiphertext, nil
+}
+
+// RotateRows intercambia las dos filas de slots de un PlaintextLabeledciphertext
+func RotateRows(params Parameters, labeledciphertext PlaintextLabeledciphertext, evk *rlwe.MemEvaluationKeySet) (PlaintextLabeledciphertext, error) {
+	rotatedCiphertext, err := rotateRowsCiphertexts(params, labeledciphertext, evk)
 	if err != nil {
 		return rotatedCiphertext, err
 	}
 
-	rotatedCiphertext.elementsA = (*CiphertextElement)(rotatedA)
+	// Intercambiamos las dos mitades de los elementos A
+	slots := len(labeledciphertext.elementsA)
+	halfSlots := slots / 2
+	rotatedCiphertext.elementsA = make(PlaintextElements, slots)
+	for i := range slots {
+		rotatedCiphertext.elementsA[i] = labeledciphertext.elementsA[(i+halfSlots)%slots]
+	}
 
-	// Rotar cada uno de los elementos B
-	rotatedCiphertext.elementsB = make([][]rlwe.Ciphertext, len(labeledciphertext.elementsB))
-	for i := range labeledciphertext.elementsB {
-		rotatedCiphertext.elementsB[i] = make([]rlwe.Ciphertext, len(labeledciphertext.elementsB[i]))
-		for j := range labeledciphertext.elementsB[i] {
-			sourceCt := &labeledciphertext.elementsB[i][j]
+	rotatedCiphertext.meta = deriveMetadata("RotateRows", false, labeledciphertext.meta)
 
-			// Normalizar el ciphertext - crea una copia del ciphertext asegurando degree 1
-			normalizedCt := rlwe.NewCiphertext(params.Parameters, sourceCt.Level(), 1)
-			err := evaluator.Add(sourceCt, []uint64{0}, normalizedCt)
-			if err != nil {
-				return rotatedCiphertext, err
-			}
+	return rotatedCiphertext, nil
+}
 
-			// Crear nuevo ciphertext para el resultado y rotar
-			rotatedCiphertext.elementsB[i][j] = *rlwe.NewCiphertext(params.Parameters, normalizedCt.Level(), 1)
-			err = evaluator.RotateColumns(normalizedCt, k, &rotatedCiphertext.elementsB[i][j])
-			if err != nil {
-				return rotatedCiphertext, err
-			}
-		}
+// RotateRowsOverflow intercambia las dos filas de slots de α y de cada uno de los elementos B
+func RotateRowsOverflow(params Parameters, labeledciphertext CiphertextLabeledciphertext, evk *rlwe.MemEvaluationKeySet) (CiphertextLabeledciphertext, error) {
+	rotatedCiphertext, err := rotateRowsCiphertexts(params, labeledciphertext, evk)
+	if err != nil {
+		return rotatedCiphertext, err
 	}
 
+	rotatedCiphertext.meta = deriveMetadata("RotateRowsOverflow", false, labeledciphertext.meta)
+
 	return rotatedCiphertext, nil
 }
 
 func ApplyEvaluationKey(params Parameters, evalKey rlwe.EvaluationKey, labeledciphertext PlaintextLabeledciphertext) (*PlaintextLabeledciphertext, error) {
+	return applyEvaluationKey(bgv.NewEvaluator(params.Parameters, nil), &evalKey, labeledciphertext)
+}
 
-	evaluator := bgv.NewEvaluator(params.Parameters, nil)
+// applyEvaluationKey aplica la clave de evaluación con un evaluador ya creado
+func applyEvaluationKey(evaluator *bgv.Evaluator, evalKey *rlwe.EvaluationKey, labeledciphertext PlaintextLabeledciphertext) (*PlaintextLabeledciphertext, error) {
 
-	ctOut, err := evaluator.ApplyEvaluationKeyNew(&labeledciphertext.elementsB[0][0], &evalKey)
+	ctOut, err := evaluator.ApplyEvaluationKeyNew(&labeledciphertext.elementsB[0][0], evalKey)
 	if err != nil {
 		return nil, err
 	}
 
-	labeledciphertext.elementsB[0][0] = *ctOut
+	// Sustituimos los elementos B por un slice nuevo para no modificar los de la entrada
+	labeledciphertext.elementsB = [][]rlwe.Ciphertext{{*ctOut}}
+
+	labeledciphertext.meta = deriveMetadata("ApplyEvaluationKey", false, labeledciphertext.meta)
 
 	return &labeledciphertext, nil
 }
 
 func ApplyEvaluationKeyOverflow(params Parameters, evalKey rlwe.EvaluationKey, labeledciphertext CiphertextLabeledciphertext) (*CiphertextLabeledciphertext, error) {
+	return applyEvaluationKeyOverflow(bgv.NewEvaluator(params.Parameters, nil), &evalKey, labeledciphertext)
+}
 
-	// Aplicar la clave de evaluación sobre elementsA
-	evaluator := bgv.NewEvaluator(params.Parameters, nil)
+// applyEvaluationKeyOverflow aplica la clave de evaluación con un evaluador ya creado
+func applyEvaluationKeyOverflow(evaluator *bgv.Evaluator, evalKey *rlwe.EvaluationKey, labeledciphertext CiphertextLabeledciphertext) (*CiphertextLabeledciphertext, error) {
 
+	// Aplicar la clave de evaluación sobre elementsA
 	ctIn := (*rlwe.Ciphertext)(labeledciphertext.elementsA)
 
-	ctOut, err := evaluator.ApplyEvaluationKeyNew(ctIn, &evalKey)
+	ctOut, err := evaluator.ApplyEvaluationKeyNew(ctIn, evalKey)
 	if err != nil {
 		return nil, err
 	}
 
 	labeledciphertext.elementsA = (*CiphertextElement)(ctOut)
 
-	// Aplicar la clave de evaluación sobre cada elemento de elementsB
+	// Aplicar la clave de evaluación sobre cada elemento de elementsB, en slices nuevos para no
+	// modificar los de la entrada
+	elementsB := make([][]rlwe.Ciphertext, len(labeledciphertext.elementsB))
 	for i := range labeledciphertext.elementsB {
+		elementsB[i] = make([]rlwe.Ciphertext, len(labeledciphertext.elementsB[i]))
 		for j := range labeledciphertext.elementsB[i] {
 			ctInB := &labeledciphertext.elementsB[i][j]
-			ctOutB, err := evaluator.ApplyEvaluationKeyNew(ctInB, &evalKey)
+			ctOutB, err := evaluator.ApplyEvaluationKeyNew(ctInB, evalKey)
 			if err != nil {
 				return nil, err
 			}
-			labeledciphertext.elementsB[i][j] = *ctOutB
+			elementsB[i][j] = *ctOutB
 		}
 	}
+	labeledciphertext.elementsB = elementsB
+
+	labeledciphertext.meta = deriveMetadata("ApplyEvaluationKeyOverflow", false, labeledciphertext.meta)
 
 	return &labeledciphertext, nil
 }
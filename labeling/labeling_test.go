@@ -0,0 +1,115 @@
+// Copyright 2025 Juan Martín Pérez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Pruebas de las operaciones básicas con módulos de texto plano de más de 32 bits.
+
+package labeling
+
+import (
+	"math/big"
+	"testing"
+)
+
+// largeModulusParameters devuelve parámetros inseguros con un módulo de texto plano de 40 bits, para
+// el que a1·a2 desborda un uint64
+func largeModulusParameters(t *testing.T) Parameters {
+	t.Helper()
+
+	moduli, err := GeneratePlaintextModuli(1, 40, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	params, err := NewParametersFromLiteral(10, []int{56, 55, 55, 54}, []int{55}, moduli[0], AllowInsecure())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return params
+}
+
+// largeValues devuelve dos vectores cercanos a t cuyo producto no cabe en 64 bits
+func largeValues(params Parameters) ([]uint64, []uint64, []uint64) {
+	t64 := params.PlaintextModulus()
+	x := make([]uint64, params.MaxSlots())
+	y := make([]uint64, params.MaxSlots())
+	want := make([]uint64, params.MaxSlots())
+	for i := range x {
+		x[i] = t64 - 1 - uint64(i)
+		y[i] = t64 - 7 - 3*uint64(i)
+		product := new(big.Int).Mul(new(big.Int).SetUint64(x[i]), new(big.Int).SetUint64(y[i]))
+		want[i] = product.Mod(product, new(big.Int).SetUint64(t64)).Uint64()
+	}
+	return x, y, want
+}
+
+func checkValues(t *testing.T, got, want []uint64) {
+	t.Helper()
+
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("slot %d: se esperaba %d y se obtuvo %d", i, want[i], got[i])
+		}
+	}
+}
+
+func TestMultLargePlaintextModulus(t *testing.T) {
+	params := largeModulusParameters(t)
+	sk, pk := GenerateKeyPair(params)
+	evk := GenerateMemEvaluationKeySet(GenerateRelinearizationKey(params, sk))
+
+	x, y, want := largeValues(params)
+	cx, err := Encrypt(params, pk, x)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cy, err := Encrypt(params, pk, y)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	product, err := Mult(params, cx, cy, pk, evk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := Decrypt(params, sk, product)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkValues(t, got, want)
+}
+
+func TestMultOverflowLargePlaintextModulus(t *testing.T) {
+	params := largeModulusParameters(t)
+	sk, pk := GenerateKeyPair(params)
+	evk := GenerateMemEvaluationKeySet(GenerateRelinearizationKey(params, sk))
+
+	x, y, want := largeValues(params)
+	cx, err := Encrypt(params, pk, x)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cy, err := Encrypt(params, pk, y)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	product, err := MultOverflow(params, cx, cy, pk, evk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := DecryptOverflow(params, sk, product)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkValues(t, got, want)
+}
@@ -0,0 +1,387 @@
+// Copyright 2025 Juan Martín Pérez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package labelinghttp expone el servicio de evaluación de labeling sobre HTTP con cuerpos JSON,
+// para entornos en los que gRPC no es una opción. Las peticiones y respuestas son las mismas que
+// las de labelingpb (EvalRequest y EvalResponse), con los labeled ciphertexts y las claves en la
+// representación JSON de labeling, es decir, con cada cifrado en base64.
+//
+// Rutas:
+//
+//	GET  /v1/parameters  hash de los parámetros del servidor, slots, módulo y límite de tamaño
+//	POST /v1/eval        evalúa una expresión sobre labeled ciphertexts con nombre
+//
+// El tamaño del cuerpo se limita a partir del tamaño esperado de un cifrado al nivel máximo y de
+// una clave de evaluación con los parámetros del servidor, para un número máximo de entradas y de
+// claves. Las peticiones mayores se rechazan con 413 sin llegar a decodificarse, igual que las que
+// traen más entradas o más claves de evaluación que el máximo. Las entradas y claves mal
+// formadas para los parámetros del servidor se rechazan con 400, y las expresiones demasiado largas
+// o anidadas, o cuyo coste estimado (labeling.EstimateCost) supera los límites del servicio o los
+// niveles de los parámetros, con 422 antes de operar con ningún cifrado.
+package labelinghttp
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+
+	"github.com/tuneinsight/lattigo/v6/core/rlwe"
+
+	"main.go/labeling"
+)
+
+const (
+	// defaultMaxInputs es el número máximo de entradas por petición si no se configura otro
+	defaultMaxInputs = 16
+	// defaultMaxKeys es el número máximo de claves de evaluación por petición si no se configura otro
+	defaultMaxKeys = 32
+	// defaultMaxMultiplications es el número máximo de multiplicaciones por expresión si no se
+	// configura otro
+	defaultMaxMultiplications = 64
+	// defaultMaxTerms es el número máximo de términos de βs del resultado si no se configura otro
+	defaultMaxTerms = 1024
+	// jsonOverhead cubre los nombres de campo, los metadatos y la expresión
+	jsonOverhead = 64 << 10
+)
+
+// EvalRequest es el cuerpo de POST /v1/eval
+type EvalRequest struct {
+	// ParametersHash es labeling.Parameters.Hash() de los parámetros del cliente; si está presente
+	// debe coincidir con los del servidor
+	ParametersHash []byte `json:"parametersHash,omitempty"`
+	// Expression es la expresión en la sintaxis de labeling.ParseExpr
+	Expression string `json:"expression"`
+	// Inputs son las entradas de la expresión por nombre
+	Inputs map[string]labeling.PlaintextLabeledciphertext `json:"inputs"`
+	// Keys son las claves públicas del cliente
+	Keys *labeling.KeyBundle `json:"keys,omitempty"`
+}
+
+// EvalResponse es el cuerpo de la respuesta de POST /v1/eval: el resultado o el error que lo impidió
+type EvalResponse struct {
+	// Result es el labeled ciphertext resultado, en forma plaintext u overflow
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  string          `json:"error,omitempty"`
+}
+
+// Operand decodifica el resultado en su forma: PlaintextLabeledciphertext o
+// CiphertextLabeledciphertext
+func (r EvalResponse) Operand() (labeling.Operand, error) {
+	if r.Error != "" {
+		return nil, errors.New(r.Error)
+	}
+
+	var form struct {
+		Form string `json:"form"`
+	}
+	if err := json.Unmarshal(r.Result, &form); err != nil {
+		return nil, err
+	}
+
+	switch form.Form {
+	case "plaintext":
+		var lc labeling.PlaintextLabeledciphertext
+		err := json.Unmarshal(r.Result, &lc)
+		return lc, err
+	case "overflow":
+		var lc labeling.CiphertextLabeledciphertext
+		err := json.Unmarshal(r.Result, &lc)
+		return lc, err
+	}
+	return nil, fmt.Errorf("labelinghttp: forma de resultado desconocida %q", form.Form)
+}
+
+// ParametersResponse es el cuerpo de la respuesta de GET /v1/parameters
+type ParametersResponse struct {
+	ParametersHash   []byte `json:"parametersHash"`
+	PlaintextModulus uint64 `json:"plaintextModulus"`
+	MaxSlots         int    `json:"maxSlots"`
+	MaxInputs        int    `json:"maxInputs"`
+	MaxBodySize      int64  `json:"maxBodySize"`
+}
+
+// Option modifica la configuración del servicio
+type Option func(*options)
+
+type options struct {
+	maxInputs   int
+	maxKeys     int
+	maxBodySize int64
+	maxMults    int
+	maxTerms    int
+	maxLength   int
+	maxDepth    int
+	multiply    []labeling.MultiplyOption
+}
+
+// WithMaxInputs limita el número de entradas de cada petición
+func WithMaxInputs(n int) Option {
+	return func(o *options) {
+		o.maxInputs = n
+	}
+}
+
+// WithMaxKeys limita el número de claves de evaluación (relinealización, Galois y cambio de clave)
+// de cada petición. El límite de tamaño del cuerpo se calcula también a partir de él.
+func WithMaxKeys(n int) Option {
+	return func(o *options) {
+		o.maxKeys = n
+	}
+}
+
+// WithMaxBodySize fija el tamaño máximo del cuerpo en bytes, en lugar del calculado a partir de los
+// parámetros
+func WithMaxBodySize(n int64) Option {
+	return func(o *options) {
+		o.maxBodySize = n
+	}
+}
+
+// WithMaxCost limita el coste estimado de cada expresión: el número de multiplicaciones entre
+// cifrados y el número de términos de βs del resultado. Un límite 0 desactiva esa comprobación.
+func WithMaxCost(multiplications, terms int) Option {
+	return func(o *options) {
+		o.maxMults = multiplications
+		o.maxTerms = terms
+	}
+}
+
+// WithMaxExpression limita la longitud en bytes de la expresión y su anidamiento de paréntesis.
+// Los límites se comprueban antes de analizarla, y no pueden superar labeling.MaxExprLength ni
+// labeling.MaxExprDepth, que ParseExpr aplica siempre.
+func WithMaxExpression(length, depth int) Option {
+	return func(o *options) {
+		o.maxLength = length
+		o.maxDepth = depth
+	}
+}
+
+// WithMultiplyOptions pasa opciones de multiplicación a cada evaluación
+func WithMultiplyOptions(opts ...labeling.MultiplyOption) Option {
+	return func(o *options) {
+		o.multiply = append(o.multiply, opts...)
+	}
+}
+
+// Handler atiende las rutas del servicio para unos parámetros. Es seguro para uso concurrente.
+type Handler struct {
+	params labeling.Parameters
+	hash   [32]byte
+	opts   options
+	mux    *http.ServeMux
+}
+
+// NewHandler crea el servicio HTTP para params
+func NewHandler(params labeling.Parameters, opts ...Option) (*Handler, error) {
+	hash, err := params.Hash()
+	if err != nil {
+		return nil, err
+	}
+
+	h := &Handler{
+		params: params,
+		hash:   hash,
+		opts: options{
+			maxInputs: defaultMaxInputs,
+			maxKeys:   defaultMaxKeys,
+			maxMults:  defaultMaxMultiplications,
+			maxTerms:  defaultMaxTerms,
+			maxLength: labeling.MaxExprLength,
+			maxDepth:  labeling.MaxExprDepth,
+		},
+		mux: http.NewServeMux(),
+	}
+	for _, opt := range opts {
+		opt(&h.opts)
+	}
+	if h.opts.maxBodySize == 0 {
+		h.opts.maxBodySize = expectedBodySize(params, h.opts.maxInputs, h.opts.maxKeys)
+	}
+
+	h.mux.HandleFunc("GET /v1/parameters", h.handleParameters)
+	h.mux.HandleFunc("POST /v1/eval", h.handleEval)
+
+	return h, nil
+}
+
+// expectedBodySize calcula el tamaño máximo de una petición con maxInputs entradas recién cifradas
+// y maxKeys claves de evaluación, en JSON con los cifrados en base64
+func expectedBodySize(params labeling.Parameters, maxInputs, maxKeys int) int64 {
+	encoded := func(n int) int64 {
+		return int64(base64.StdEncoding.EncodedLen(n))
+	}
+
+	// Cada entrada es un β y un elemento A por slot, de hasta 20 dígitos y el separador
+	input := encoded(rlwe.NewCiphertext(params, 1, params.MaxLevel()).BinarySize()) + 21*int64(params.MaxSlots())
+	key := encoded(rlwe.NewGaloisKey(params).BinarySize())
+	publicKey := encoded(rlwe.NewPublicKey(params).BinarySize())
+
+	return int64(maxInputs)*input + int64(maxKeys)*key + publicKey + jsonOverhead
+}
+
+// MaxBodySize devuelve el tamaño máximo del cuerpo de una petición en bytes
+func (h *Handler) MaxBodySize() int64 {
+	return h.opts.maxBodySize
+}
+
+// ServeHTTP atiende la petición
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+func (h *Handler) handleParameters(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, ParametersResponse{
+		ParametersHash:   h.hash[:],
+		PlaintextModulus: h.params.PlaintextModulus(),
+		MaxSlots:         h.params.MaxSlots(),
+		MaxInputs:        h.opts.maxInputs,
+		MaxBodySize:      h.opts.maxBodySize,
+	})
+}
+
+func (h *Handler) handleEval(w http.ResponseWriter, r *http.Request) {
+	if mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err != nil || mediaType != "application/json" {
+		writeError(w, http.StatusUnsupportedMediaType, fmt.Errorf("labelinghttp: se esperaba application/json"))
+		return
+	}
+
+	// Rechazamos las peticiones demasiado grandes antes de leerlas cuando declaran su longitud
+	if r.ContentLength > h.opts.maxBodySize {
+		writeError(w, http.StatusRequestEntityTooLarge, fmt.Errorf("labelinghttp: la petición supera %d bytes", h.opts.maxBodySize))
+		return
+	}
+
+	var req EvalRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, h.opts.maxBodySize)).Decode(&req); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeError(w, http.StatusRequestEntityTooLarge, fmt.Errorf("labelinghttp: la petición supera %d bytes", h.opts.maxBodySize))
+			return
+		}
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if len(req.Inputs) > h.opts.maxInputs {
+		writeError(w, http.StatusRequestEntityTooLarge, fmt.Errorf("labelinghttp: %d entradas, máximo %d", len(req.Inputs), h.opts.maxInputs))
+		return
+	}
+	if req.Keys != nil {
+		if n := countKeys(req.Keys); n > h.opts.maxKeys {
+			writeError(w, http.StatusRequestEntityTooLarge, fmt.Errorf("labelinghttp: %d claves de evaluación, máximo %d", n, h.opts.maxKeys))
+			return
+		}
+	}
+	if len(req.ParametersHash) > 0 && string(req.ParametersHash) != string(h.hash[:]) {
+		writeError(w, http.StatusConflict, labeling.ErrParametersMismatch)
+		return
+	}
+
+	if err := h.checkExpression(req.Expression); err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+	if err := h.checkCost(req.Expression); err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	result, err := labeling.EvalWithBundle(h.params, req.Expression, req.Inputs, req.Keys, h.opts.multiply...)
+	if errors.Is(err, labeling.ErrMalformed) {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, EvalResponse{Result: data})
+}
+
+// countKeys cuenta las claves de evaluación del paquete: relinealización, Galois y cambio de clave
+func countKeys(keys *labeling.KeyBundle) int {
+	n := len(keys.GaloisKeys) + len(keys.EvaluationKeys)
+	if keys.RelinearizationKey != nil {
+		n++
+	}
+	return n
+}
+
+// checkExpression rechaza las expresiones demasiado largas o anidadas sin llegar a analizarlas
+func (h *Handler) checkExpression(source string) error {
+	if len(source) > h.opts.maxLength {
+		return fmt.Errorf("%w: la expresión tiene %d bytes, máximo %d", labeling.ErrSyntax, len(source), h.opts.maxLength)
+	}
+
+	depth := 0
+	for i := 0; i < len(source); i++ {
+		switch source[i] {
+		case '(':
+			if depth++; depth > h.opts.maxDepth {
+				return fmt.Errorf("%w: más de %d niveles de anidamiento", labeling.ErrSyntax, h.opts.maxDepth)
+			}
+		case ')':
+			depth--
+		}
+	}
+
+	return nil
+}
+
+// checkCost rechaza las expresiones que no caben en los niveles de los parámetros o cuyo coste
+// estimado supera los límites del servicio
+func (h *Handler) checkCost(source string) error {
+	expr, err := labeling.ParseExpr(source)
+	if err != nil {
+		return err
+	}
+
+	cost, err := labeling.EstimateCost(expr, h.params, h.opts.multiply...)
+	if err != nil {
+		return err
+	}
+	if !cost.Feasible() {
+		return fmt.Errorf("%w: la expresión consume %d niveles, máximo %d", labeling.ErrInsufficientDepth, cost.Levels, h.params.MaxLevel())
+	}
+	if h.opts.maxMults != 0 && cost.Multiplications > h.opts.maxMults {
+		return fmt.Errorf("labelinghttp: %d multiplicaciones, máximo %d", cost.Multiplications, h.opts.maxMults)
+	}
+	if h.opts.maxTerms != 0 && cost.Terms > h.opts.maxTerms {
+		return fmt.Errorf("labelinghttp: %d términos en el resultado, máximo %d", cost.Terms, h.opts.maxTerms)
+	}
+
+	return nil
+}
+
+// writeJSON escribe v como cuerpo JSON con el código status
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError escribe una EvalResponse con el error
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, EvalResponse{Error: err.Error()})
+}
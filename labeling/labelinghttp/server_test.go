@@ -0,0 +1,109 @@
+// Copyright 2025 Juan Martín Pérez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package labelinghttp
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/tuneinsight/lattigo/v6/core/rlwe"
+
+	"main.go/labeling"
+)
+
+func TestEvalMaxKeys(t *testing.T) {
+	params, err := labeling.InsecureTestParameters()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sk, pk := labeling.GenerateKeyPair(params)
+	x, err := labeling.Encrypt(params, pk, []uint64{2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	evk := labeling.GenerateMemEvaluationKeySetWithGalois(labeling.GenerateRelinearizationKey(params, sk), labeling.GenerateGaloisKeysForRotations(params, sk, []int{1})...)
+	body, err := json.Marshal(EvalRequest{
+		Expression: "x*x",
+		Inputs:     map[string]labeling.PlaintextLabeledciphertext{"x": x},
+		Keys:       labeling.NewKeyBundle(pk.(*rlwe.PublicKey), evk),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// La petición lleva dos claves de evaluación: la de relinealización y una de Galois
+	for maxKeys, status := range map[int]int{1: http.StatusRequestEntityTooLarge, 2: http.StatusOK} {
+		// El tamaño del cuerpo no limita: solo cuenta el número de claves
+		h, err := NewHandler(params, WithMaxKeys(maxKeys), WithMaxBodySize(int64(len(body))))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req := httptest.NewRequest(http.MethodPost, "/v1/eval", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != status {
+			t.Fatalf("máximo de %d claves: se esperaba %d y se obtuvo %d: %s", maxKeys, status, rec.Code, rec.Body)
+		}
+	}
+}
+
+func TestEvalRejectsDeepExpressions(t *testing.T) {
+	params, err := labeling.InsecureTestParameters()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, pk := labeling.GenerateKeyPair(params)
+	x, err := labeling.Encrypt(params, pk, []uint64{2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h, err := NewHandler(params)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Un millón de paréntesis anidados agotaría la pila de un analizador sin límite
+	const nesting = 1 << 20
+	expressions := map[string]string{
+		"anidada": strings.Repeat("(", nesting) + "x" + strings.Repeat(")", nesting),
+		"larga":   "x" + strings.Repeat("+x", labeling.MaxExprLength),
+	}
+	for name, expression := range expressions {
+		body, err := json.Marshal(EvalRequest{
+			Expression: expression,
+			Inputs:     map[string]labeling.PlaintextLabeledciphertext{"x": x},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req := httptest.NewRequest(http.MethodPost, "/v1/eval", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != http.StatusUnprocessableEntity {
+			t.Fatalf("expresión %s: se esperaba %d y se obtuvo %d: %s", name, http.StatusUnprocessableEntity, rec.Code, rec.Body)
+		}
+	}
+}
@@ -0,0 +1,22 @@
+// Copyright 2025 Juan Martín Pérez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package labelingpb contiene los mensajes de labeling.proto generados con protoc-gen-go, de modo
+// que clientes en otros lenguajes (Python, TypeScript...) generados a partir del mismo esquema
+// pueden hablar con servicios de evaluación en Go. Los mensajes implementan proto.Message y se
+// codifican con proto.Marshal y proto.Unmarshal. Las conversiones desde y hacia los tipos de
+// labeling están en el paquete labeling.
+package labelingpb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative labeling.proto
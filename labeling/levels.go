@@ -0,0 +1,188 @@
+// Copyright 2025 Juan Martín Pérez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package labeling
+
+import (
+	"fmt"
+
+	"github.com/tuneinsight/lattigo/v6/core/rlwe"
+	"github.com/tuneinsight/lattigo/v6/schemes/bgv"
+)
+
+// ciphertexts devuelve todos los rlwe.Ciphertext internos: α (si está cifrado) y cada β
+func (lc Labeledciphertext[T]) ciphertexts() []*rlwe.Ciphertext {
+	var cts []*rlwe.Ciphertext
+
+	if alpha, ok := any(lc.elementsA).(*CiphertextElement); ok && alpha != nil {
+		cts = append(cts, (*rlwe.Ciphertext)(alpha))
+	}
+
+	for i := range lc.elementsB {
+		for j := range lc.elementsB[i] {
+			cts = append(cts, &lc.elementsB[i][j])
+		}
+	}
+
+	return cts
+}
+
+// Level devuelve el nivel efectivo del labeled ciphertext, es decir, el menor nivel de sus
+// componentes cifradas. En BGV coincide con el número de multiplicaciones que aún puede absorber.
+func (lc Labeledciphertext[T]) Level() int {
+	cts := lc.ciphertexts()
+	if len(cts) == 0 {
+		return 0
+	}
+
+	level := cts[0].Level()
+	for _, ct := range cts[1:] {
+		level = min(level, ct.Level())
+	}
+	return level
+}
+
+// MaxLevel devuelve el mayor nivel de las componentes cifradas.
+// Si difiere de Level, las componentes no están alineadas y las de mayor nivel pueden reducirse.
+func (lc Labeledciphertext[T]) MaxLevel() int {
+	level := 0
+	for _, ct := range lc.ciphertexts() {
+		level = max(level, ct.Level())
+	}
+	return level
+}
+
+// Degree devuelve el grado del labeled ciphertext: el mayor número de βs que se multiplican
+// al descifrar. Vale 1 para un PlaintextLabeledciphertext y 2 tras un MultOverflow.
+func (lc Labeledciphertext[T]) Degree() int {
+	degree := 0
+	for i := range lc.elementsB {
+		degree = max(degree, len(lc.elementsB[i]))
+	}
+	return degree
+}
+
+// mapCiphertexts aplica f a cada componente cifrada (α si está cifrado y cada β) y devuelve un
+// nuevo labeled ciphertext con los resultados, conservando los elementos A en texto plano
+func mapCiphertexts[T any](lc Labeledciphertext[T], f func(ct *rlwe.Ciphertext) (*rlwe.Ciphertext, error)) (Labeledciphertext[T], error) {
+	result := Labeledciphertext[T]{elementsA: lc.elementsA, meta: lc.meta}
+
+	if alpha, ok := any(lc.elementsA).(*CiphertextElement); ok && alpha != nil {
+		ct, err := f((*rlwe.Ciphertext)(alpha))
+		if err != nil {
+			return result, err
+		}
+		result.elementsA = any((*CiphertextElement)(ct)).(T)
+	}
+
+	result.elementsB = make([][]rlwe.Ciphertext, len(lc.elementsB))
+	for i := range lc.elementsB {
+		result.elementsB[i] = make([]rlwe.Ciphertext, len(lc.elementsB[i]))
+		for j := range lc.elementsB[i] {
+			ct, err := f(&lc.elementsB[i][j])
+			if err != nil {
+				return result, err
+			}
+			result.elementsB[i][j] = *ct
+		}
+	}
+
+	return result, nil
+}
+
+// Rescale divide todas las componentes cifradas por el último primo de su cadena, bajando un nivel.
+// Reduce el ruido en valor absoluto y permite controlar su crecimiento en computaciones largas.
+// Solo está disponible en BGV: BFV mantiene los cifrados en el nivel máximo.
+func Rescale[T PlaintextElements | *CiphertextElement](params Parameters, labeledciphertext Labeledciphertext[T]) (Labeledciphertext[T], error) {
+	if params.Scheme() != SchemeBGV {
+		return labeledciphertext, fmt.Errorf("labeling: Rescale no está disponible en %s", params.Scheme())
+	}
+
+	evaluator := bgv.NewEvaluator(params.Parameters, nil)
+
+	result, err := mapCiphertexts(labeledciphertext, func(ct *rlwe.Ciphertext) (*rlwe.Ciphertext, error) {
+		if ct.Level() == 0 {
+			return nil, fmt.Errorf("labeling: no se puede reescalar un cifrado en el nivel 0")
+		}
+
+		ctOut := rlwe.NewCiphertext(params, ct.Degree(), ct.Level()-1)
+		if err := evaluator.Rescale(ct, ctOut); err != nil {
+			return nil, err
+		}
+		return ctOut, nil
+	})
+	if err != nil {
+		return labeledciphertext, err
+	}
+
+	result.meta = deriveMetadata("Rescale", false, labeledciphertext.meta)
+
+	return result, nil
+}
+
+// LevelError indica que una operación requiere un nivel que el labeled ciphertext no tiene
+type LevelError struct {
+	// Level es el nivel efectivo del labeled ciphertext
+	Level int
+	// Required es el nivel que requería la operación
+	Required int
+}
+
+func (e *LevelError) Error() string {
+	return fmt.Sprintf("labeling: nivel %d incompatible, se requiere el nivel %d", e.Level, e.Required)
+}
+
+// dropToLevel baja todas las componentes cifradas al nivel indicado
+func dropToLevel[T any](lc Labeledciphertext[T], level int) (Labeledciphertext[T], error) {
+	if current := lc.Level(); level < 0 || level > current {
+		return lc, &LevelError{Level: current, Required: level}
+	}
+
+	return mapCiphertexts(lc, func(ct *rlwe.Ciphertext) (*rlwe.Ciphertext, error) {
+		ctOut := ct.CopyNew()
+		ctOut.Resize(ctOut.Degree(), level)
+		return ctOut, nil
+	})
+}
+
+// DropLevel baja n niveles el labeled ciphertext descartando primos de la cadena, sin reescalar.
+// Todas las componentes quedan alineadas en el nivel Level() - n.
+func DropLevel[T any](labeledciphertext Labeledciphertext[T], n int) (Labeledciphertext[T], error) {
+	result, err := dropToLevel(labeledciphertext, labeledciphertext.Level()-n)
+	if err != nil {
+		return labeledciphertext, err
+	}
+
+	result.meta = deriveMetadata("DropLevel", false, labeledciphertext.meta)
+
+	return result, nil
+}
+
+// AlignLevels lleva dos labeled ciphertexts, y todas sus componentes, al menor de sus niveles
+// para que puedan combinarse con Sum, Mult y sus variantes
+func AlignLevels[T1, T2 any](labeledciphertext1 Labeledciphertext[T1], labeledciphertext2 Labeledciphertext[T2]) (Labeledciphertext[T1], Labeledciphertext[T2], error) {
+	level := min(labeledciphertext1.Level(), labeledciphertext2.Level())
+
+	aligned1, err := dropToLevel(labeledciphertext1, level)
+	if err != nil {
+		return labeledciphertext1, labeledciphertext2, err
+	}
+
+	aligned2, err := dropToLevel(labeledciphertext2, level)
+	if err != nil {
+		return labeledciphertext1, labeledciphertext2, err
+	}
+
+	return aligned1, aligned2, nil
+}
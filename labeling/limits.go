@@ -0,0 +1,65 @@
+// Copyright 2025 Juan Martín Pérez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package labeling
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrDegreeExceeded se devuelve cuando una operación superaría el grado o el número de términos
+// máximos configurados en alguno de sus operandos
+var ErrDegreeExceeded = errors.New("labeling: grado máximo superado")
+
+// Terms devuelve el número de productos de βs que se suman al descifrar.
+// Cada SumOverflow concatena los términos de sus operandos.
+func (lc Labeledciphertext[T]) Terms() int {
+	return len(lc.elementsB)
+}
+
+// WithMaxDegree devuelve una copia del labeled ciphertext que limita a degree el número de βs
+// por término. Los resultados de operar con él heredan el límite; 0 lo elimina.
+func (lc Labeledciphertext[T]) WithMaxDegree(degree int) Labeledciphertext[T] {
+	lc.meta.maxDegree = max(degree, 0)
+	return lc
+}
+
+// WithMaxTerms devuelve una copia del labeled ciphertext que limita a terms el número de términos.
+// Los resultados de operar con él heredan el límite; 0 lo elimina.
+func (lc Labeledciphertext[T]) WithMaxTerms(terms int) Labeledciphertext[T] {
+	lc.meta.maxTerms = max(terms, 0)
+	return lc
+}
+
+// MaxDegree devuelve el grado máximo configurado, o 0 si no hay límite
+func (lc Labeledciphertext[T]) MaxDegree() int {
+	return lc.meta.maxDegree
+}
+
+// MaxTerms devuelve el número máximo de términos configurado, o 0 si no hay límite
+func (lc Labeledciphertext[T]) MaxTerms() int {
+	return lc.meta.maxTerms
+}
+
+// checkLimits comprueba que un resultado con el grado y los términos indicados respeta los límites
+func (m metadata) checkLimits(degree, terms int) error {
+	if m.maxDegree != 0 && degree > m.maxDegree {
+		return fmt.Errorf("%w: grado %d, máximo %d", ErrDegreeExceeded, degree, m.maxDegree)
+	}
+	if m.maxTerms != 0 && terms > m.maxTerms {
+		return fmt.Errorf("%w: %d términos, máximo %d", ErrDegreeExceeded, terms, m.maxTerms)
+	}
+	return nil
+}
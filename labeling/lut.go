@@ -0,0 +1,129 @@
+// Copyright 2025 Juan Martín Pérez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package labeling
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"sync"
+
+	"github.com/tuneinsight/lattigo/v6/core/rlwe"
+)
+
+// lutCacheSize es el número máximo de tablas cuyos coeficientes se guardan
+const lutCacheSize = 64
+
+// lutCache guarda los coeficientes interpolados de las últimas tablas, indexados por el resumen de t
+// y la tabla. Solo se interpolan tablas que caben en la profundidad, así que cada entrada tiene a lo
+// sumo min(len(table), 2^profundidad + 1) coeficientes.
+var lutCache = struct {
+	sync.Mutex
+	coeffs map[[sha256.Size]byte][]uint64
+	order  [][sha256.Size]byte
+}{coeffs: make(map[[sha256.Size]byte][]uint64)}
+
+// lutCoefficients devuelve los coeficientes del polinomio que vale table[x] en x = 0, …, len(table) − 1,
+// interpolándolos solo la primera vez que se usa cada tabla. El polinomio debe tener grado como mucho
+// degree, así que basta con interpolar los degree + 1 primeros puntos. Cuando la caché está llena se
+// descarta la tabla más antigua.
+func lutCoefficients(t uint64, table []uint64, degree int) []uint64 {
+	hash := sha256.New()
+	_ = binary.Write(hash, binary.LittleEndian, t)
+	_ = binary.Write(hash, binary.LittleEndian, table)
+
+	var key [sha256.Size]byte
+	copy(key[:], hash.Sum(nil))
+
+	lutCache.Lock()
+	coeffs, ok := lutCache.coeffs[key]
+	lutCache.Unlock()
+	if ok {
+		return coeffs
+	}
+
+	points := make([]uint64, min(len(table), degree+1))
+	for i := range points {
+		points[i] = uint64(i)
+	}
+	coeffs = interpolate(points, table[:len(points)], t)
+
+	lutCache.Lock()
+	defer lutCache.Unlock()
+	if _, ok := lutCache.coeffs[key]; !ok {
+		if len(lutCache.order) == lutCacheSize {
+			delete(lutCache.coeffs, lutCache.order[0])
+			lutCache.order = lutCache.order[1:]
+		}
+		lutCache.coeffs[key] = coeffs
+		lutCache.order = append(lutCache.order, key)
+	}
+
+	return coeffs
+}
+
+// degreeAtMost indica si el polinomio que interpola values en los puntos 0, 1, …, len(values) − 1 tiene
+// grado como mucho degree, es decir, si sus diferencias finitas de orden degree + 1 son nulas. Vale en
+// Z_t porque los factoriales hasta len(values) − 1 < t son invertibles. Cuesta O(len(values)·degree)
+// frente al O(len(values)²) de interpolate.
+func degreeAtMost(values []uint64, degree int, t uint64) bool {
+	if degree+1 >= len(values) {
+		return true
+	}
+
+	diffs := make([]uint64, len(values))
+	for i, value := range values {
+		diffs[i] = value % t
+	}
+	for order := 1; order <= degree+1; order++ {
+		for i := range len(diffs) - order {
+			diffs[i] = (diffs[i+1] + t - diffs[i]) % t
+		}
+	}
+	for _, diff := range diffs[:len(diffs)-degree-1] {
+		if diff != 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// EvalLUT evalúa una función arbitraria dada como tabla: el resultado vale table[x] en los slots con
+// valor x. Las entradas deben estar en [0, len(table)) y la tabla se interpola en un polinomio de grado
+// a lo sumo len(table) − 1, cuyos coeficientes se guardan para las siguientes llamadas con la misma
+// tabla. El grado se comprueba con diferencias finitas antes de interpolar: si la cadena de módulos es
+// demasiado corta devuelve ErrInsufficientDepth.
+func EvalLUT(params Parameters, labeledciphertext PlaintextLabeledciphertext, table []uint64, key rlwe.EncryptionKey, evk *rlwe.MemEvaluationKeySet, opts ...MultiplyOption) (Operand, error) {
+	options := multiplyOptions{depth: params.MaxLevel()}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	t := params.PlaintextModulus()
+	if len(table) == 0 || uint64(len(table)) > t {
+		return nil, fmt.Errorf("labeling: tabla de %d entradas fuera de rango para t = %d", len(table), t)
+	}
+
+	operation := fmt.Sprintf("EvalLUT con %d entradas", len(table))
+
+	// evalInterpolated admite grados hasta 2^(depth − multiplicaciones)
+	maxDegree := maxPolynomialDegree(options.depth - labeledciphertext.Multiplications())
+	if maxDegree < 0 || !degreeAtMost(table, maxDegree, t) {
+		return nil, fmt.Errorf("%w: %s requiere un polinomio de grado mayor que %d", ErrInsufficientDepth, operation, max(maxDegree, 0))
+	}
+
+	return evalInterpolated(params, labeledciphertext, lutCoefficients(t, table, maxDegree), operation, key, evk, opts...)
+}
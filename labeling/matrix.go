@@ -0,0 +1,276 @@
+// Copyright 2025 Juan Martín Pérez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package labeling
+
+import (
+	"fmt"
+
+	"github.com/tuneinsight/lattigo/v6/core/rlwe"
+)
+
+// MatrixLayout indica cómo se empaqueta una matriz en labeled ciphertexts
+type MatrixLayout int
+
+const (
+	// RowMajor guarda cada fila en un labeled ciphertext, a partir del slot 0
+	RowMajor MatrixLayout = iota
+	// ColumnMajor guarda cada columna en un labeled ciphertext, a partir del slot 0
+	ColumnMajor
+)
+
+// EncryptedMatrix es una matriz cifrada con un labeled ciphertext por fila o por columna. Los
+// vectores están en forma plaintext salvo en los resultados de MatMul que hayan agotado el
+// presupuesto de profundidad, que quedan en forma overflow.
+type EncryptedMatrix struct {
+	Rows, Cols int
+	Layout     MatrixLayout
+	vectors    []Operand
+}
+
+// EncryptMatrix cifra una matriz de Rows × Cols con el empaquetado indicado.
+// Cada fila (o columna) debe caber en la primera fila de slots, es decir, tener como mucho N/2 elementos.
+func EncryptMatrix(params Parameters, key rlwe.EncryptionKey, values [][]uint64, layout MatrixLayout) (EncryptedMatrix, error) {
+	if len(values) == 0 {
+		return EncryptedMatrix{}, fmt.Errorf("labeling: matriz vacía")
+	}
+
+	matrix := EncryptedMatrix{Rows: len(values), Cols: len(values[0]), Layout: layout}
+	for _, row := range values {
+		if len(row) != matrix.Cols {
+			return EncryptedMatrix{}, fmt.Errorf("labeling: las filas de la matriz tienen longitudes distintas")
+		}
+	}
+
+	count, length := matrix.Rows, matrix.Cols
+	if layout == ColumnMajor {
+		count, length = matrix.Cols, matrix.Rows
+	}
+	if length > params.MaxSlots()/2 {
+		return EncryptedMatrix{}, fmt.Errorf("labeling: vectores de %d elementos, el máximo es %d", length, params.MaxSlots()/2)
+	}
+
+	matrix.vectors = make([]Operand, count)
+	vector := make([]uint64, length)
+	for v := range count {
+		for i := range length {
+			if layout == RowMajor {
+				vector[i] = values[v][i]
+			} else {
+				vector[i] = values[i][v]
+			}
+		}
+
+		labeledciphertext, err := Encrypt(params, key, vector)
+		if err != nil {
+			return EncryptedMatrix{}, err
+		}
+		matrix.vectors[v] = labeledciphertext
+	}
+
+	return matrix, nil
+}
+
+// DecryptMatrix descifra una EncryptedMatrix y la devuelve como filas
+func DecryptMatrix(params Parameters, key *rlwe.SecretKey, matrix EncryptedMatrix) ([][]uint64, error) {
+	values := make([][]uint64, matrix.Rows)
+	for i := range values {
+		values[i] = make([]uint64, matrix.Cols)
+	}
+
+	for v, labeledciphertext := range matrix.vectors {
+		vector, err := decryptOperand(params, key, labeledciphertext)
+		if err != nil {
+			return nil, err
+		}
+
+		if matrix.Layout == RowMajor {
+			copy(values[v], vector[:matrix.Cols])
+		} else {
+			for i := range matrix.Rows {
+				values[i][v] = vector[i]
+			}
+		}
+	}
+
+	return values, nil
+}
+
+// unitVector devuelve el vector con un 1 en la posición index
+func unitVector(params Parameters, index int) []uint64 {
+	vector := make([]uint64, params.MaxSlots())
+	vector[index] = 1
+	return vector
+}
+
+// maskOperand multiplica slot a slot un labeled ciphertext de cualquier forma por un vector público
+func maskOperand(params Parameters, a Operand, vector []uint64) (Operand, error) {
+	switch x := a.(type) {
+	case PlaintextLabeledciphertext:
+		return mulPlain(params, x, vector)
+	case CiphertextLabeledciphertext:
+		return mulPlainOverflow(params, x, vector)
+	}
+
+	return nil, fmt.Errorf("%w: producto por un vector de %T", ErrUnsupportedOperands, a)
+}
+
+// innerSumOperand suma todos los slots de un labeled ciphertext de cualquier forma
+func innerSumOperand(params Parameters, a Operand, evk *rlwe.MemEvaluationKeySet) (Operand, error) {
+	switch x := a.(type) {
+	case PlaintextLabeledciphertext:
+		return innerSum(params, x, evk)
+	case CiphertextLabeledciphertext:
+		return InnerSumOverflow(params, x, evk)
+	}
+
+	return nil, fmt.Errorf("%w: suma de slots de %T", ErrUnsupportedOperands, a)
+}
+
+// withMetadata devuelve a con los metadatos meta
+func withMetadata(a Operand, meta metadata) Operand {
+	switch x := a.(type) {
+	case PlaintextLabeledciphertext:
+		x.meta = meta
+		return x
+	case CiphertextLabeledciphertext:
+		x.meta = meta
+		return x
+	}
+	return a
+}
+
+// operandMetadata devuelve los metadatos de un labeled ciphertext de cualquier forma
+func operandMetadata(a Operand) metadata {
+	switch x := a.(type) {
+	case PlaintextLabeledciphertext:
+		return x.meta
+	case CiphertextLabeledciphertext:
+		return x.meta
+	}
+	return metadata{}
+}
+
+// MatMul multiplica dos matrices cifradas: a debe estar empaquetada por filas y b por columnas, y el
+// resultado se empaqueta por filas. Cada elemento cᵢⱼ es el producto escalar de la fila i de a y la
+// columna j de b, que se coloca en el slot j multiplicando por un vector unitario.
+//
+// Los productos entre cifrados se hacen con Multiply: mientras quede presupuesto de profundidad son
+// Mult y la suma de slots es la de InnerSum; al agotarlo pasan a forma overflow y la suma de slots es
+// la de InnerSumOverflow, que pliega los βs en α con Compact antes de rotar para que el número de
+// términos no crezca con N. En ese caso el resultado queda en forma overflow y, en BGV, Compact
+// consume un nivel más. evk debe incluir la clave de relinealización y las claves de
+// InnerSumGaloisElements.
+func MatMul(params Parameters, a, b EncryptedMatrix, key rlwe.EncryptionKey, evk *rlwe.MemEvaluationKeySet, opts ...MultiplyOption) (EncryptedMatrix, error) {
+	if a.Layout != RowMajor || b.Layout != ColumnMajor {
+		return EncryptedMatrix{}, fmt.Errorf("labeling: MatMul requiere a por filas y b por columnas")
+	}
+	if a.Cols != b.Rows {
+		return EncryptedMatrix{}, fmt.Errorf("labeling: dimensiones incompatibles %d×%d y %d×%d", a.Rows, a.Cols, b.Rows, b.Cols)
+	}
+
+	result := EncryptedMatrix{Rows: a.Rows, Cols: b.Cols, Layout: RowMajor, vectors: make([]Operand, a.Rows)}
+	for i := range a.Rows {
+		var row Operand
+		for j := range b.Cols {
+			product, err := Multiply(params, a.vectors[i], b.vectors[j], key, evk, opts...)
+			if err != nil {
+				return EncryptedMatrix{}, err
+			}
+
+			// cᵢⱼ replicado en todos los slots
+			if product, err = innerSumOperand(params, product, evk); err != nil {
+				return EncryptedMatrix{}, err
+			}
+
+			// Nos quedamos con el slot j
+			if product, err = maskOperand(params, product, unitVector(params, j)); err != nil {
+				return EncryptedMatrix{}, err
+			}
+
+			if j == 0 {
+				row = product
+			} else if row, err = Add(params, row, product); err != nil {
+				return EncryptedMatrix{}, err
+			}
+		}
+
+		metas := []metadata{operandMetadata(a.vectors[i])}
+		for _, column := range b.vectors {
+			metas = append(metas, operandMetadata(column))
+		}
+		result.vectors[i] = withMetadata(row, deriveMetadata("MatMul", true, metas...))
+	}
+
+	return result, nil
+}
+
+// TransposeGaloisElements devuelve los elementos de Galois de las rotaciones que necesita Transpose
+// para una matriz de rows × cols
+func TransposeGaloisElements(params Parameters, rows, cols int) []uint64 {
+	var shifts []int
+	for i := range rows {
+		for j := range cols {
+			shifts = append(shifts, j-i)
+		}
+	}
+	return ColumnRotationGaloisElements(params, shifts...)
+}
+
+// Transpose traspone una matriz cifrada conservando su empaquetado, permutando los slots sin descifrar:
+// el elemento del slot j del vector i pasa al slot i del vector j, aislándolo con MulPlain y rotándolo
+// j − i posiciones. Requiere Rows·Cols rotaciones. Si solo se necesita cambiar de empaquetado, una matriz
+// por filas ya es, sin operar, la traspuesta empaquetada por columnas. Las matrices en forma overflow
+// se trasponen igual, y el número de términos de cada vector crece con el de vectores.
+func Transpose(params Parameters, matrix EncryptedMatrix, evk *rlwe.MemEvaluationKeySet) (EncryptedMatrix, error) {
+	// count vectores de length elementos
+	count, length := matrix.Rows, matrix.Cols
+	if matrix.Layout == ColumnMajor {
+		count, length = matrix.Cols, matrix.Rows
+	}
+
+	result := EncryptedMatrix{Rows: matrix.Cols, Cols: matrix.Rows, Layout: matrix.Layout, vectors: make([]Operand, length)}
+	for j := range length {
+		var vector Operand
+		for i := range count {
+			// Aislamos el slot j del vector i
+			element, err := maskOperand(params, matrix.vectors[i], unitVector(params, j))
+			if err != nil {
+				return EncryptedMatrix{}, err
+			}
+
+			// y lo llevamos al slot i
+			if j != i {
+				if element, err = rotateOperand(params, element, j-i, evk); err != nil {
+					return EncryptedMatrix{}, err
+				}
+			}
+
+			if i == 0 {
+				vector = element
+			} else if vector, err = Add(params, vector, element); err != nil {
+				return EncryptedMatrix{}, err
+			}
+		}
+
+		metas := make([]metadata, count)
+		for i := range count {
+			metas[i] = operandMetadata(matrix.vectors[i])
+		}
+
+		result.vectors[j] = withMetadata(vector, deriveMetadata("Transpose", false, metas...))
+	}
+
+	return result, nil
+}
@@ -0,0 +1,89 @@
+// Copyright 2025 Juan Martín Pérez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Pruebas del producto de matrices en forma plaintext y en forma overflow.
+
+package labeling
+
+import (
+	"testing"
+)
+
+func TestMatMul(t *testing.T) {
+	params, err := InsecureTestParameters()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sk, pk := GenerateKeyPair(params)
+	galEls := append(InnerSumGaloisElements(params), TransposeGaloisElements(params, 2, 2)...)
+	evk := GenerateMemEvaluationKeySetWithGalois(GenerateRelinearizationKey(params, sk), GenerateGaloisKeys(params, sk, galEls)...)
+
+	left := [][]uint64{{1, 2, 3}, {4, 5, 6}}
+	right := [][]uint64{{7, 8}, {9, 10}, {11, 12}}
+	want := [][]uint64{{58, 64}, {139, 154}}
+
+	a, err := EncryptMatrix(params, pk, left, RowMajor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := EncryptMatrix(params, pk, right, ColumnMajor)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Con presupuesto los productos son Mult; sin él, o con WithOverflow, pasan a forma overflow
+	cases := map[string]struct {
+		opts     []MultiplyOption
+		overflow bool
+	}{
+		"plaintext":           {nil, false},
+		"overflow":            {[]MultiplyOption{WithOverflow()}, true},
+		"presupuesto agotado": {[]MultiplyOption{WithDepthBudget(1)}, true},
+	}
+	for name, c := range cases {
+		product, err := MatMul(params, a, b, pk, evk, c.opts...)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		for i, row := range product.vectors {
+			if row.IsOverflow() != c.overflow {
+				t.Fatalf("%s: fila %d en forma overflow = %t", name, i, row.IsOverflow())
+			}
+		}
+
+		got, err := DecryptMatrix(params, sk, product)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		for i := range want {
+			checkValues(t, got[i], want[i])
+		}
+
+		// El resultado se traspone en cualquiera de las dos formas
+		transposed, err := Transpose(params, product, evk)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if got, err = DecryptMatrix(params, sk, transposed); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		for i := range want {
+			for j := range want[i] {
+				if got[j][i] != want[i][j] {
+					t.Fatalf("%s: traspuesta (%d, %d): se esperaba %d y se obtuvo %d", name, j, i, want[i][j], got[j][i])
+				}
+			}
+		}
+	}
+}
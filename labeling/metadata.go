@@ -0,0 +1,96 @@
+// Copyright 2025 Juan Martín Pérez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package labeling
+
+import "slices"
+
+// maxOperations es el número máximo de operaciones que guarda el historial. Sin límite, combinar un
+// labeled ciphertext consigo mismo duplicaría el historial en cada operación, y el historial se
+// serializa con el labeled ciphertext.
+const maxOperations = 64
+
+// metadata guarda la procedencia de un labeled ciphertext
+type metadata struct {
+	// multiplications es la profundidad multiplicativa acumulada
+	multiplications int
+	// operations es el historial de operaciones que lo produjeron, operandos incluidos, limitado a
+	// las últimas maxOperations
+	operations []string
+	// maxDegree y maxTerms limitan la forma de los βs; 0 significa sin límite
+	maxDegree int
+	maxTerms  int
+	// labels son las etiquetas de las entradas de las que depende, sin repetir
+	labels []Label
+}
+
+// minLimit combina dos límites quedándose con el más estricto, siendo 0 sin límite
+func minLimit(a, b int) int {
+	if a == 0 || (b != 0 && b < a) {
+		return b
+	}
+	return a
+}
+
+// deriveMetadata construye los metadatos del resultado de una operación a partir de los de sus operandos.
+// Si la operación es una multiplicación entre cifrados, la profundidad aumenta en uno.
+func deriveMetadata(operation string, multiplication bool, operands ...metadata) metadata {
+	var result metadata
+
+	for _, operand := range operands {
+		result.multiplications = max(result.multiplications, operand.multiplications)
+		result.operations = append(result.operations, operand.operations...)
+		result.maxDegree = minLimit(result.maxDegree, operand.maxDegree)
+		result.maxTerms = minLimit(result.maxTerms, operand.maxTerms)
+		for _, label := range operand.labels {
+			if !slices.Contains(result.labels, label) {
+				result.labels = append(result.labels, label)
+			}
+		}
+	}
+
+	if multiplication {
+		result.multiplications++
+	}
+	result.operations = append(result.operations, operation)
+	if excess := len(result.operations) - maxOperations; excess > 0 {
+		result.operations = slices.Clone(result.operations[excess:])
+	}
+
+	return result
+}
+
+// Multiplications devuelve el número de multiplicaciones entre cifrados en el camino más largo
+// que produjo el labeled ciphertext
+func (lc Labeledciphertext[T]) Multiplications() int {
+	return lc.meta.multiplications
+}
+
+// IsOverflow indica si el labeled ciphertext está en forma overflow, con α cifrado
+func (lc Labeledciphertext[T]) IsOverflow() bool {
+	_, ok := any(lc.elementsA).(*CiphertextElement)
+	return ok
+}
+
+// Operations devuelve el historial de operaciones que produjo el labeled ciphertext, empezando por
+// los cifrados de las entradas. Solo se guardan las últimas 64 operaciones.
+func (lc Labeledciphertext[T]) Operations() []string {
+	return slices.Clone(lc.meta.operations)
+}
+
+// Labels devuelve las etiquetas de las entradas de las que depende el labeled ciphertext,
+// en orden de aparición. Está vacío si ninguna entrada se cifró con EncryptLabeled.
+func (lc Labeledciphertext[T]) Labels() []Label {
+	return slices.Clone(lc.meta.labels)
+}
@@ -0,0 +1,311 @@
+// Copyright 2025 Juan Martín Pérez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Generación multiparte de claves.
+//
+// Cada una de las N partes genera su propia clave secreta sᵢ, y la clave secreta conjunta es
+// s = Σ sᵢ, que nadie conoce. Las partes acuerdan una semilla pública común (CRS) de la que se
+// deriva el polinomio aleatorio a, cada una publica su contribución −a·sᵢ + eᵢ y el combinador
+// las suma para obtener la clave pública conjunta (−a·s + e, a). Los labeled ciphertexts cifrados
+// con ella solo pueden descifrarse con la colaboración de todas las partes, mediante CollectiveDecrypt.
+
+package labeling
+
+import (
+	"fmt"
+
+	"github.com/tuneinsight/lattigo/v6/core/rlwe"
+	"github.com/tuneinsight/lattigo/v6/multiparty"
+	"github.com/tuneinsight/lattigo/v6/ring"
+	"github.com/tuneinsight/lattigo/v6/utils/sampling"
+)
+
+// CollectiveKeyGen coordina la generación de una clave pública conjunta a partir de las claves
+// secretas de N partes
+type CollectiveKeyGen struct {
+	params   Parameters
+	protocol multiparty.PublicKeyGenProtocol
+	crp      multiparty.PublicKeyGenCRP
+}
+
+// NewCollectiveKeyGen inicia el protocolo. seed es la semilla pública común que todas las partes
+// deben usar; no es secreta, pero no debe reutilizarse entre ejecuciones.
+func NewCollectiveKeyGen(params Parameters, seed []byte) (*CollectiveKeyGen, error) {
+	crs, err := sampling.NewKeyedPRNG(seed)
+	if err != nil {
+		return nil, err
+	}
+
+	protocol := multiparty.NewPublicKeyGenProtocol(params)
+
+	return &CollectiveKeyGen{params: params, protocol: protocol, crp: protocol.SampleCRP(crs)}, nil
+}
+
+// GenerateSecretShare genera la clave secreta sᵢ de una parte, que nunca sale de ella
+func GenerateSecretShare(params Parameters) *rlwe.SecretKey {
+	return rlwe.NewKeyGenerator(params).GenSecretKeyNew()
+}
+
+// GenShare es el paso de cada parte: calcula su contribución pública a la clave conjunta
+func (c *CollectiveKeyGen) GenShare(sk *rlwe.SecretKey) multiparty.PublicKeyGenShare {
+	share := c.protocol.AllocateShare()
+	c.protocol.GenShare(sk, c.crp, &share)
+	return share
+}
+
+// Aggregate es el paso del combinador: suma las contribuciones de todas las partes y devuelve
+// la clave pública conjunta
+func (c *CollectiveKeyGen) Aggregate(shares []multiparty.PublicKeyGenShare) (*rlwe.PublicKey, error) {
+	if len(shares) == 0 {
+		return nil, fmt.Errorf("labeling: CollectiveKeyGen requiere al menos una contribución")
+	}
+
+	// Partimos de una contribución nula para no modificar las recibidas
+	aggregated := c.protocol.AllocateShare()
+	for _, share := range shares {
+		c.protocol.AggregateShares(aggregated, share, &aggregated)
+	}
+
+	pk := rlwe.NewPublicKey(c.params)
+	c.protocol.GenPublicKey(aggregated, c.crp, pk)
+
+	return pk, nil
+}
+
+// DecryptionShare es el descifrado parcial de una parte: una contribución por cada componente
+// cifrada del labeled ciphertext, α incluido si está en forma overflow
+type DecryptionShare struct {
+	shares []multiparty.KeySwitchShare
+}
+
+// CollectiveDecrypt coordina el descifrado de un labeled ciphertext cifrado con una clave pública
+// conjunta. Cada parte cambia cada componente cifrada de su clave sᵢ a la clave nula, añadiendo ruido
+// de inundación para no revelar sᵢ, y el combinador suma las contribuciones y termina el descifrado
+// con Decrypt o DecryptOverflow sin que nadie conozca la clave conjunta.
+//
+// El combinador ve el descifrado de cada componente. En la forma overflow eso incluye cada β, es
+// decir, las máscaras de las entradas, de las que junto con sus elementos A públicos obtendría las
+// entradas. Por eso la forma overflow solo se admite sin términos de β, plegados antes en α con Compact.
+type CollectiveDecrypt struct {
+	params   Parameters
+	protocol multiparty.KeySwitchProtocol
+	zero     *rlwe.SecretKey
+}
+
+// NewCollectiveDecrypt inicia el protocolo con ruido de inundación gaussiano de desviación sigma.
+// sigma debe ser mucho mayor que el ruido de los cifrados, por ejemplo 2^30, o FloodingSigma(ruido, λ)
+// para λ bits de seguridad estadística.
+func NewCollectiveDecrypt(params Parameters, sigma float64) (*CollectiveDecrypt, error) {
+	protocol, err := multiparty.NewKeySwitchProtocol(params, ring.DiscreteGaussian{Sigma: sigma, Bound: 6 * sigma})
+	if err != nil {
+		return nil, err
+	}
+
+	return &CollectiveDecrypt{params: params, protocol: protocol, zero: rlwe.NewSecretKey(params)}, nil
+}
+
+// operandCiphertexts devuelve las componentes cifradas de un labeled ciphertext de cualquier forma
+func operandCiphertexts(labeledciphertext Operand) ([]*rlwe.Ciphertext, error) {
+	switch lc := labeledciphertext.(type) {
+	case PlaintextLabeledciphertext:
+		return lc.ciphertexts(), nil
+	case CiphertextLabeledciphertext:
+		return lc.ciphertexts(), nil
+	}
+
+	return nil, fmt.Errorf("%w: descifrado colectivo de %T", ErrUnsupportedOperands, labeledciphertext)
+}
+
+// decryptableCiphertexts devuelve las componentes cifradas de un labeled ciphertext que puede
+// descifrarse colectivamente sin revelar más que el resultado
+func decryptableCiphertexts(labeledciphertext Operand) ([]*rlwe.Ciphertext, error) {
+	if lc, ok := labeledciphertext.(CiphertextLabeledciphertext); ok && len(lc.elementsB) > 0 {
+		return nil, fmt.Errorf("%w: el descifrado colectivo de la forma overflow revelaría sus %d términos de β; pliégalos en α con Compact", ErrUnsupportedOperands, len(lc.elementsB))
+	}
+	return operandCiphertexts(labeledciphertext)
+}
+
+// PartialDecrypt es el paso de cada parte: calcula con su clave sᵢ el descifrado parcial de todas
+// las componentes cifradas del labeled ciphertext. En forma overflow debe estar compactado.
+func (c *CollectiveDecrypt) PartialDecrypt(sk *rlwe.SecretKey, labeledciphertext Operand) (DecryptionShare, error) {
+	cts, err := decryptableCiphertexts(labeledciphertext)
+	if err != nil {
+		return DecryptionShare{}, err
+	}
+
+	share := DecryptionShare{shares: make([]multiparty.KeySwitchShare, len(cts))}
+	for i, ct := range cts {
+		share.shares[i] = c.protocol.AllocateShare(ct.Level())
+		c.protocol.GenShare(sk, c.zero, ct, &share.shares[i])
+	}
+
+	return share, nil
+}
+
+// Combine es el paso del combinador: suma los descifrados parciales de todas las partes y descifra
+// el labeled ciphertext
+func (c *CollectiveDecrypt) Combine(labeledciphertext Operand, shares []DecryptionShare) ([]uint64, error) {
+	cts, err := decryptableCiphertexts(labeledciphertext)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(shares) == 0 {
+		return nil, fmt.Errorf("labeling: CollectiveDecrypt requiere al menos un descifrado parcial")
+	}
+	for _, share := range shares {
+		if len(share.shares) != len(cts) {
+			return nil, fmt.Errorf("labeling: descifrado parcial con %d componentes para un labeled ciphertext con %d", len(share.shares), len(cts))
+		}
+	}
+
+	// Sumamos las contribuciones de cada componente partiendo de una contribución nula
+	combined := make([]multiparty.KeySwitchShare, len(cts))
+	for i, ct := range cts {
+		combined[i] = c.protocol.AllocateShare(ct.Level())
+		for _, share := range shares {
+			if err := c.protocol.AggregateShares(combined[i], share.shares[i], &combined[i]); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// Cambiamos cada componente a la clave nula en el mismo orden en que se recorrieron
+	next := 0
+	keySwitch := func(ct *rlwe.Ciphertext) (*rlwe.Ciphertext, error) {
+		ctOut := rlwe.NewCiphertext(c.params, 1, ct.Level())
+		c.protocol.KeySwitch(ct, combined[next], ctOut)
+		next++
+		return ctOut, nil
+	}
+
+	switch lc := labeledciphertext.(type) {
+	case PlaintextLabeledciphertext:
+		switched, err := mapCiphertexts(lc, keySwitch)
+		if err != nil {
+			return nil, err
+		}
+		return Decrypt(c.params, c.zero, switched)
+	case CiphertextLabeledciphertext:
+		switched, err := mapCiphertexts(lc, keySwitch)
+		if err != nil {
+			return nil, err
+		}
+		return DecryptOverflow(c.params, c.zero, switched)
+	}
+
+	return nil, fmt.Errorf("%w: descifrado colectivo de %T", ErrUnsupportedOperands, labeledciphertext)
+}
+
+// KeySwitchShare es la contribución de una parte al cambio de clave colectivo: una por cada
+// componente cifrada del labeled ciphertext, α incluido si está en forma overflow
+type KeySwitchShare struct {
+	shares []multiparty.PublicKeySwitchShare
+}
+
+// CollectiveKeySwitch coordina el cambio de un labeled ciphertext de la clave conjunta a la clave
+// de una parte de salida, que solo tiene que publicar su clave pública. Cada parte cambia cada
+// componente cifrada de su clave sᵢ a la clave pública de destino con ruido de inundación, y el
+// combinador suma las contribuciones. La clave conjunta no se reconstruye en ningún momento y el
+// resultado solo puede descifrarlo la parte de salida, con Decrypt o DecryptOverflow.
+type CollectiveKeySwitch struct {
+	params   Parameters
+	protocol multiparty.PublicKeySwitchProtocol
+	target   *rlwe.PublicKey
+}
+
+// NewCollectiveKeySwitch inicia el protocolo hacia la clave pública target con ruido de inundación
+// gaussiano de desviación sigma, que debe ser mucho mayor que el ruido de los cifrados
+func NewCollectiveKeySwitch(params Parameters, target *rlwe.PublicKey, sigma float64) (*CollectiveKeySwitch, error) {
+	protocol, err := multiparty.NewPublicKeySwitchProtocol(params, ring.DiscreteGaussian{Sigma: sigma, Bound: 6 * sigma})
+	if err != nil {
+		return nil, err
+	}
+
+	return &CollectiveKeySwitch{params: params, protocol: protocol, target: target}, nil
+}
+
+// GenShare es el paso de cada parte: calcula con su clave sᵢ la contribución de todas las
+// componentes cifradas del labeled ciphertext
+func (c *CollectiveKeySwitch) GenShare(sk *rlwe.SecretKey, labeledciphertext Operand) (KeySwitchShare, error) {
+	cts, err := operandCiphertexts(labeledciphertext)
+	if err != nil {
+		return KeySwitchShare{}, err
+	}
+
+	share := KeySwitchShare{shares: make([]multiparty.PublicKeySwitchShare, len(cts))}
+	for i, ct := range cts {
+		share.shares[i] = c.protocol.AllocateShare(ct.Level())
+		c.protocol.GenShare(sk, c.target, ct, &share.shares[i])
+	}
+
+	return share, nil
+}
+
+// Switch es el paso del combinador: suma las contribuciones de todas las partes y devuelve el
+// labeled ciphertext, en la misma forma, cifrado con la clave de la parte de salida
+func (c *CollectiveKeySwitch) Switch(labeledciphertext Operand, shares []KeySwitchShare) (Operand, error) {
+	cts, err := operandCiphertexts(labeledciphertext)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(shares) == 0 {
+		return nil, fmt.Errorf("labeling: CollectiveKeySwitch requiere al menos una contribución")
+	}
+	for _, share := range shares {
+		if len(share.shares) != len(cts) {
+			return nil, fmt.Errorf("labeling: contribución con %d componentes para un labeled ciphertext con %d", len(share.shares), len(cts))
+		}
+	}
+
+	// Sumamos las contribuciones de cada componente partiendo de una contribución nula
+	combined := make([]multiparty.PublicKeySwitchShare, len(cts))
+	for i, ct := range cts {
+		combined[i] = c.protocol.AllocateShare(ct.Level())
+		for _, share := range shares {
+			if err := c.protocol.AggregateShares(combined[i], share.shares[i], &combined[i]); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// Cambiamos cada componente a la clave de destino en el mismo orden en que se recorrieron
+	next := 0
+	keySwitch := func(ct *rlwe.Ciphertext) (*rlwe.Ciphertext, error) {
+		ctOut := rlwe.NewCiphertext(c.params, 1, ct.Level())
+		c.protocol.KeySwitch(ct, combined[next], ctOut)
+		next++
+		return ctOut, nil
+	}
+
+	switch lc := labeledciphertext.(type) {
+	case PlaintextLabeledciphertext:
+		switched, err := mapCiphertexts(lc, keySwitch)
+		if err != nil {
+			return nil, err
+		}
+		switched.meta = deriveMetadata("CollectiveKeySwitch", false, lc.meta)
+		return switched, nil
+	case CiphertextLabeledciphertext:
+		switched, err := mapCiphertexts(lc, keySwitch)
+		if err != nil {
+			return nil, err
+		}
+		switched.meta = deriveMetadata("CollectiveKeySwitch", false, lc.meta)
+		return switched, nil
+	}
+
+	return nil, fmt.Errorf("%w: cambio de clave colectivo de %T", ErrUnsupportedOperands, labeledciphertext)
+}
@@ -0,0 +1,156 @@
+// Copyright 2025 Juan Martín Pérez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package labeling
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+
+	"github.com/tuneinsight/lattigo/v6/core/rlwe"
+	"github.com/tuneinsight/lattigo/v6/schemes/bgv"
+)
+
+// ComponentNoise describe el ruido de una componente cifrada de un labeled ciphertext
+type ComponentNoise struct {
+	// Name identifica la componente: "alpha" o "beta[i][j]"
+	Name string
+	// Level es el nivel de la componente
+	Level int
+	// NoiseBits es log2 de la norma infinito del ruido
+	NoiseBits float64
+	// BudgetBits es el margen restante hasta que el ruido impida descifrar, en bits
+	BudgetBits float64
+}
+
+// NoiseReport agrupa el ruido de todas las componentes de un labeled ciphertext
+type NoiseReport struct {
+	Components []ComponentNoise
+}
+
+// Min devuelve el menor presupuesto de ruido entre todas las componentes, que es el que limita el descifrado
+func (r NoiseReport) Min() float64 {
+	budget := math.Inf(1)
+	for _, component := range r.Components {
+		budget = math.Min(budget, component.BudgetBits)
+	}
+	return budget
+}
+
+// NoiseBudget descifra internamente cada componente del labeled ciphertext y calcula su ruido
+// y el margen restante en bits. Requiere la clave secreta, así que es una herramienta de
+// depuración para ajustar circuitos y parámetros de forma empírica.
+func NoiseBudget[T any](params Parameters, key *rlwe.SecretKey, labeledciphertext Labeledciphertext[T]) (NoiseReport, error) {
+	var report NoiseReport
+
+	if alpha, ok := any(labeledciphertext.elementsA).(*CiphertextElement); ok && alpha != nil {
+		component, err := measureNoise(params, key, (*rlwe.Ciphertext)(alpha))
+		if err != nil {
+			return report, err
+		}
+		component.Name = "alpha"
+		report.Components = append(report.Components, component)
+	}
+
+	for i := range labeledciphertext.elementsB {
+		for j := range labeledciphertext.elementsB[i] {
+			component, err := measureNoise(params, key, &labeledciphertext.elementsB[i][j])
+			if err != nil {
+				return report, err
+			}
+			component.Name = fmt.Sprintf("beta[%d][%d]", i, j)
+			report.Components = append(report.Components, component)
+		}
+	}
+
+	return report, nil
+}
+
+// measureNoise calcula la norma infinito de t·e, siendo Dec(ct) = m + t·e,
+// restando al descifrado la recodificación del mensaje decodificado
+func measureNoise(params Parameters, key *rlwe.SecretKey, ct *rlwe.Ciphertext) (ComponentNoise, error) {
+	level := ct.Level()
+	encoder := bgv.NewEncoder(params.Parameters)
+
+	// Dec(ct) = m + t·e
+	pt := rlwe.NewDecryptor(params, key).DecryptNew(ct)
+
+	values := make([]uint64, params.MaxSlots())
+	if err := encoder.Decode(pt, values); err != nil {
+		return ComponentNoise{}, err
+	}
+
+	// Recodificamos m con los mismos metadatos para aislar t·e
+	ptMessage := bgv.NewPlaintext(params.Parameters, level)
+	*ptMessage.MetaData = *pt.MetaData
+	if err := encoder.Encode(values, ptMessage); err != nil {
+		return ComponentNoise{}, err
+	}
+
+	ringQ := params.RingQ().AtLevel(level)
+	noise := ringQ.NewPoly()
+	ringQ.Sub(pt.Value, ptMessage.Value, noise)
+	if pt.IsNTT {
+		ringQ.INTT(noise, noise)
+	}
+
+	// Reconstruimos cada coeficiente por CRT y nos quedamos con el mayor en valor absoluto centrado
+	moduli := params.Q()[:level+1]
+	Q := big.NewInt(1)
+	for _, qi := range moduli {
+		Q.Mul(Q, new(big.Int).SetUint64(qi))
+	}
+	halfQ := new(big.Int).Rsh(Q, 1)
+
+	crt := make([]*big.Int, len(moduli))
+	for i, qi := range moduli {
+		bigQi := new(big.Int).SetUint64(qi)
+		Qi := new(big.Int).Quo(Q, bigQi)
+		crt[i] = new(big.Int).Mul(Qi, new(big.Int).ModInverse(Qi, bigQi))
+	}
+
+	maxNoise := new(big.Int)
+	coeff := new(big.Int)
+	tmp := new(big.Int)
+	for j := range params.N() {
+		coeff.SetUint64(0)
+		for i := range moduli {
+			tmp.SetUint64(noise.Coeffs[i][j])
+			tmp.Mul(tmp, crt[i])
+			coeff.Add(coeff, tmp)
+		}
+		coeff.Mod(coeff, Q)
+		if coeff.Cmp(halfQ) > 0 {
+			coeff.Sub(Q, coeff)
+		}
+		if coeff.Cmp(maxNoise) > 0 {
+			maxNoise.Set(coeff)
+		}
+	}
+
+	noiseBits := 0.0
+	if maxNoise.Sign() > 0 {
+		f, _ := new(big.Float).SetInt(maxNoise).Float64()
+		noiseBits = math.Log2(f)
+	}
+
+	halfQFloat, _ := new(big.Float).SetInt(halfQ).Float64()
+
+	return ComponentNoise{
+		Level:      level,
+		NoiseBits:  noiseBits,
+		BudgetBits: math.Log2(halfQFloat) - noiseBits,
+	}, nil
+}
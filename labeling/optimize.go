@@ -0,0 +1,271 @@
+// Copyright 2025 Juan Martín Pérez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package labeling
+
+import (
+	"slices"
+)
+
+// OptimizeExpr reescribe una expresión para reducir su profundidad multiplicativa y el número de
+// multiplicaciones entre cifrados, que determinan los parámetros necesarios:
+//
+//   - pliega las constantes módulo t y agrupa los términos semejantes (x + x → 2·x),
+//   - reequilibra los productos de varios factores en un árbol de profundidad mínima,
+//   - saca factor común en las sumas (x·y + x·z → x·(y + z)) cuando no aumenta la profundidad.
+//
+// Las subexpresiones repetidas se evalúan una sola vez en EvalExpr.
+func OptimizeExpr(params Parameters, expr Expr) Expr {
+	return optimizeExpr(expr, params.PlaintextModulus())
+}
+
+// exprDepth devuelve la profundidad multiplicativa de la expresión, sin contar los productos por constantes
+func exprDepth(expr Expr) int {
+	switch e := expr.(type) {
+	case AddExpr:
+		return max(exprDepth(e.Left), exprDepth(e.Right))
+	case MulExpr:
+		depth := max(exprDepth(e.Left), exprDepth(e.Right))
+		if !isConstant(e.Left) && !isConstant(e.Right) {
+			depth++
+		}
+		return depth
+	case RotateExpr:
+		return exprDepth(e.Operand)
+	}
+	return 0
+}
+
+// exprMultiplications cuenta los productos distintos entre cifrados de la expresión,
+// ya que EvalExpr reutiliza las subexpresiones repetidas
+func exprMultiplications(expr Expr) int {
+	seen := make(map[string]bool)
+
+	var visit func(Expr)
+	visit = func(expr Expr) {
+		switch e := expr.(type) {
+		case AddExpr:
+			visit(e.Left)
+			visit(e.Right)
+		case MulExpr:
+			if !isConstant(e.Left) && !isConstant(e.Right) {
+				seen[e.String()] = true
+			}
+			visit(e.Left)
+			visit(e.Right)
+		case RotateExpr:
+			visit(e.Operand)
+		}
+	}
+	visit(expr)
+
+	return len(seen)
+}
+
+// sumTerms aplana una cadena de sumas en la lista de sus términos
+func sumTerms(expr Expr, terms []Expr) []Expr {
+	if e, ok := expr.(AddExpr); ok {
+		return sumTerms(e.Right, sumTerms(e.Left, terms))
+	}
+	return append(terms, expr)
+}
+
+// productFactors aplana una cadena de productos en la lista de sus factores
+func productFactors(expr Expr, factors []Expr) []Expr {
+	if e, ok := expr.(MulExpr); ok {
+		return productFactors(e.Right, productFactors(e.Left, factors))
+	}
+	return append(factors, expr)
+}
+
+// splitCoefficient separa un término optimizado en su coeficiente constante y el resto
+func splitCoefficient(term Expr) (uint64, Expr) {
+	if e, ok := term.(MulExpr); ok {
+		if c, ok := e.Left.(ConstExpr); ok {
+			return c.Value, e.Right
+		}
+	}
+	return 1, term
+}
+
+// withCoefficient construye c·term, omitiendo el coeficiente unidad
+func withCoefficient(c uint64, term Expr) Expr {
+	if c == 1 {
+		return term
+	}
+	return MulExpr{Left: ConstExpr{Value: c}, Right: term}
+}
+
+func optimizeExpr(expr Expr, t uint64) Expr {
+	switch e := expr.(type) {
+	case ConstExpr:
+		return ConstExpr{Value: e.Value % t}
+	case AddExpr:
+		return optimizeSum(sumTerms(e, nil), t)
+	case MulExpr:
+		return optimizeProduct(productFactors(e, nil), t)
+	case RotateExpr:
+		operand := optimizeExpr(e.Operand, t)
+		if isConstant(operand) {
+			return operand
+		}
+		return RotateExpr{Operand: operand, K: e.K}
+	}
+	return expr
+}
+
+// optimizeSum pliega las constantes, agrupa los términos semejantes e intenta sacar factor común
+func optimizeSum(terms []Expr, t uint64) Expr {
+	constant := uint64(0)
+	var keys []string
+	coefficients := make(map[string]uint64)
+	bodies := make(map[string]Expr)
+
+	for _, term := range terms {
+		for _, term := range sumTerms(optimizeExpr(term, t), nil) {
+			if c, ok := term.(ConstExpr); ok {
+				constant = (constant + c.Value) % t
+				continue
+			}
+
+			c, body := splitCoefficient(term)
+			key := body.String()
+			if _, ok := bodies[key]; !ok {
+				keys = append(keys, key)
+				bodies[key] = body
+			}
+			coefficients[key] = (coefficients[key] + c) % t
+		}
+	}
+
+	var reduced []Expr
+	for _, key := range keys {
+		if coefficients[key] != 0 {
+			reduced = append(reduced, withCoefficient(coefficients[key], bodies[key]))
+		}
+	}
+
+	result := buildSum(reduced, constant)
+	if candidate := factorCommon(reduced, constant, t); candidate != nil &&
+		exprDepth(candidate) <= exprDepth(result) && exprMultiplications(candidate) < exprMultiplications(result) {
+		return candidate
+	}
+
+	return result
+}
+
+// buildSum encadena los términos y añade la constante al final
+func buildSum(terms []Expr, constant uint64) Expr {
+	if len(terms) == 0 {
+		return ConstExpr{Value: constant}
+	}
+
+	result := terms[0]
+	for _, term := range terms[1:] {
+		result = AddExpr{Left: result, Right: term}
+	}
+	if constant != 0 {
+		result = AddExpr{Left: result, Right: ConstExpr{Value: constant}}
+	}
+
+	return result
+}
+
+// factorCommon saca el factor no constante que aparece en más términos de la suma.
+// Devuelve nil si ningún factor aparece en al menos dos términos.
+func factorCommon(terms []Expr, constant uint64, t uint64) Expr {
+	var keys []string
+	counts := make(map[string]int)
+	factors := make(map[string]Expr)
+
+	for _, term := range terms {
+		seen := make(map[string]bool)
+		for _, factor := range productFactors(term, nil) {
+			key := factor.String()
+			if isConstant(factor) || seen[key] {
+				continue
+			}
+			seen[key] = true
+			if _, ok := factors[key]; !ok {
+				keys = append(keys, key)
+				factors[key] = factor
+			}
+			counts[key]++
+		}
+	}
+
+	best := ""
+	for _, key := range keys {
+		if counts[key] >= 2 && (best == "" || counts[key] > counts[best]) {
+			best = key
+		}
+	}
+	if best == "" {
+		return nil
+	}
+
+	var inner, rest []Expr
+	for _, term := range terms {
+		termFactors := productFactors(term, nil)
+		i := slices.IndexFunc(termFactors, func(factor Expr) bool { return factor.String() == best })
+		if i < 0 {
+			rest = append(rest, term)
+			continue
+		}
+
+		remaining := slices.Delete(termFactors, i, i+1)
+		if len(remaining) == 0 {
+			inner = append(inner, ConstExpr{Value: 1})
+			continue
+		}
+		product := remaining[0]
+		for _, factor := range remaining[1:] {
+			product = MulExpr{Left: product, Right: factor}
+		}
+		inner = append(inner, product)
+	}
+
+	factored := MulExpr{Left: factors[best], Right: buildSum(inner, 0)}
+	return optimizeExpr(buildSum(append(rest, factored), constant), t)
+}
+
+// optimizeProduct pliega las constantes y combina los factores restantes emparejando siempre
+// los dos de menor profundidad, lo que minimiza la profundidad del producto
+func optimizeProduct(factors []Expr, t uint64) Expr {
+	constant := uint64(1)
+	var operands []Expr
+
+	for _, factor := range factors {
+		for _, factor := range productFactors(optimizeExpr(factor, t), nil) {
+			if c, ok := factor.(ConstExpr); ok {
+				constant = mulMod(constant, c.Value, t)
+				continue
+			}
+			operands = append(operands, factor)
+		}
+	}
+
+	if constant == 0 || len(operands) == 0 {
+		return ConstExpr{Value: constant}
+	}
+
+	for len(operands) > 1 {
+		slices.SortStableFunc(operands, func(a, b Expr) int {
+			return exprDepth(a) - exprDepth(b)
+		})
+		operands = append([]Expr{MulExpr{Left: operands[0], Right: operands[1]}}, operands[2:]...)
+	}
+
+	return withCoefficient(constant, operands[0])
+}
@@ -0,0 +1,387 @@
+// Copyright 2025 Juan Martín Pérez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package labeling
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/tuneinsight/lattigo/v6/core/rlwe"
+	"github.com/tuneinsight/lattigo/v6/schemes/bgv"
+	"github.com/tuneinsight/lattigo/v6/utils"
+)
+
+// ParametersLiteral agrupa los valores necesarios para instanciar unos Parameters
+type ParametersLiteral struct {
+	LogN             int
+	LogQ             []int
+	LogP             []int
+	PlaintextModulus uint64
+}
+
+// Conjuntos de parámetros predefinidos.
+// El nombre indica el grado del anillo (PN = log2(N)) y la cota del estándar de
+// cifrado homomórfico para log2(QP) en el nivel de seguridad indicado. Los primos
+// generados pueden quedar algo por encima de 2^LogQi, así que los tamaños suman un
+// bit menos que la cota cuando el módulo real la superaría.
+// Todos usan el módulo de texto plano 0x3ee0001, que es NTT-friendly hasta LogN = 16.
+// La profundidad multiplicativa disponible es len(LogQ) - 1.
+var (
+	// ParamsPN13QP218 ofrece 128 bits de seguridad y profundidad 2
+	ParamsPN13QP218 = ParametersLiteral{
+		LogN:             13,
+		LogQ:             []int{58, 55, 50},
+		LogP:             []int{55},
+		PlaintextModulus: 0x3ee0001,
+	}
+
+	// ParamsPN14QP438 ofrece 128 bits de seguridad y profundidad 5
+	ParamsPN14QP438 = ParametersLiteral{
+		LogN:             14,
+		LogQ:             []int{56, 55, 55, 54, 54, 54},
+		LogP:             []int{55, 55},
+		PlaintextModulus: 0x3ee0001,
+	}
+
+	// ParamsPN15QP880 ofrece 128 bits de seguridad y profundidad 12
+	ParamsPN15QP880 = ParametersLiteral{
+		LogN:             15,
+		LogQ:             []int{60, 55, 55, 55, 55, 55, 55, 55, 55, 55, 55, 55, 55},
+		LogP:             []int{55, 55, 50},
+		PlaintextModulus: 0x3ee0001,
+	}
+
+	// ParamsPN14QP305 ofrece 192 bits de seguridad y profundidad 3
+	ParamsPN14QP305 = ParametersLiteral{
+		LogN:             14,
+		LogQ:             []int{55, 50, 50, 50},
+		LogP:             []int{50, 49},
+		PlaintextModulus: 0x3ee0001,
+	}
+
+	// ParamsPN15QP611 ofrece 192 bits de seguridad y profundidad 8
+	ParamsPN15QP611 = ParametersLiteral{
+		LogN:             15,
+		LogQ:             []int{60, 55, 55, 55, 55, 55, 55, 55, 55},
+		LogP:             []int{55, 55},
+		PlaintextModulus: 0x3ee0001,
+	}
+
+	// ParamsPN14QP237 ofrece 256 bits de seguridad y profundidad 2
+	ParamsPN14QP237 = ParametersLiteral{
+		LogN:             14,
+		LogQ:             []int{55, 50, 50},
+		LogP:             []int{41, 41},
+		PlaintextModulus: 0x3ee0001,
+	}
+
+	// ParamsPN15QP476 ofrece 256 bits de seguridad y profundidad 6
+	ParamsPN15QP476 = ParametersLiteral{
+		LogN:             15,
+		LogQ:             []int{55, 50, 50, 50, 50, 50, 50},
+		LogP:             []int{60, 60},
+		PlaintextModulus: 0x3ee0001,
+	}
+)
+
+// InsecureTestParameters devuelve parámetros con un anillo pequeño (LogN = 10) y profundidad 3
+// para que las pruebas unitarias se ejecuten en milisegundos.
+// NO ofrecen ninguna seguridad y no deben usarse fuera de las pruebas.
+func InsecureTestParameters() (Parameters, error) {
+	return NewParametersFromLiteral(10, []int{56, 55, 55, 54}, []int{55}, 0x3ee0001, AllowInsecure())
+}
+
+// Scheme identifica el esquema homomórfico sobre el que se construye el labeling
+type Scheme int
+
+const (
+	// SchemeBGV usa BGV, con cambio de módulo tras cada multiplicación
+	SchemeBGV Scheme = iota
+	// SchemeBFV usa BFV, con multiplicación invariante a escala
+	SchemeBFV
+)
+
+// String devuelve el nombre del esquema
+func (s Scheme) String() string {
+	switch s {
+	case SchemeBGV:
+		return "BGV"
+	case SchemeBFV:
+		return "BFV"
+	default:
+		return fmt.Sprintf("Scheme(%d)", int(s))
+	}
+}
+
+// MarshalText serializa el esquema por su nombre
+func (s Scheme) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText reconstruye el esquema a partir de su nombre
+func (s *Scheme) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "BGV":
+		*s = SchemeBGV
+	case "BFV":
+		*s = SchemeBFV
+	default:
+		return fmt.Errorf("labeling: esquema desconocido %q", text)
+	}
+	return nil
+}
+
+// Scheme devuelve el esquema con el que se construyeron los parámetros
+func (p Parameters) Scheme() Scheme {
+	return p.scheme
+}
+
+// mulRelin multiplica dos ciphertexts y relineariza el resultado con la multiplicación propia del esquema.
+// El evaluador de BFV es el de BGV con la multiplicación entre ciphertexts invariante a escala.
+func (p Parameters) mulRelin(evaluator *bgv.Evaluator, op0, op1, opOut *rlwe.Ciphertext) error {
+	if p.scheme == SchemeBFV {
+		return evaluator.MulRelinScaleInvariant(op0, op1, opOut)
+	}
+	return evaluator.MulRelin(op0, op1, opOut)
+}
+
+// ParametersOption modifica el comportamiento de los constructores de Parameters
+type ParametersOption func(*parametersOptions)
+
+type parametersOptions struct {
+	allowInsecure bool
+	minSecurity   int
+	scheme        Scheme
+}
+
+func newParametersOptions(opts []ParametersOption) parametersOptions {
+	options := parametersOptions{minSecurity: SecurityLevel128}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return options
+}
+
+// AllowInsecure permite construir parámetros que no alcanzan el nivel de seguridad mínimo.
+// Solo debe usarse para pruebas.
+func AllowInsecure() ParametersOption {
+	return func(o *parametersOptions) {
+		o.allowInsecure = true
+	}
+}
+
+// WithScheme selecciona el esquema subyacente (BGV por defecto)
+func WithScheme(scheme Scheme) ParametersOption {
+	return func(o *parametersOptions) {
+		o.scheme = scheme
+	}
+}
+
+// WithMinSecurity fija el nivel de seguridad mínimo (en bits) exigido al construir los parámetros
+func WithMinSecurity(bits int) ParametersOption {
+	return func(o *parametersOptions) {
+		o.minSecurity = bits
+	}
+}
+
+// NewParametersFromPreset instancia unos Parameters a partir de un ParametersLiteral,
+// típicamente uno de los conjuntos predefinidos
+func NewParametersFromPreset(literal ParametersLiteral, opts ...ParametersOption) (Parameters, error) {
+	return NewParametersFromLiteral(literal.LogN, literal.LogQ, literal.LogP, literal.PlaintextModulus, opts...)
+}
+
+// maxPlaintextBits es el mayor tamaño de t que admite SuggestParameters: cada nivel de la cadena
+// necesita log2(t) + 28 bits y los primos de lattigo tienen como mucho 60
+const maxPlaintextBits = 32
+
+// SuggestParameters busca la combinación de LogN, LogQ y LogP más pequeña que soporta depth
+// multiplicaciones etiquetadas (o re-cifrados) consecutivos sobre un espacio de texto plano
+// de plaintextBits bits, como mucho 32, respetando el nivel de seguridad indicado. Si los
+// parámetros de un LogN no se pueden construir, prueba con el siguiente.
+func SuggestParameters(depth int, plaintextBits int, security int) (Parameters, error) {
+	if depth < 0 {
+		return Parameters{}, fmt.Errorf("labeling: profundidad inválida %d", depth)
+	}
+	if plaintextBits <= 0 || plaintextBits > maxPlaintextBits {
+		return Parameters{}, fmt.Errorf("labeling: texto plano de %d bits, se admiten entre 1 y %d", plaintextBits, maxPlaintextBits)
+	}
+
+	// Cada nivel de la cadena debe absorber el crecimiento del ruido de una multiplicación,
+	// que es aproximadamente log2(t) más un margen fijo
+	levelBits := utils.Min(plaintextBits+28, 60)
+	firstBits := utils.Min(levelBits+2, 60)
+	specialBits := utils.Min(levelBits+1, 60)
+
+	for logN := 12; logN <= 16; logN++ {
+		bound := MaxLogQP(logN, security)
+		if bound == 0 {
+			continue
+		}
+
+		// Con una sola prima especial la descomposición es por primas de Q,
+		// pasamos a dos cuando la cadena es larga para reducir el ruido del key switching
+		logQ := make([]int, depth+1)
+		logQ[0] = firstBits
+		for i := 1; i <= depth; i++ {
+			logQ[i] = levelBits
+		}
+		logP := []int{specialBits}
+		if depth >= 4 {
+			logP = append(logP, specialBits)
+		}
+
+		total := 0
+		for _, b := range append(logQ, logP...) {
+			total += b
+		}
+		if total > bound {
+			continue
+		}
+
+		t, err := GeneratePlaintextModulus(plaintextBits, logN)
+		if err != nil {
+			continue
+		}
+
+		params, err := NewParametersFromLiteral(logN, logQ, logP, t, WithMinSecurity(security))
+		if err != nil {
+			continue
+		}
+
+		return params, nil
+	}
+
+	return Parameters{}, fmt.Errorf("labeling: no existen parámetros para profundidad %d, %d bits de texto plano y %d bits de seguridad", depth, plaintextBits, security)
+}
+
+// GeneratePlaintextModulus devuelve el mayor primo t de bits bits tal que t ≡ 1 mod 2N.
+// Estos módulos permiten el empaquetado en N slots (NTT-friendly) y sustituyen a constantes
+// fijas como 0x3ee0001 cuando el rango de los datos requiere otro tamaño de texto plano.
+func GeneratePlaintextModulus(bits int, logN int) (uint64, error) {
+	moduli, err := GeneratePlaintextModuli(1, bits, logN)
+	if err != nil {
+		return 0, err
+	}
+	return moduli[0], nil
+}
+
+// GeneratePlaintextModuli devuelve los count mayores primos distintos de bits bits tales que t ≡ 1 mod 2N,
+// en orden decreciente. Al ser primos distintos son coprimos dos a dos, como requiere el modo CRT.
+func GeneratePlaintextModuli(count int, bits int, logN int) ([]uint64, error) {
+	if bits > 61 || bits <= logN+1 {
+		return nil, fmt.Errorf("labeling: no existe un primo de %d bits congruente con 1 mod 2^%d", bits, logN+1)
+	}
+
+	moduli := make([]uint64, 0, count)
+	step := uint64(1) << (logN + 1)
+	lower := uint64(1) << (bits - 1)
+	for k := ((uint64(1) << bits) - 1) / step; k*step+1 >= lower && len(moduli) < count; k-- {
+		t := k*step + 1
+		if new(big.Int).SetUint64(t).ProbablyPrime(0) {
+			moduli = append(moduli, t)
+		}
+	}
+
+	if len(moduli) < count {
+		return nil, fmt.Errorf("labeling: solo existen %d primos de %d bits congruentes con 1 mod 2^%d", len(moduli), bits, logN+1)
+	}
+
+	return moduli, nil
+}
+
+// MarshalBinary serializa los parámetros en binario: un byte con el esquema seguido de los parámetros BGV
+func (p Parameters) MarshalBinary() ([]byte, error) {
+	data, err := p.Parameters.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte{byte(p.scheme)}, data...), nil
+}
+
+// UnmarshalBinary reconstruye los parámetros a partir de su serialización binaria
+func (p *Parameters) UnmarshalBinary(data []byte) error {
+	if len(data) == 0 {
+		return fmt.Errorf("labeling: serialización de parámetros vacía")
+	}
+
+	scheme := Scheme(data[0])
+	if scheme != SchemeBGV && scheme != SchemeBFV {
+		return fmt.Errorf("labeling: esquema desconocido %d", data[0])
+	}
+
+	if err := p.Parameters.UnmarshalBinary(data[1:]); err != nil {
+		return err
+	}
+	p.scheme = scheme
+
+	return nil
+}
+
+// parametersJSON es la representación JSON de Parameters
+type parametersJSON struct {
+	Scheme     Scheme         `json:"scheme"`
+	Parameters bgv.Parameters `json:"parameters"`
+}
+
+// MarshalJSON serializa los parámetros en JSON
+func (p Parameters) MarshalJSON() ([]byte, error) {
+	return json.Marshal(parametersJSON{Scheme: p.scheme, Parameters: p.Parameters})
+}
+
+// UnmarshalJSON reconstruye los parámetros a partir de su serialización JSON
+func (p *Parameters) UnmarshalJSON(data []byte) error {
+	var aux parametersJSON
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	p.Parameters = aux.Parameters
+	p.scheme = aux.Scheme
+
+	return nil
+}
+
+// Equal indica si dos conjuntos de parámetros son idénticos, comparando sus serializaciones
+func (p Parameters) Equal(other *Parameters) bool {
+	if other == nil {
+		return false
+	}
+
+	data, err := p.MarshalBinary()
+	if err != nil {
+		return false
+	}
+
+	otherData, err := other.MarshalBinary()
+	if err != nil {
+		return false
+	}
+
+	return bytes.Equal(data, otherData)
+}
+
+// Hash devuelve un resumen SHA-256 estable de los parámetros, útil para que cliente y servidor
+// comprueben que trabajan con el mismo esquema sin intercambiar la serialización completa
+func (p Parameters) Hash() ([sha256.Size]byte, error) {
+	data, err := p.MarshalBinary()
+	if err != nil {
+		return [sha256.Size]byte{}, err
+	}
+
+	return sha256.Sum256(data), nil
+}
@@ -0,0 +1,221 @@
+// Copyright 2025 Juan Martín Pérez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package labeling
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ErrSyntax se devuelve cuando una expresión textual no es válida
+var ErrSyntax = errors.New("labeling: error de sintaxis")
+
+const (
+	// MaxExprLength es la longitud máxima en runas de una expresión que acepta ParseExpr
+	MaxExprLength = 64 << 10
+	// MaxExprDepth es el anidamiento máximo de paréntesis y de rot que acepta ParseExpr. El
+	// analizador es recursivo, y sin este límite una expresión con millones de paréntesis agota la
+	// pila del proceso.
+	MaxExprDepth = 64
+)
+
+// exprParser es un analizador descendente recursivo para la gramática
+//
+//	expr   := term ('+' term)*
+//	term   := factor ('*' factor)*
+//	factor := número | identificador | 'rot' '(' expr ',' entero ')' | '(' expr ')'
+type exprParser struct {
+	source []rune
+	pos    int
+	depth  int
+}
+
+// ParseExpr compila una expresión textual como "x*y + 3*z" en un árbol que puede evaluarse con EvalExpr.
+// Los identificadores son los nombres de las entradas y los números son constantes sin signo.
+// rot(e, k) rota k posiciones las columnas de e; k puede ser negativo.
+// Las expresiones de más de MaxExprLength runas o con más de MaxExprDepth niveles de anidamiento
+// se rechazan con ErrSyntax.
+func ParseExpr(source string) (Expr, error) {
+	if n := utf8.RuneCountInString(source); n > MaxExprLength {
+		return nil, fmt.Errorf("%w: la expresión tiene %d símbolos, máximo %d", ErrSyntax, n, MaxExprLength)
+	}
+	parser := exprParser{source: []rune(source)}
+
+	expr, err := parser.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+
+	if parser.skipSpaces(); parser.pos < len(parser.source) {
+		return nil, parser.errorf("símbolo inesperado %q", parser.source[parser.pos])
+	}
+
+	return expr, nil
+}
+
+func (p *exprParser) errorf(format string, args ...any) error {
+	return fmt.Errorf("%w en la posición %d: %s", ErrSyntax, p.pos, fmt.Sprintf(format, args...))
+}
+
+func (p *exprParser) skipSpaces() {
+	for p.pos < len(p.source) && unicode.IsSpace(p.source[p.pos]) {
+		p.pos++
+	}
+}
+
+// enter entra en un nivel de anidamiento y falla si supera MaxExprDepth; leave sale de él
+func (p *exprParser) enter() error {
+	if p.depth++; p.depth > MaxExprDepth {
+		return p.errorf("más de %d niveles de anidamiento", MaxExprDepth)
+	}
+	return nil
+}
+
+func (p *exprParser) leave() {
+	p.depth--
+}
+
+// accept consume el operador op si es el siguiente símbolo
+func (p *exprParser) accept(op rune) bool {
+	p.skipSpaces()
+	if p.pos < len(p.source) && p.source[p.pos] == op {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *exprParser) parseExpr() (Expr, error) {
+	expr, err := p.parseTerm()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.accept('+') {
+		right, err := p.parseTerm()
+		if err != nil {
+			return nil, err
+		}
+		expr = AddExpr{Left: expr, Right: right}
+	}
+
+	return expr, nil
+}
+
+func (p *exprParser) parseTerm() (Expr, error) {
+	expr, err := p.parseFactor()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.accept('*') {
+		right, err := p.parseFactor()
+		if err != nil {
+			return nil, err
+		}
+		expr = MulExpr{Left: expr, Right: right}
+	}
+
+	return expr, nil
+}
+
+func (p *exprParser) parseFactor() (Expr, error) {
+	p.skipSpaces()
+	if p.pos >= len(p.source) {
+		return nil, p.errorf("fin inesperado de la expresión")
+	}
+
+	start := p.pos
+	switch r := p.source[p.pos]; {
+	case r == '(':
+		p.pos++
+		if err := p.enter(); err != nil {
+			return nil, err
+		}
+		defer p.leave()
+		expr, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if !p.accept(')') {
+			return nil, p.errorf("falta ')'")
+		}
+		return expr, nil
+
+	case unicode.IsDigit(r):
+		for p.pos < len(p.source) && unicode.IsDigit(p.source[p.pos]) {
+			p.pos++
+		}
+		literal := string(p.source[start:p.pos])
+		value, err := strconv.ParseUint(literal, 10, 64)
+		if err != nil {
+			p.pos = start
+			return nil, p.errorf("constante %q fuera de rango", literal)
+		}
+		return ConstExpr{Value: value}, nil
+
+	case unicode.IsLetter(r) || r == '_':
+		for p.pos < len(p.source) && (unicode.IsLetter(p.source[p.pos]) || unicode.IsDigit(p.source[p.pos]) || p.source[p.pos] == '_') {
+			p.pos++
+		}
+		name := string(p.source[start:p.pos])
+		if name == "rot" && p.accept('(') {
+			return p.parseRotation()
+		}
+		return VarExpr{Name: name}, nil
+	}
+
+	return nil, p.errorf("símbolo inesperado %q", p.source[p.pos])
+}
+
+// parseRotation analiza los argumentos de rot tras el paréntesis de apertura
+func (p *exprParser) parseRotation() (Expr, error) {
+	if err := p.enter(); err != nil {
+		return nil, err
+	}
+	defer p.leave()
+
+	operand, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	if !p.accept(',') {
+		return nil, p.errorf("falta ',' en rot")
+	}
+
+	p.skipSpaces()
+	start := p.pos
+	if p.pos < len(p.source) && p.source[p.pos] == '-' {
+		p.pos++
+	}
+	for p.pos < len(p.source) && unicode.IsDigit(p.source[p.pos]) {
+		p.pos++
+	}
+	literal := string(p.source[start:p.pos])
+	k, err := strconv.Atoi(literal)
+	if err != nil {
+		p.pos = start
+		return nil, p.errorf("desplazamiento %q no válido en rot", literal)
+	}
+
+	if !p.accept(')') {
+		return nil, p.errorf("falta ')'")
+	}
+
+	return RotateExpr{Operand: operand, K: k}, nil
+}
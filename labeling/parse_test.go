@@ -0,0 +1,48 @@
+// Copyright 2025 Juan Martín Pérez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package labeling
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseExprLimits(t *testing.T) {
+	nested := func(depth int) string {
+		return strings.Repeat("(", depth) + "x" + strings.Repeat(")", depth)
+	}
+	rotations := func(depth int) string {
+		return strings.Repeat("rot(", depth) + "x" + strings.Repeat(", 1)", depth)
+	}
+
+	for _, source := range []string{nested(MaxExprDepth), rotations(MaxExprDepth)} {
+		if _, err := ParseExpr(source); err != nil {
+			t.Fatalf("anidamiento %d: %v", MaxExprDepth, err)
+		}
+	}
+
+	tooLong := "x" + strings.Repeat("+x", MaxExprLength/2)
+	for name, source := range map[string]string{
+		"paréntesis": nested(MaxExprDepth + 1),
+		"rotaciones": rotations(MaxExprDepth + 1),
+		"millones":   nested(1 << 22),
+		"longitud":   tooLong,
+	} {
+		if _, err := ParseExpr(source); !errors.Is(err, ErrSyntax) {
+			t.Fatalf("%s: se esperaba ErrSyntax, se obtuvo %v", name, err)
+		}
+	}
+}
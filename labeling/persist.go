@@ -0,0 +1,333 @@
+// Copyright 2025 Juan Martín Pérez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Persistencia de claves cifradas en reposo.
+//
+// PersistentKeyStore guarda cada clave serializada en un almacén clave-valor genérico (KV). Las
+// claves secretas se cifran con AES-256-GCM bajo una clave derivada de una frase de paso con
+// Argon2id y una sal aleatoria por registro; el identificador y el tipo de la clave se autentican
+// como datos adicionales, de modo que un registro no puede moverse a otro identificador sin que
+// se detecte. El resto de claves son públicas y se guardan en claro. Cada registro tiene la forma:
+//
+//	versión (1 byte) | tipo (1 byte) | cifrado (1 byte) | contenido
+//
+// donde cifrado vale 0 en claro, 1 con frase de paso y 2 con un KMS (véase kms.go). Con frase de
+// paso el contenido es sal (16 bytes) | nonce (12 bytes) | texto cifrado.
+
+package labeling
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/tuneinsight/lattigo/v6/core/rlwe"
+	"golang.org/x/crypto/argon2"
+)
+
+// Parámetros de Argon2id, los recomendados por el RFC 9106 con memoria limitada
+const (
+	argon2Time    = 3
+	argon2Memory  = 64 * 1024
+	argon2Threads = 4
+	argon2KeyLen  = 32
+	argon2SaltLen = 16
+)
+
+// persistVersion es la versión del formato de los registros
+const persistVersion = 1
+
+// ErrWrongPassphrase se devuelve cuando un registro cifrado no se puede descifrar, bien porque la
+// frase de paso no es la correcta o porque el registro ha sido alterado
+var ErrWrongPassphrase = errors.New("labeling: frase de paso incorrecta o registro alterado")
+
+// KV es un almacén clave-valor genérico sobre el que se construye un PersistentKeyStore
+type KV interface {
+	// Get devuelve el valor con el nombre name, o ErrKeyNotFound
+	Get(name string) ([]byte, error)
+	// Put guarda el valor con el nombre name, sustituyendo el anterior si existía
+	Put(name string, value []byte) error
+	// Delete elimina el valor con el nombre name, o devuelve ErrKeyNotFound
+	Delete(name string) error
+}
+
+// FileKV es un KV en un directorio del sistema de ficheros, con un fichero por valor
+type FileKV struct {
+	dir string
+}
+
+// NewFileKV crea un KV en el directorio dir, creándolo si no existe con permisos solo para el
+// propietario
+func NewFileKV(dir string) (*FileKV, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	return &FileKV{dir: dir}, nil
+}
+
+// path devuelve la ruta del fichero de name. El nombre se codifica en hexadecimal para que no
+// pueda contener separadores ni salir del directorio.
+func (kv *FileKV) path(name string) string {
+	return filepath.Join(kv.dir, hex.EncodeToString([]byte(name)))
+}
+
+// Get devuelve el contenido del fichero de name
+func (kv *FileKV) Get(name string) ([]byte, error) {
+	data, err := os.ReadFile(kv.path(name))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %q", ErrKeyNotFound, name)
+	}
+	return data, err
+}
+
+// Put escribe el fichero de name. La escritura es atómica: se escribe un fichero temporal y se
+// renombra, de modo que una interrupción nunca deja un registro a medias.
+func (kv *FileKV) Put(name string, value []byte) error {
+	tmp, err := os.CreateTemp(kv.dir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(value); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), kv.path(name))
+}
+
+// Delete elimina el fichero de name
+func (kv *FileKV) Delete(name string) error {
+	err := os.Remove(kv.path(name))
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("%w: %q", ErrKeyNotFound, name)
+	}
+	return err
+}
+
+// recordSealer cifra y descifra el contenido de los registros de claves secretas
+type recordSealer interface {
+	// mode identifica el mecanismo en el byte de cifrado del registro
+	mode() byte
+	seal(aad, content []byte) ([]byte, error)
+	open(aad, sealed []byte) ([]byte, error)
+}
+
+// PersistentKeyStore es un KeyStore sobre un KV que cifra las claves secretas en reposo
+type PersistentKeyStore struct {
+	kv     KV
+	sealer recordSealer
+}
+
+// NewPersistentKeyStore crea un KeyStore sobre kv. passphrase protege las claves secretas y debe
+// ser la misma en todas las aperturas del almacén.
+func NewPersistentKeyStore(kv KV, passphrase []byte) (*PersistentKeyStore, error) {
+	if len(passphrase) == 0 {
+		return nil, fmt.Errorf("labeling: PersistentKeyStore requiere una frase de paso")
+	}
+	return &PersistentKeyStore{kv: kv, sealer: passphraseSealer(append([]byte{}, passphrase...))}, nil
+}
+
+// NewFileKeyStore crea un KeyStore persistente en el directorio dir
+func NewFileKeyStore(dir string, passphrase []byte) (*PersistentKeyStore, error) {
+	kv, err := NewFileKV(dir)
+	if err != nil {
+		return nil, err
+	}
+	return NewPersistentKeyStore(kv, passphrase)
+}
+
+// keyTagOf devuelve el tipo de registro de una clave
+func keyTagOf(key any) (keyTag, error) {
+	switch key.(type) {
+	case *rlwe.SecretKey:
+		return keyTagSecretKey, nil
+	case *rlwe.PublicKey:
+		return keyTagPublicKey, nil
+	case *rlwe.RelinearizationKey:
+		return keyTagRelinearizationKey, nil
+	case *rlwe.GaloisKey:
+		return keyTagGaloisKey, nil
+	case *rlwe.EvaluationKey:
+		return keyTagEvaluationKey, nil
+	case *rlwe.MemEvaluationKeySet:
+		return keyTagEvaluationKeySet, nil
+	}
+	return 0, fmt.Errorf("labeling: tipo de clave %T no soportado", key)
+}
+
+// newKeyOfTag reserva una clave vacía del tipo de registro tag
+func newKeyOfTag(tag keyTag) (encoding.BinaryUnmarshaler, error) {
+	switch tag {
+	case keyTagSecretKey:
+		return new(rlwe.SecretKey), nil
+	case keyTagPublicKey:
+		return new(rlwe.PublicKey), nil
+	case keyTagRelinearizationKey:
+		return new(rlwe.RelinearizationKey), nil
+	case keyTagGaloisKey:
+		return new(rlwe.GaloisKey), nil
+	case keyTagEvaluationKey:
+		return new(rlwe.EvaluationKey), nil
+	case keyTagEvaluationKeySet:
+		return new(rlwe.MemEvaluationKeySet), nil
+	}
+	return nil, fmt.Errorf("labeling: tipo de registro %d desconocido", tag)
+}
+
+// recordAAD son los datos adicionales autenticados de un registro cifrado
+func recordAAD(id KeyID, tag keyTag) []byte {
+	return append([]byte{persistVersion, byte(tag)}, id...)
+}
+
+// passphraseSealer cifra los registros con una clave derivada de una frase de paso
+type passphraseSealer []byte
+
+func (p passphraseSealer) mode() byte { return 1 }
+
+// newCipher deriva la clave AES de la frase de paso y la sal
+func (p passphraseSealer) newCipher(salt []byte) (cipher.AEAD, error) {
+	key := argon2.IDKey(p, salt, argon2Time, argon2Memory, argon2Threads, argon2KeyLen)
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal cifra el contenido de un registro con una sal y un nonce nuevos
+func (p passphraseSealer) seal(aad, content []byte) ([]byte, error) {
+	salt := make([]byte, argon2SaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	aead, err := p.newCipher(salt)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	sealed := append(salt, nonce...)
+	return aead.Seal(sealed, nonce, content, aad), nil
+}
+
+// open descifra el contenido de un registro
+func (p passphraseSealer) open(aad, sealed []byte) ([]byte, error) {
+	if len(sealed) < argon2SaltLen {
+		return nil, ErrWrongPassphrase
+	}
+
+	aead, err := p.newCipher(sealed[:argon2SaltLen])
+	if err != nil {
+		return nil, err
+	}
+
+	sealed = sealed[argon2SaltLen:]
+	if len(sealed) < aead.NonceSize() {
+		return nil, ErrWrongPassphrase
+	}
+
+	content, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], aad)
+	if err != nil {
+		return nil, ErrWrongPassphrase
+	}
+	return content, nil
+}
+
+// Get lee y, si es una clave secreta, descifra la clave con el identificador id
+func (s *PersistentKeyStore) Get(id KeyID) (any, error) {
+	record, err := s.kv.Get(string(id))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(record) < 3 {
+		return nil, fmt.Errorf("labeling: registro de la clave %q truncado", id)
+	}
+	if record[0] != persistVersion {
+		return nil, fmt.Errorf("labeling: versión de registro %d no soportada", record[0])
+	}
+
+	tag := keyTag(record[1])
+	content := record[3:]
+	if mode := record[2]; mode != 0 {
+		if mode != s.sealer.mode() {
+			return nil, fmt.Errorf("labeling: la clave %q está cifrada con otro mecanismo (%d)", id, mode)
+		}
+		if content, err = s.sealer.open(recordAAD(id, tag), content); err != nil {
+			return nil, err
+		}
+	} else if tag == keyTagSecretKey {
+		// Una clave secreta en claro solo puede deberse a un registro manipulado
+		return nil, fmt.Errorf("labeling: la clave secreta %q no está cifrada", id)
+	}
+
+	key, err := newKeyOfTag(tag)
+	if err != nil {
+		return nil, err
+	}
+	if err := key.UnmarshalBinary(content); err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
+
+// Put serializa la clave y la guarda con el identificador id, cifrándola si es secreta
+func (s *PersistentKeyStore) Put(id KeyID, key any) error {
+	tag, err := keyTagOf(key)
+	if err != nil {
+		return err
+	}
+
+	content, err := key.(encoding.BinaryMarshaler).MarshalBinary()
+	if err != nil {
+		return err
+	}
+
+	var mode byte
+	if tag == keyTagSecretKey {
+		if content, err = s.sealer.seal(recordAAD(id, tag), content); err != nil {
+			return err
+		}
+		mode = s.sealer.mode()
+	}
+
+	return s.kv.Put(string(id), append([]byte{persistVersion, byte(tag), mode}, content...))
+}
+
+// Delete elimina la clave con el identificador id
+func (s *PersistentKeyStore) Delete(id KeyID) error {
+	return s.kv.Delete(string(id))
+}
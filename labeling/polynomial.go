@@ -0,0 +1,266 @@
+// Copyright 2025 Juan Martín Pérez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Evaluación de polinomios con coeficientes públicos.
+//
+// La regla de Horner encadena d − 1 multiplicaciones para un polinomio de grado d, así que la
+// profundidad crece linealmente con el grado. EvalPolynomial usa pasos pequeños y grandes
+// (baby-step giant-step, como Paterson–Stockmeyer): calcula x, x², …, x^(k−1) con un árbol de
+// profundidad logarítmica y las potencias x^k, x^2k, x^4k, … por cuadrados, y divide el polinomio
+// recursivamente como p = p_bajo + x^(k·2^i)·p_alto hasta que cada trozo es una combinación lineal
+// de las potencias pequeñas, que no consume profundidad. Con k ≈ √d la profundidad es ⌈log2 d⌉ y
+// el número de multiplicaciones entre cifrados, del orden de 2√d. La profundidad se cuenta como en
+// Power y MultMany: multiplicaciones encadenadas frente al presupuesto de WithDepthBudget.
+
+package labeling
+
+import (
+	"fmt"
+	"math/bits"
+
+	"github.com/tuneinsight/lattigo/v6/core/rlwe"
+)
+
+// polynomialDepth devuelve la profundidad multiplicativa de EvalPolynomial para un polinomio de
+// grado degree: ⌈log2 degree⌉, 0 para grado 0 o 1
+func polynomialDepth(degree int) int {
+	if degree <= 1 {
+		return 0
+	}
+	return bits.Len(uint(degree - 1))
+}
+
+// maxPolynomialDegree devuelve el mayor grado que EvalPolynomial evalúa con profundidad depth,
+// o −1 si depth es negativa
+func maxPolynomialDegree(depth int) int {
+	if depth < 0 {
+		return -1
+	}
+	if depth >= bits.UintSize-2 {
+		return int(^uint(0) >> 1)
+	}
+	return 1 << depth
+}
+
+// polynomialEvaluator guarda las potencias de x que comparten los trozos del polinomio
+type polynomialEvaluator struct {
+	params Parameters
+	key    rlwe.EncryptionKey
+	evk    *rlwe.MemEvaluationKeySet
+	opts   []MultiplyOption
+	// baby son x^0 (sin usar), x, x², …, x^(k−1)
+	baby []PlaintextLabeledciphertext
+	// giant[i] es x^(k·2^i), calculada al pedirla por primera vez
+	giant []PlaintextLabeledciphertext
+}
+
+// EvalPolynomial evalúa p(x) = Σ coeffs[i]·x^i con pasos pequeños y grandes (ver la cabecera del
+// fichero). Un polinomio de grado d requiere profundidad ⌈log2 d⌉; las multiplicaciones cuyo
+// resultado solo se suma pasan por Multiply y pueden quedar en forma overflow.
+func EvalPolynomial(params Parameters, labeledciphertext PlaintextLabeledciphertext, coeffs []uint64, key rlwe.EncryptionKey, evk *rlwe.MemEvaluationKeySet, opts ...MultiplyOption) (Operand, error) {
+	t := params.PlaintextModulus()
+
+	// Descartamos los coeficientes principales nulos
+	degree := len(coeffs) - 1
+	for degree > 0 && coeffs[degree]%t == 0 {
+		degree--
+	}
+
+	if degree < 0 {
+		return nil, fmt.Errorf("labeling: EvalPolynomial requiere al menos un coeficiente")
+	}
+	if degree == 0 {
+		return Encrypt(params, key, broadcast(params, coeffs[0]%t))
+	}
+
+	options := multiplyOptions{depth: params.MaxLevel()}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if required := labeledciphertext.Multiplications() + polynomialDepth(degree); required > options.depth {
+		return nil, fmt.Errorf("labeling: EvalPolynomial de grado %d requiere profundidad %d y el presupuesto es %d", degree, required, options.depth)
+	}
+
+	reduced := make([]uint64, degree+1)
+	for i := range reduced {
+		reduced[i] = coeffs[i] % t
+	}
+
+	// k = 2^b potencias pequeñas y 2^m trozos, con b + m = ⌈log2(d + 1)⌉
+	logDegree := bits.Len(uint(degree))
+	b := max(1, (logDegree+1)/2)
+	m := max(0, logDegree-b)
+
+	e := &polynomialEvaluator{params: params, key: key, evk: evk, opts: opts}
+	if err := e.babySteps(labeledciphertext, 1<<b); err != nil {
+		return nil, err
+	}
+
+	result, constant, err := e.eval(reduced, m, true)
+	if err != nil {
+		return nil, err
+	}
+	if result == nil {
+		return Encrypt(params, key, broadcast(params, constant))
+	}
+	return addConstOperand(params, result, constant)
+}
+
+// babySteps calcula x^j para j < k con profundidad ⌈log2 j⌉
+func (e *polynomialEvaluator) babySteps(x PlaintextLabeledciphertext, k int) error {
+	e.baby = make([]PlaintextLabeledciphertext, k)
+	e.baby[1] = x
+
+	// x^j = x^(2^⌊log2 j⌋)·x^(j − 2^⌊log2 j⌋)
+	for j := 2; j < k; j++ {
+		high := 1 << (bits.Len(uint(j)) - 1)
+		low := j - high
+		if low == 0 {
+			low = high / 2
+			high /= 2
+		}
+
+		var err error
+		if e.baby[j], err = Mult(e.params, e.baby[high], e.baby[low], e.key, e.evk); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// giantStep devuelve x^(k·2^i) por cuadrados, calculándola solo la primera vez que se pide. Con
+// i = −1 devuelve x^(k/2).
+func (e *polynomialEvaluator) giantStep(i int) (PlaintextLabeledciphertext, error) {
+	if i < 0 {
+		return e.baby[len(e.baby)/2], nil
+	}
+	if i < len(e.giant) {
+		return e.giant[i], nil
+	}
+
+	factor, err := e.giantStep(i - 1)
+	if err != nil {
+		return PlaintextLabeledciphertext{}, err
+	}
+	square, err := Mult(e.params, factor, factor, e.key, e.evk)
+	if err != nil {
+		return PlaintextLabeledciphertext{}, err
+	}
+	e.giant = append(e.giant, square)
+	return square, nil
+}
+
+// eval evalúa el trozo Σ coeffs[i]·x^i, de menos de k·2^m coeficientes. Devuelve el término
+// cifrado y el término constante por separado, con result nil si el trozo es constante. Si final es
+// falso el resultado se multiplicará de nuevo y debe quedar en forma plaintext.
+func (e *polynomialEvaluator) eval(coeffs []uint64, m int, final bool) (Operand, uint64, error) {
+	if m == 0 || len(coeffs) <= len(e.baby) {
+		return e.linear(coeffs)
+	}
+
+	// p = p_bajo + x^(k·2^(m−1))·p_alto
+	split := len(e.baby) << (m - 1)
+	if len(coeffs) <= split {
+		return e.eval(coeffs, m-1, final)
+	}
+
+	low, lowConstant, err := e.eval(coeffs[:split], m-1, final)
+	if err != nil {
+		return nil, 0, err
+	}
+	high, highConstant, err := e.eval(coeffs[split:], m-1, false)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Un trozo alto constante solo escala la potencia grande, sin multiplicar cifrados. En el último
+	// producto se escala su raíz y se multiplica por ella con Multiply: x^(k·2^(m−1)) puede tener ya
+	// toda la profundidad y la forma plaintext no la admite.
+	var product Operand
+	switch {
+	case high == nil && !final:
+		giant, err := e.giantStep(m - 1)
+		if err != nil {
+			return nil, 0, err
+		}
+		if product, err = MulScalar(e.params, giant, highConstant); err != nil {
+			return nil, 0, err
+		}
+	case high == nil:
+		root, err := e.giantStep(m - 2)
+		if err != nil {
+			return nil, 0, err
+		}
+		scaled, err := MulScalar(e.params, root, highConstant)
+		if err != nil {
+			return nil, 0, err
+		}
+		if product, err = Multiply(e.params, scaled, root, e.key, e.evk, e.opts...); err != nil {
+			return nil, 0, err
+		}
+	default:
+		giant, err := e.giantStep(m - 1)
+		if err != nil {
+			return nil, 0, err
+		}
+		if high, err = addConstOperand(e.params, high, highConstant); err != nil {
+			return nil, 0, err
+		}
+		if final {
+			product, err = Multiply(e.params, high, giant, e.key, e.evk, e.opts...)
+		} else {
+			product, err = Mult(e.params, high.(PlaintextLabeledciphertext), giant, e.key, e.evk)
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+
+	if low == nil {
+		return product, lowConstant, nil
+	}
+	result, err := Add(e.params, low, product)
+	return result, lowConstant, err
+}
+
+// linear evalúa Σ coeffs[i]·x^i como combinación lineal de las potencias pequeñas, sin consumir
+// profundidad. Devuelve result nil si todos los coeficientes salvo el constante son nulos.
+func (e *polynomialEvaluator) linear(coeffs []uint64) (Operand, uint64, error) {
+	var result PlaintextLabeledciphertext
+	found := false
+
+	for i := 1; i < len(coeffs); i++ {
+		if coeffs[i] == 0 {
+			continue
+		}
+
+		term, err := MulScalar(e.params, e.baby[i], coeffs[i])
+		if err != nil {
+			return nil, 0, err
+		}
+		if !found {
+			result, found = term, true
+			continue
+		}
+		if result, err = Sum(e.params.Parameters, result, term); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	if !found {
+		return nil, coeffs[0], nil
+	}
+	return result, coeffs[0], nil
+}
@@ -0,0 +1,147 @@
+// Copyright 2025 Juan Martín Pérez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Pruebas de la evaluación de polinomios y de las comparaciones que se apoyan en ella.
+
+package labeling
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestPolynomialDepth(t *testing.T) {
+	for degree, want := range []int{0, 0, 1, 2, 2, 3, 3, 3, 3, 4} {
+		if got := polynomialDepth(degree); got != want {
+			t.Fatalf("grado %d: se esperaba profundidad %d y se obtuvo %d", degree, want, got)
+		}
+		if degree > 0 && maxPolynomialDegree(want) < degree {
+			t.Fatalf("grado %d: maxPolynomialDegree(%d) = %d", degree, want, maxPolynomialDegree(want))
+		}
+	}
+}
+
+func TestEvalPolynomial(t *testing.T) {
+	params, err := InsecureTestParameters()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sk, pk := GenerateKeyPair(params)
+	evk := GenerateMemEvaluationKeySet(GenerateRelinearizationKey(params, sk))
+	tm := params.PlaintextModulus()
+
+	values := make([]uint64, params.MaxSlots())
+	for i := range values {
+		values[i] = uint64(i*7919) % tm
+	}
+	x, err := Encrypt(params, pk, values)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Con tres niveles la regla de Horner llegaba a grado 4; ahora se llega a 2^3 = 8
+	for degree := 1; degree <= maxPolynomialDegree(params.MaxLevel()); degree++ {
+		t.Run(fmt.Sprintf("grado %d", degree), func(t *testing.T) {
+			coeffs := make([]uint64, degree+1)
+			for i := range coeffs {
+				coeffs[i] = uint64(3*i+1) % tm
+			}
+			// Un coeficiente intermedio nulo deja un trozo sin términos cifrados
+			if degree > 4 {
+				coeffs[degree-1] = 0
+			}
+
+			result, err := EvalPolynomial(params, x, coeffs, pk, evk)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			want := make([]uint64, len(values))
+			for i, v := range values {
+				for j := degree; j >= 0; j-- {
+					want[i] = (mulMod(want[i], v, tm) + coeffs[j]) % tm
+				}
+			}
+			got, err := decryptOperand(params, sk, result)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkValues(t, got, want)
+		})
+	}
+
+	if _, err := EvalPolynomial(params, x, make([]uint64, maxPolynomialDegree(params.MaxLevel())+1), pk, evk); err != nil {
+		t.Fatal(err)
+	}
+	coeffs := make([]uint64, maxPolynomialDegree(params.MaxLevel())+2)
+	coeffs[len(coeffs)-1] = 1
+	if _, err := EvalPolynomial(params, x, coeffs, pk, evk); err == nil {
+		t.Fatal("EvalPolynomial aceptó un grado mayor que el que admite la profundidad")
+	}
+}
+
+func TestLessThanBound(t *testing.T) {
+	params, err := InsecureTestParameters()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sk, pk := GenerateKeyPair(params)
+	evk := GenerateMemEvaluationKeySet(GenerateRelinearizationKey(params, sk))
+
+	// Grado 2·5 − 2 = 8, fuera del alcance de Horner con tres niveles
+	bound := maxBound(params.MaxLevel())
+	if bound != 5 {
+		t.Fatalf("se esperaba la cota máxima 5 y se obtuvo %d", bound)
+	}
+
+	a := make([]uint64, params.MaxSlots())
+	b := make([]uint64, params.MaxSlots())
+	want := make([]uint64, params.MaxSlots())
+	for i := range a {
+		a[i] = uint64(i) % bound
+		b[i] = uint64(i/int(bound)) % bound
+		if a[i] < b[i] {
+			want[i] = 1
+		}
+	}
+	ca, err := Encrypt(params, pk, a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cb, err := Encrypt(params, pk, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := LessThan(params, ca, cb, pk, evk, WithBound(bound))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := decryptOperand(params, sk, result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkValues(t, got, want)
+
+	// Una cota mayor se rechaza antes de interpolar, nombrando la cota máxima
+	_, err = LessThan(params, ca, cb, pk, evk, WithBound(bound+2))
+	if !errors.Is(err, ErrInsufficientDepth) {
+		t.Fatalf("se esperaba ErrInsufficientDepth y se obtuvo %v", err)
+	}
+	if !strings.Contains(err.Error(), fmt.Sprintf("la cota máxima es %d", bound)) {
+		t.Fatalf("el error no nombra la cota máxima: %v", err)
+	}
+}
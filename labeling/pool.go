@@ -0,0 +1,88 @@
+// Copyright 2025 Juan Martín Pérez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Decodificación sobre polinomios reutilizables.
+//
+// Un servidor que recibe miles de labeled ciphertexts por segundo reserva con UnmarshalBinary los
+// polinomios de cada β, que luego recoge el recolector de basura. CiphertextPool guarda cifrados
+// ya reservados al nivel máximo: UnmarshalBinaryPooled decodifica directamente sobre sus
+// polinomios, leyendo los coeficientes de la serialización sin copias intermedias, y Release los
+// devuelve al pool cuando el labeled ciphertext ya no se usa. Como lattigo reutiliza los
+// polinomios siempre que su capacidad basta, un cifrado del pool sirve para cualquier nivel.
+
+package labeling
+
+import (
+	"sync"
+
+	"github.com/tuneinsight/lattigo/v6/core/rlwe"
+)
+
+// CiphertextPool es un conjunto de cifrados de grado 1 al nivel máximo de unos parámetros,
+// reutilizables entre decodificaciones. Es seguro para uso concurrente.
+type CiphertextPool struct {
+	pool sync.Pool
+}
+
+// NewCiphertextPool crea un pool de cifrados para params
+func NewCiphertextPool(params Parameters) *CiphertextPool {
+	return &CiphertextPool{
+		pool: sync.Pool{
+			New: func() any {
+				return rlwe.NewCiphertext(params, 1, params.MaxLevel())
+			},
+		},
+	}
+}
+
+// Get devuelve un cifrado del pool, o uno nuevo sin reservar si el pool es nil
+func (p *CiphertextPool) Get() *rlwe.Ciphertext {
+	if p == nil {
+		return new(rlwe.Ciphertext)
+	}
+	return p.pool.Get().(*rlwe.Ciphertext)
+}
+
+// Put devuelve un cifrado al pool. El cifrado no debe volver a usarse.
+func (p *CiphertextPool) Put(ct *rlwe.Ciphertext) {
+	if p == nil || ct == nil {
+		return
+	}
+	p.pool.Put(ct)
+}
+
+// release devuelve al pool una copia de cada cifrado, que comparte sus polinomios, para no retener
+// los slices de βs de los que forman parte
+func (p *CiphertextPool) release(cts []*rlwe.Ciphertext) {
+	for _, ct := range cts {
+		if ct.Value != nil {
+			released := *ct
+			p.Put(&released)
+		}
+	}
+}
+
+// UnmarshalBinaryPooled decodifica el labeled ciphertext como UnmarshalBinary, pero sobre cifrados
+// tomados de pool. Al terminar de usarlo debe llamarse a Release para devolverlos. Como
+// UnmarshalBinary, no comprueba el resultado contra los parámetros; para eso está Check.
+func (lc *Labeledciphertext[T]) UnmarshalBinaryPooled(data []byte, pool *CiphertextPool) error {
+	return lc.unmarshal(data, pool)
+}
+
+// Release devuelve a pool los cifrados del labeled ciphertext y lo deja vacío. Ni él ni los
+// resultados de operaciones que compartan sus cifrados deben volver a usarse.
+func (lc *Labeledciphertext[T]) Release(pool *CiphertextPool) {
+	pool.release(lc.ciphertexts())
+	*lc = Labeledciphertext[T]{}
+}
@@ -0,0 +1,183 @@
+// Copyright 2025 Juan Martín Pérez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package labeling
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/tuneinsight/lattigo/v6/core/rlwe"
+)
+
+// ErrProgramMismatch se devuelve cuando las entradas o el resultado no corresponden al programa
+var ErrProgramMismatch = errors.New("labeling: el labeled ciphertext no corresponde al programa")
+
+// LabeledProgram es una expresión declarada sobre entradas identificadas por etiqueta, como en el
+// modelo de labeled HE. El identificador del programa compromete su nombre, sus entradas y la
+// serialización canónica de la expresión, de modo que dos partes que acuerdan el ID acuerdan
+// también el circuito exacto.
+//
+// No es una verificación de la evaluación: el servidor elige el ID que adjunta al resultado y los
+// metadatos con las etiquetas, y puede devolver un cifrado de otro circuito, o uno fabricado, con
+// el ID y las etiquetas del programa acordado. Decrypt solo detecta los errores de un servidor
+// honesto, como evaluar otro programa o mezclar entradas de otro conjunto de etiquetas.
+type LabeledProgram struct {
+	name   string
+	inputs []Label
+	body   Expr
+}
+
+// ProgramResult es el resultado de evaluar un LabeledProgram, con el identificador que declara el
+// servidor
+type ProgramResult struct {
+	Program string
+	Value   Operand
+}
+
+// NewLabeledProgram declara un programa con nombre sobre las etiquetas indicadas. Las variables de
+// body son las etiquetas de las entradas, y debe usar solo etiquetas de inputs.
+func NewLabeledProgram(name string, inputs []Label, body Expr) (*LabeledProgram, error) {
+	for i, label := range inputs {
+		if slices.Contains(inputs[:i], label) {
+			return nil, fmt.Errorf("%w: la etiqueta %q se repite", ErrProgramMismatch, label)
+		}
+	}
+
+	var err error
+	var visit func(Expr)
+	visit = func(expr Expr) {
+		switch e := expr.(type) {
+		case AddExpr:
+			visit(e.Left)
+			visit(e.Right)
+		case MulExpr:
+			visit(e.Left)
+			visit(e.Right)
+		case RotateExpr:
+			visit(e.Operand)
+		case VarExpr:
+			if err == nil && !slices.Contains(inputs, Label(e.Name)) {
+				err = fmt.Errorf("%w: %s usa la etiqueta %q, que no es una entrada", ErrProgramMismatch, name, e.Name)
+			}
+		}
+	}
+	if _, err := indexExpr(body); err != nil {
+		return nil, err
+	}
+	visit(body)
+	if err != nil {
+		return nil, err
+	}
+
+	return &LabeledProgram{name: name, inputs: slices.Clone(inputs), body: body}, nil
+}
+
+// Name devuelve el nombre del programa
+func (p *LabeledProgram) Name() string {
+	return p.name
+}
+
+// Inputs devuelve las etiquetas de las entradas en orden
+func (p *LabeledProgram) Inputs() []Label {
+	return slices.Clone(p.inputs)
+}
+
+// Body devuelve la expresión del programa
+func (p *LabeledProgram) Body() Expr {
+	return p.body
+}
+
+// ID identifica el programa por su nombre, sus entradas y la forma canónica de su expresión, con
+// cada campo precedido de su longitud
+func (p *LabeledProgram) ID() string {
+	hash := sha256.New()
+	field := func(value string) {
+		var length [8]byte
+		binary.BigEndian.PutUint64(length[:], uint64(len(value)))
+		hash.Write(length[:])
+		hash.Write([]byte(value))
+	}
+
+	field(p.name)
+	for _, label := range p.inputs {
+		field(string(label))
+	}
+	field(p.body.String())
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// Evaluate es el paso del servidor: comprueba que cada entrada se cifró con la etiqueta declarada
+// y evalúa la expresión con EvalExpr
+func (p *LabeledProgram) Evaluate(params Parameters, key rlwe.EncryptionKey, evk *rlwe.MemEvaluationKeySet, inputs ...PlaintextLabeledciphertext) (ProgramResult, error) {
+	if len(inputs) != len(p.inputs) {
+		return ProgramResult{}, fmt.Errorf("%w: %s espera %d entradas y recibe %d", ErrProgramMismatch, p.name, len(p.inputs), len(inputs))
+	}
+
+	named := make(map[string]PlaintextLabeledciphertext, len(inputs))
+	for i, input := range inputs {
+		if labels := input.Labels(); len(labels) != 1 || labels[0] != p.inputs[i] {
+			return ProgramResult{}, fmt.Errorf("%w: la entrada %d no tiene la etiqueta %q", ErrProgramMismatch, i, p.inputs[i])
+		}
+		named[string(p.inputs[i])] = input
+	}
+
+	value, err := EvalExpr(params, p.body, named, key, evk)
+	if err != nil {
+		return ProgramResult{}, err
+	}
+
+	return ProgramResult{Program: p.ID(), Value: value}, nil
+}
+
+// Decrypt es el paso del propietario: comprueba que el resultado declara este programa y que sus
+// etiquetas son entradas del programa, y lo descifra. Las dos comprobaciones se hacen sobre datos
+// que aporta el servidor, así que no prueban que el valor sea el del programa (ver LabeledProgram).
+func (p *LabeledProgram) Decrypt(params Parameters, key *rlwe.SecretKey, result ProgramResult) ([]uint64, error) {
+	if result.Program != p.ID() {
+		return nil, fmt.Errorf("%w: resultado del programa %s", ErrProgramMismatch, result.Program)
+	}
+
+	var labels []Label
+	switch value := result.Value.(type) {
+	case PlaintextLabeledciphertext:
+		labels = value.Labels()
+	case CiphertextLabeledciphertext:
+		labels = value.Labels()
+	}
+	for _, label := range labels {
+		if !slices.Contains(p.inputs, label) {
+			return nil, fmt.Errorf("%w: el resultado depende de la etiqueta %q", ErrProgramMismatch, label)
+		}
+	}
+
+	return decryptOperand(params, key, result.Value)
+}
+
+// decryptOperand descifra un labeled ciphertext de cualquier forma
+func decryptOperand(params Parameters, key *rlwe.SecretKey, value Operand) ([]uint64, error) {
+	switch value := value.(type) {
+	case PlaintextLabeledciphertext:
+		return Decrypt(params, key, value)
+	case CiphertextLabeledciphertext:
+		return DecryptOverflow(params, key, value)
+	}
+
+	return nil, fmt.Errorf("%w: descifrado de %T", ErrUnsupportedOperands, value)
+}
@@ -0,0 +1,94 @@
+// Copyright 2025 Juan Martín Pérez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package labeling
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestLabeledProgram(t *testing.T) {
+	params, err := InsecureTestParameters()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sk, pk := GenerateKeyPair(params)
+	evk := GenerateMemEvaluationKeySet(GenerateRelinearizationKey(params, sk))
+	labelKey, err := GenerateLabelKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	registry := NewLabelRegistry()
+
+	labels := []Label{"x", "y"}
+	inputs := make([]PlaintextLabeledciphertext, len(labels))
+	for i, label := range labels {
+		if inputs[i], err = EncryptLabeled(params, pk, labelKey, registry, label, broadcast(params, uint64(i+2))); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	parse := func(source string) Expr {
+		expr, err := ParseExpr(source)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return expr
+	}
+	program, err := NewLabeledProgram("producto", labels, parse("x*y + 3"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := program.Evaluate(params, pk, evk, inputs...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := program.Decrypt(params, sk, result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkValues(t, got, broadcast(params, 2*3+3))
+
+	// Con el mismo nombre y las mismas entradas, otro cuerpo es otro programa
+	other, err := NewLabeledProgram("producto", labels, parse("x + y"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other.ID() == program.ID() {
+		t.Fatal("el ID no depende del cuerpo del programa")
+	}
+	otherResult, err := other.Evaluate(params, pk, evk, inputs...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := program.Decrypt(params, sk, otherResult); !errors.Is(err, ErrProgramMismatch) {
+		t.Fatalf("se esperaba ErrProgramMismatch, se obtuvo %v", err)
+	}
+
+	// Las entradas deben llevar las etiquetas en el orden declarado
+	if _, err := program.Evaluate(params, pk, evk, inputs[1], inputs[0]); !errors.Is(err, ErrProgramMismatch) {
+		t.Fatalf("entradas permutadas: se esperaba ErrProgramMismatch, se obtuvo %v", err)
+	}
+}
+
+func TestNewLabeledProgramRejectsUnknownLabels(t *testing.T) {
+	body := MulExpr{Left: VarExpr{Name: "x"}, Right: VarExpr{Name: "z"}}
+	if _, err := NewLabeledProgram("producto", []Label{"x", "y"}, body); !errors.Is(err, ErrProgramMismatch) {
+		t.Fatalf("se esperaba ErrProgramMismatch, se obtuvo %v", err)
+	}
+	if _, err := NewLabeledProgram("producto", []Label{"x", "x"}, VarExpr{Name: "x"}); !errors.Is(err, ErrProgramMismatch) {
+		t.Fatalf("etiqueta repetida: se esperaba ErrProgramMismatch, se obtuvo %v", err)
+	}
+}
@@ -0,0 +1,648 @@
+// Copyright 2025 Juan Martín Pérez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Pruebas de descifrado verificable.
+//
+// El propietario de la clave publica el valor descifrado junto con el descifrado de cada componente
+// cifrada (β en forma plaintext; α y cada β distinto en forma overflow) y una prueba de que todos
+// se obtienen con la clave secreta de pk. Un auditor recompone el valor como Decrypt o
+// DecryptOverflow y comprueba la prueba sin conocer la clave.
+//
+// La prueba es un protocolo sigma de Lyubashevsky (Fiat-Shamir con abortos) para el sistema lineal
+//
+//	a·s − e  = −b              (clave pública (b, a), b = −a·s + e)
+//	c1·s − eᵢ = Mᵢ − c0         (componente i, (c0, c1) = Mᵢ + eᵢ con la codificación Mᵢ)
+//
+// con s, e y eᵢ cortos. El probador enmascara el testigo con y uniforme, deriva el reto c (un
+// polinomio con challengeWeight coeficientes ±1) del hash del enunciado y de los compromisos
+// w = A·y, y publica z = y + c·testigo solo si z no revela el testigo; si no, vuelve a empezar. La
+// solidez es la relajada habitual de estas pruebas.
+//
+// La prueba revela el número de bits del ruido de cada componente; puede ocultarse antes con
+// FloodNoise.
+
+package labeling
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/tuneinsight/lattigo/v6/core/rlwe"
+	"github.com/tuneinsight/lattigo/v6/ring"
+	"github.com/tuneinsight/lattigo/v6/schemes/bgv"
+	"github.com/tuneinsight/lattigo/v6/utils/sampling"
+)
+
+// ErrInvalidProof se devuelve cuando una prueba de descifrado no es válida
+var ErrInvalidProof = errors.New("labeling: prueba de descifrado no válida")
+
+const (
+	// proofDomain separa el hash de las pruebas de cualquier otro uso de SHA-256
+	proofDomain = "labeling-decryption-proof-v1"
+	// challengeWeight es el número de coeficientes ±1 del reto
+	challengeWeight = 60
+	// maxProofAttempts limita los reintentos del muestreo con rechazo
+	maxProofAttempts = 256
+)
+
+// DecryptionProof prueba que un valor es el descifrado correcto de un labeled ciphertext
+type DecryptionProof struct {
+	// components son los descifrados de cada componente cifrada
+	components [][]uint64
+	// bounds son los bits de la cota de s, de e y de cada eᵢ
+	bounds []uint8
+	// digest es el hash del que se deriva el reto
+	digest [sha256.Size]byte
+	// z son las respuestas para s, e y cada eᵢ, en coeficientes centrados
+	z [][]*big.Int
+}
+
+// decryptionComponents devuelve las componentes cifradas cuyo descifrado se prueba y, en forma
+// overflow, los términos como índices en los βs (que empiezan en la componente 1)
+func decryptionComponents[T any](labeledciphertext Labeledciphertext[T]) ([]*rlwe.Ciphertext, [][]int, error) {
+	switch elementsA := any(labeledciphertext.elementsA).(type) {
+	case PlaintextElements:
+		if len(labeledciphertext.elementsB) != 1 || len(labeledciphertext.elementsB[0]) != 1 {
+			return nil, nil, fmt.Errorf("labeling: la prueba de descifrado requiere un único β en forma plaintext")
+		}
+		return []*rlwe.Ciphertext{&labeledciphertext.elementsB[0][0]}, nil, nil
+
+	case *CiphertextElement:
+		table, err := DeduplicateBetas(labeledciphertext)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		cts := []*rlwe.Ciphertext{(*rlwe.Ciphertext)(elementsA)}
+		for k := range table.Betas {
+			cts = append(cts, &table.Betas[k])
+		}
+		return cts, table.Terms, nil
+	}
+
+	return nil, nil, fmt.Errorf("%w: prueba de descifrado de %T", ErrUnsupportedOperands, labeledciphertext.elementsA)
+}
+
+// recombine obtiene el valor descifrado a partir de los descifrados de las componentes
+func recombine[T any](params Parameters, labeledciphertext Labeledciphertext[T], components [][]uint64, terms [][]int) []uint64 {
+	if elementsA, ok := any(labeledciphertext.elementsA).(PlaintextElements); ok {
+		t := params.PlaintextModulus()
+		value := make([]uint64, len(elementsA))
+		for i, elementA := range elementsA {
+			value[i] = (elementA + components[0][i]) % t
+		}
+		return value
+	}
+
+	return combineOverflow(params, components[0], components[1:], terms)
+}
+
+// decryptionRelation es el sistema lineal A·s − eₖ = −tₖ en el dominio NTT: la parte 0 es la clave
+// pública y las siguientes son las componentes cifradas
+type decryptionRelation struct {
+	params Parameters
+	levels []int
+	a      []ring.Poly
+	t      []ring.Poly
+}
+
+// nttCopy devuelve una copia de p en el dominio NTT y fuera de la forma de Montgomery
+func nttCopy(ringQ *ring.Ring, p ring.Poly, isNTT, isMontgomery bool) ring.Poly {
+	q := *p.CopyNew()
+	if isMontgomery {
+		ringQ.IMForm(q, q)
+	}
+	if !isNTT {
+		ringQ.NTT(q, q)
+	}
+	return q
+}
+
+// newDecryptionRelation construye el sistema para pk y las componentes con sus descifrados
+func newDecryptionRelation(params Parameters, pk *rlwe.PublicKey, cts []*rlwe.Ciphertext, components [][]uint64) (*decryptionRelation, error) {
+	if pk == nil || len(pk.Value) != 2 {
+		return nil, fmt.Errorf("labeling: clave pública no válida")
+	}
+
+	relation := &decryptionRelation{params: params}
+
+	// b = −a·s + e. La clave pública no lleva metadatos: rlwe la genera siempre en NTT y en forma
+	// de Montgomery
+	level := params.MaxLevel()
+	ringQ := params.RingQ().AtLevel(level)
+	relation.levels = append(relation.levels, level)
+	relation.a = append(relation.a, nttCopy(ringQ, pk.Value[1].Q, true, true))
+	relation.t = append(relation.t, nttCopy(ringQ, pk.Value[0].Q, true, true))
+
+	// c0 + c1·s = Mᵢ + eᵢ
+	encoder := bgv.NewEncoder(params.Parameters)
+	for i, ct := range cts {
+		if ct.Degree() != 1 {
+			return nil, fmt.Errorf("labeling: la prueba de descifrado requiere cifrados de grado 1; relinealiza antes")
+		}
+
+		level := ct.Level()
+		ringQ := params.RingQ().AtLevel(level)
+
+		pt := bgv.NewPlaintext(params.Parameters, level)
+		*pt.MetaData = *ct.MetaData
+		if err := encoder.Encode(components[i], pt); err != nil {
+			return nil, err
+		}
+
+		target := nttCopy(ringQ, ct.Value[0], ct.IsNTT, false)
+		ringQ.Sub(target, nttCopy(ringQ, pt.Value, pt.IsNTT, false), target)
+
+		relation.levels = append(relation.levels, level)
+		relation.a = append(relation.a, nttCopy(ringQ, ct.Value[1], ct.IsNTT, false))
+		relation.t = append(relation.t, target)
+	}
+
+	return relation, nil
+}
+
+// lift lleva coeficientes enteros al dominio NTT en el nivel indicado
+func (r *decryptionRelation) lift(level int, coeffs []*big.Int) ring.Poly {
+	ringQ := r.params.RingQ().AtLevel(level)
+	p := ringQ.NewPoly()
+	ringQ.SetCoefficientsBigint(coeffs, p)
+	ringQ.NTT(p, p)
+	return p
+}
+
+// commit calcula wₖ = Aₖ·zs − zₖ + c·tₖ; con c nil son los compromisos del probador
+func (r *decryptionRelation) commit(zs []*big.Int, z [][]*big.Int, c []*big.Int) []ring.Poly {
+	maxLevel := r.params.MaxLevel()
+	zsNTT := r.lift(maxLevel, zs)
+
+	var cNTT ring.Poly
+	if c != nil {
+		cNTT = r.lift(maxLevel, c)
+	}
+
+	w := make([]ring.Poly, len(r.a))
+	for k, level := range r.levels {
+		ringQ := r.params.RingQ().AtLevel(level)
+		w[k] = ringQ.NewPoly()
+		ringQ.MulCoeffsBarrett(r.a[k], zsNTT, w[k])
+		ringQ.Sub(w[k], r.lift(level, z[k]), w[k])
+		if c != nil {
+			ringQ.MulCoeffsBarrettThenAdd(cNTT, r.t[k], w[k])
+		}
+	}
+
+	return w
+}
+
+// witness devuelve s y los errores eₖ = Aₖ·s + tₖ en coeficientes centrados
+func (r *decryptionRelation) witness(sk *rlwe.SecretKey) ([]*big.Int, [][]*big.Int) {
+	maxLevel := r.params.MaxLevel()
+	ringQ := r.params.RingQ().AtLevel(maxLevel)
+
+	// La clave secreta está en el dominio NTT y en la forma de Montgomery
+	sNTT := nttCopy(ringQ, sk.Value.Q, true, true)
+	s := centered(ringQ, sNTT)
+
+	errs := make([][]*big.Int, len(r.a))
+	for k, level := range r.levels {
+		ringQ := r.params.RingQ().AtLevel(level)
+		e := ringQ.NewPoly()
+		ringQ.MulCoeffsBarrett(r.a[k], sNTT, e)
+		ringQ.Add(e, r.t[k], e)
+		errs[k] = centered(ringQ, e)
+	}
+
+	return s, errs
+}
+
+// centered devuelve los coeficientes centrados de un polinomio en el dominio NTT
+func centered(ringQ *ring.Ring, p ring.Poly) []*big.Int {
+	q := *p.CopyNew()
+	ringQ.INTT(q, q)
+	coeffs := make([]*big.Int, ringQ.N())
+	for i := range coeffs {
+		coeffs[i] = new(big.Int)
+	}
+	ringQ.PolyToBigintCentered(q, 1, coeffs)
+	return coeffs
+}
+
+// maskBound es la cota B de las máscaras de una parte con testigo de bits bits; con ella el
+// muestreo con rechazo acepta con probabilidad constante
+func maskBound(bits uint8, n, parts int) *big.Int {
+	bound := new(big.Int).Lsh(big.NewInt(1), uint(bits))
+	return bound.Mul(bound, big.NewInt(int64(challengeWeight*n*parts)))
+}
+
+// acceptBound es la cota de ‖z‖∞ que no revela el testigo: B − challengeWeight·2^bits
+func acceptBound(bits uint8, n, parts int) *big.Int {
+	slack := new(big.Int).Lsh(big.NewInt(challengeWeight), uint(bits))
+	return slack.Sub(maskBound(bits, n, parts), slack)
+}
+
+// normBits devuelve los bits de ‖x‖∞
+func normBits(x []*big.Int) uint8 {
+	bits := 1
+	for _, coeff := range x {
+		bits = max(bits, coeff.BitLen())
+	}
+	return uint8(bits)
+}
+
+// withinBound comprueba ‖x‖∞ ≤ bound
+func withinBound(x []*big.Int, bound *big.Int) bool {
+	abs := new(big.Int)
+	for _, coeff := range x {
+		if abs.Abs(coeff).Cmp(bound) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// sampleMask devuelve n coeficientes uniformes en [−bound, bound]
+func sampleMask(n int, bound *big.Int) ([]*big.Int, error) {
+	width := new(big.Int).Lsh(bound, 1)
+	width.Add(width, big.NewInt(1))
+
+	y := make([]*big.Int, n)
+	for i := range y {
+		coeff, err := rand.Int(rand.Reader, width)
+		if err != nil {
+			return nil, err
+		}
+		y[i] = coeff.Sub(coeff, bound)
+	}
+	return y, nil
+}
+
+// deriveChallenge deriva del digest el reto: challengeWeight coeficientes ±1 en posiciones distintas
+func deriveChallenge(digest [sha256.Size]byte, n int) ([]*big.Int, error) {
+	prng, err := sampling.NewKeyedPRNG(digest[:])
+	if err != nil {
+		return nil, err
+	}
+
+	c := make([]*big.Int, n)
+	for i := range c {
+		c[i] = new(big.Int)
+	}
+
+	// n es una potencia de dos, así que la reducción no tiene sesgo
+	buf := make([]byte, 8)
+	for placed := 0; placed < min(challengeWeight, n); {
+		if _, err := prng.Read(buf); err != nil {
+			return nil, err
+		}
+		r := binary.LittleEndian.Uint64(buf)
+		position := int(r & uint64(n-1))
+		if c[position].Sign() != 0 {
+			continue
+		}
+		if r>>63 == 1 {
+			c[position].SetInt64(-1)
+		} else {
+			c[position].SetInt64(1)
+		}
+		placed++
+	}
+
+	return c, nil
+}
+
+// mulChallenge calcula y + c·x en Z[X]/(X^N + 1) con c de coeficientes en {−1, 0, 1}
+func mulChallenge(y, c, x []*big.Int) []*big.Int {
+	n := len(x)
+	z := make([]*big.Int, n)
+	for i := range z {
+		z[i] = new(big.Int).Set(y[i])
+	}
+
+	for j, cj := range c {
+		if cj.Sign() == 0 {
+			continue
+		}
+		for i, xi := range x {
+			// X^N = −1
+			index, sign := i+j, cj.Sign()
+			if index >= n {
+				index, sign = index-n, -sign
+			}
+			if sign > 0 {
+				z[index].Add(z[index], xi)
+			} else {
+				z[index].Sub(z[index], xi)
+			}
+		}
+	}
+
+	return z
+}
+
+// transcript calcula el hash del enunciado (pk, componentes, descifrados y cotas) y de los compromisos
+func transcript(pk *rlwe.PublicKey, cts []*rlwe.Ciphertext, components [][]uint64, bounds []uint8, w []ring.Poly) ([sha256.Size]byte, error) {
+	hash := sha256.New()
+	hash.Write([]byte(proofDomain))
+
+	data, err := pk.MarshalBinary()
+	if err != nil {
+		return [sha256.Size]byte{}, err
+	}
+	hash.Write(data)
+
+	for i, ct := range cts {
+		if _, err := ct.WriteTo(hash); err != nil {
+			return [sha256.Size]byte{}, err
+		}
+		for _, value := range components[i] {
+			hash.Write(binary.LittleEndian.AppendUint64(nil, value))
+		}
+	}
+
+	hash.Write(bounds)
+
+	for _, p := range w {
+		for _, row := range p.Coeffs {
+			for _, coeff := range row {
+				hash.Write(binary.LittleEndian.AppendUint64(nil, coeff))
+			}
+		}
+	}
+
+	var digest [sha256.Size]byte
+	copy(digest[:], hash.Sum(nil))
+	return digest, nil
+}
+
+// checkMargin comprueba que la cota de cada parte deja margen de descifrado: 2·B·t < Q en su nivel
+func (r *decryptionRelation) checkMargin(bounds []uint8) error {
+	n, parts := r.params.N(), len(bounds)
+	t := new(big.Int).SetUint64(r.params.PlaintextModulus())
+
+	for k, level := range r.levels {
+		Q := big.NewInt(1)
+		for _, qi := range r.params.Q()[:level+1] {
+			Q.Mul(Q, new(big.Int).SetUint64(qi))
+		}
+
+		limit := maskBound(bounds[k+1], n, parts)
+		limit.Lsh(limit, 1).Mul(limit, t)
+		if limit.Cmp(Q) >= 0 {
+			return fmt.Errorf("labeling: el ruido de la parte %d deja demasiado poco margen para la prueba", k)
+		}
+	}
+
+	return nil
+}
+
+// ProveDecryption descifra el labeled ciphertext y genera la prueba de que el valor es su descifrado
+// correcto con la clave secreta de pk. Las componentes cifradas deben ser de grado 1.
+func ProveDecryption[T PlaintextElements | *CiphertextElement](params Parameters, sk *rlwe.SecretKey, pk *rlwe.PublicKey, labeledciphertext Labeledciphertext[T]) ([]uint64, *DecryptionProof, error) {
+	cts, terms, err := decryptionComponents(labeledciphertext)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	encoder := bgv.NewEncoder(params.Parameters)
+	decryptor := rlwe.NewDecryptor(params, sk)
+
+	components := make([][]uint64, len(cts))
+	for i, ct := range cts {
+		components[i] = make([]uint64, params.MaxSlots())
+		if err := encoder.Decode(decryptor.DecryptNew(ct), components[i]); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	relation, err := newDecryptionRelation(params, pk, cts, components)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Testigo (s, e, eᵢ) y sus cotas
+	s, errs := relation.witness(sk)
+	witness := append([][]*big.Int{s}, errs...)
+
+	bounds := make([]uint8, len(witness))
+	for k, x := range witness {
+		bounds[k] = normBits(x)
+	}
+	if err := relation.checkMargin(bounds); err != nil {
+		return nil, nil, err
+	}
+
+	n, parts := params.N(), len(witness)
+	for range maxProofAttempts {
+		// Compromisos w = A·y
+		y := make([][]*big.Int, parts)
+		for k := range y {
+			if y[k], err = sampleMask(n, maskBound(bounds[k], n, parts)); err != nil {
+				return nil, nil, err
+			}
+		}
+
+		digest, err := transcript(pk, cts, components, bounds, relation.commit(y[0], y[1:], nil))
+		if err != nil {
+			return nil, nil, err
+		}
+
+		c, err := deriveChallenge(digest, n)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		// z = y + c·testigo, que se descarta si se sale de la cota de aceptación
+		z := make([][]*big.Int, parts)
+		accepted := true
+		for k := range z {
+			z[k] = mulChallenge(y[k], c, witness[k])
+			if !withinBound(z[k], acceptBound(bounds[k], n, parts)) {
+				accepted = false
+				break
+			}
+		}
+		if !accepted {
+			continue
+		}
+
+		proof := &DecryptionProof{components: components, bounds: bounds, digest: digest, z: z}
+		return recombine(params, labeledciphertext, components, terms), proof, nil
+	}
+
+	return nil, nil, fmt.Errorf("labeling: no se pudo generar la prueba de descifrado tras %d intentos", maxProofAttempts)
+}
+
+// VerifyDecryption comprueba que value es el descifrado correcto del labeled ciphertext con la clave
+// secreta de pk. Devuelve ErrInvalidProof si la prueba no es válida.
+func VerifyDecryption[T PlaintextElements | *CiphertextElement](params Parameters, pk *rlwe.PublicKey, labeledciphertext Labeledciphertext[T], value []uint64, proof *DecryptionProof) error {
+	cts, terms, err := decryptionComponents(labeledciphertext)
+	if err != nil {
+		return err
+	}
+
+	n, parts := params.N(), len(cts)+2
+	if proof == nil || len(proof.components) != len(cts) || len(proof.bounds) != parts || len(proof.z) != parts {
+		return fmt.Errorf("%w: dimensiones incorrectas", ErrInvalidProof)
+	}
+	for _, component := range proof.components {
+		if len(component) != params.MaxSlots() {
+			return fmt.Errorf("%w: dimensiones incorrectas", ErrInvalidProof)
+		}
+		for _, v := range component {
+			if v >= params.PlaintextModulus() {
+				return fmt.Errorf("%w: descifrado fuera de Z_t", ErrInvalidProof)
+			}
+		}
+	}
+
+	// El valor debe recomponerse a partir de los descifrados de las componentes
+	recombined := recombine(params, labeledciphertext, proof.components, terms)
+	if len(recombined) != len(value) {
+		return fmt.Errorf("%w: el valor no corresponde al labeled ciphertext", ErrInvalidProof)
+	}
+	for i := range value {
+		if recombined[i] != value[i] {
+			return fmt.Errorf("%w: el valor no corresponde al labeled ciphertext", ErrInvalidProof)
+		}
+	}
+
+	relation, err := newDecryptionRelation(params, pk, cts, proof.components)
+	if err != nil {
+		return err
+	}
+	if err := relation.checkMargin(proof.bounds); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidProof, err)
+	}
+
+	for k, z := range proof.z {
+		if len(z) != n || !withinBound(z, acceptBound(proof.bounds[k], n, parts)) {
+			return fmt.Errorf("%w: respuesta fuera de cota", ErrInvalidProof)
+		}
+	}
+
+	// w = A·z − z' + c·t debe reproducir el reto
+	c, err := deriveChallenge(proof.digest, n)
+	if err != nil {
+		return err
+	}
+
+	digest, err := transcript(pk, cts, proof.components, proof.bounds, relation.commit(proof.z[0], proof.z[1:], c))
+	if err != nil {
+		return err
+	}
+	if digest != proof.digest {
+		return fmt.Errorf("%w: el reto no coincide", ErrInvalidProof)
+	}
+
+	return nil
+}
+
+// MarshalBinary serializa la prueba: el dominio, los descifrados, las cotas, el digest y las
+// respuestas, cada coeficiente como signo, longitud y magnitud
+func (p DecryptionProof) MarshalBinary() ([]byte, error) {
+	data := []byte(proofDomain)
+
+	data = binary.LittleEndian.AppendUint32(data, uint32(len(p.components)))
+	for _, component := range p.components {
+		data = binary.LittleEndian.AppendUint32(data, uint32(len(component)))
+		for _, v := range component {
+			data = binary.LittleEndian.AppendUint64(data, v)
+		}
+	}
+
+	data = binary.LittleEndian.AppendUint32(data, uint32(len(p.bounds)))
+	data = append(data, p.bounds...)
+	data = append(data, p.digest[:]...)
+
+	data = binary.LittleEndian.AppendUint32(data, uint32(len(p.z)))
+	for _, z := range p.z {
+		data = binary.LittleEndian.AppendUint32(data, uint32(len(z)))
+		for _, coeff := range z {
+			magnitude := coeff.Bytes()
+			if len(magnitude) > 0xffff {
+				return nil, fmt.Errorf("labeling: coeficiente de la prueba demasiado grande")
+			}
+			data = append(data, byte(max(coeff.Sign(), 0)))
+			data = binary.LittleEndian.AppendUint16(data, uint16(len(magnitude)))
+			data = append(data, magnitude...)
+		}
+	}
+
+	return data, nil
+}
+
+// UnmarshalBinary reconstruye la prueba a partir de su serialización binaria. No la comprueba:
+// debe llamarse a VerifyDecryption.
+func (p *DecryptionProof) UnmarshalBinary(data []byte) error {
+	if !bytes.HasPrefix(data, []byte(proofDomain)) {
+		return fmt.Errorf("labeling: la serialización no es una prueba de descifrado")
+	}
+	r := &byteReader{data: data[len(proofDomain):], what: "prueba de descifrado"}
+
+	var proof DecryptionProof
+
+	proof.components = make([][]uint64, 0)
+	for range r.uint32() {
+		length := r.uint32()
+		values := r.next(8 * length)
+		if r.err != nil {
+			return r.err
+		}
+		component := make([]uint64, length)
+		for i := range component {
+			component[i] = binary.LittleEndian.Uint64(values[8*i:])
+		}
+		proof.components = append(proof.components, component)
+	}
+
+	proof.bounds = append([]uint8{}, r.next(r.uint32())...)
+	copy(proof.digest[:], r.next(sha256.Size))
+
+	proof.z = make([][]*big.Int, 0)
+	for range r.uint32() {
+		length := r.uint32()
+		if r.err != nil || length > len(r.data) {
+			return fmt.Errorf("labeling: prueba de descifrado truncada")
+		}
+		z := make([]*big.Int, length)
+		for i := range z {
+			sign := r.next(1)
+			magnitude := r.next(r.uint16())
+			if r.err != nil {
+				return r.err
+			}
+			z[i] = new(big.Int).SetBytes(magnitude)
+			if sign[0] == 0 {
+				z[i].Neg(z[i])
+			}
+		}
+		proof.z = append(proof.z, z)
+	}
+
+	if r.err != nil {
+		return r.err
+	}
+	if len(r.data) != 0 {
+		return fmt.Errorf("labeling: datos sobrantes tras la prueba de descifrado")
+	}
+
+	*p = proof
+
+	return nil
+}
@@ -0,0 +1,360 @@
+// Copyright 2025 Juan Martín Pérez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Conversiones con los mensajes protobuf de labelingpb.
+//
+// El esquema está en labelingpb/labeling.proto. Los mensajes llevan el hash de los parámetros, y
+// las conversiones de vuelta lo comprueban cuando está presente. EvalProto atiende una
+// EvalRequest completa y sirve de base para servicios gRPC; EvalWithBundle es la evaluación común
+// a cualquier transporte.
+
+package labeling
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/tuneinsight/lattigo/v6/core/rlwe"
+
+	"main.go/labeling/labelingpb"
+)
+
+// checkParametersHash comprueba el hash de los parámetros de un mensaje, si lo trae
+func checkParametersHash(params Parameters, hash []byte) error {
+	if len(hash) == 0 {
+		return nil
+	}
+
+	expected, err := params.Hash()
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(hash, expected[:]) {
+		return ErrParametersMismatch
+	}
+
+	return nil
+}
+
+// ToProto convierte el labeled ciphertext en su mensaje protobuf
+func (lc Labeledciphertext[T]) ToProto(params Parameters) (*labelingpb.LabeledCiphertext, error) {
+	hash, err := params.Hash()
+	if err != nil {
+		return nil, err
+	}
+
+	msg := &labelingpb.LabeledCiphertext{
+		ParametersHash: hash[:],
+		Metadata: &labelingpb.Metadata{
+			Multiplications: uint32(lc.meta.multiplications),
+			MaxDegree:       uint32(lc.meta.maxDegree),
+			MaxTerms:        uint32(lc.meta.maxTerms),
+			Operations:      append([]string{}, lc.meta.operations...),
+		},
+	}
+	for _, label := range lc.meta.labels {
+		msg.Metadata.Labels = append(msg.Metadata.Labels, string(label))
+	}
+
+	switch elementsA := any(lc.elementsA).(type) {
+	case PlaintextElements:
+		msg.Form = labelingpb.LabeledCiphertext_FORM_PLAINTEXT
+		msg.ElementsA = append([]uint64{}, elementsA...)
+
+	case *CiphertextElement:
+		if elementsA == nil {
+			return nil, fmt.Errorf("labeling: labeled ciphertext sin α")
+		}
+		msg.Form = labelingpb.LabeledCiphertext_FORM_OVERFLOW
+		if msg.Alpha, err = (*rlwe.Ciphertext)(elementsA).MarshalBinary(); err != nil {
+			return nil, err
+		}
+
+	default:
+		return nil, fmt.Errorf("%w: conversión de %T", ErrUnsupportedOperands, lc.elementsA)
+	}
+
+	for _, term := range lc.elementsB {
+		pbTerm := &labelingpb.Term{Betas: make([][]byte, len(term))}
+		for j := range term {
+			if pbTerm.Betas[j], err = term[j].MarshalBinary(); err != nil {
+				return nil, err
+			}
+		}
+		msg.ElementsB = append(msg.ElementsB, pbTerm)
+	}
+
+	return msg, nil
+}
+
+// LabeledciphertextFromProto reconstruye un labeled ciphertext a partir de su mensaje protobuf.
+// La forma del mensaje debe coincidir con T, y el resultado debe superar Check.
+func LabeledciphertextFromProto[T PlaintextElements | *CiphertextElement](params Parameters, msg *labelingpb.LabeledCiphertext) (Labeledciphertext[T], error) {
+	var result Labeledciphertext[T]
+
+	if msg == nil {
+		return result, fmt.Errorf("%w: mensaje de labeled ciphertext vacío", ErrMalformed)
+	}
+	if err := checkParametersHash(params, msg.ParametersHash); err != nil {
+		return result, err
+	}
+
+	switch any(result.elementsA).(type) {
+	case PlaintextElements:
+		if msg.Form != labelingpb.LabeledCiphertext_FORM_PLAINTEXT {
+			return result, fmt.Errorf("%w: se esperaba un labeled ciphertext en forma plaintext", ErrMalformed)
+		}
+		if len(msg.GetElementsB()) != 1 || len(msg.GetElementsB()[0].GetBetas()) != 1 {
+			return result, fmt.Errorf("%w: un labeled ciphertext en forma plaintext requiere un único β", ErrMalformed)
+		}
+		result.elementsA = any(append(PlaintextElements{}, msg.ElementsA...)).(T)
+
+	case *CiphertextElement:
+		if msg.Form != labelingpb.LabeledCiphertext_FORM_OVERFLOW {
+			return result, fmt.Errorf("%w: se esperaba un labeled ciphertext en forma overflow", ErrMalformed)
+		}
+		alpha := new(rlwe.Ciphertext)
+		if err := alpha.UnmarshalBinary(msg.Alpha); err != nil {
+			return result, fmt.Errorf("%w: α: %s", ErrMalformed, err)
+		}
+		result.elementsA = any((*CiphertextElement)(alpha)).(T)
+	}
+
+	result.elementsB = make([][]rlwe.Ciphertext, len(msg.ElementsB))
+	for i, term := range msg.ElementsB {
+		if len(term.GetBetas()) == 0 {
+			return result, fmt.Errorf("%w: término %d sin βs", ErrMalformed, i)
+		}
+		result.elementsB[i] = make([]rlwe.Ciphertext, len(term.Betas))
+		for j, beta := range term.Betas {
+			if err := result.elementsB[i][j].UnmarshalBinary(beta); err != nil {
+				return result, fmt.Errorf("%w: β[%d][%d]: %s", ErrMalformed, i, j, err)
+			}
+		}
+	}
+
+	if meta := msg.Metadata; meta != nil {
+		result.meta.multiplications = int(meta.Multiplications)
+		result.meta.maxDegree = int(meta.MaxDegree)
+		result.meta.maxTerms = int(meta.MaxTerms)
+		result.meta.operations = append([]string{}, meta.Operations...)
+		for _, label := range meta.Labels {
+			result.meta.labels = append(result.meta.labels, Label(label))
+		}
+	}
+
+	if err := result.Check(params); err != nil {
+		return result, err
+	}
+
+	return result, nil
+}
+
+// operandToProto convierte el resultado de una evaluación, en cualquiera de las dos formas
+func operandToProto(params Parameters, op Operand) (*labelingpb.LabeledCiphertext, error) {
+	switch lc := op.(type) {
+	case PlaintextLabeledciphertext:
+		return lc.ToProto(params)
+	case CiphertextLabeledciphertext:
+		return lc.ToProto(params)
+	}
+	return nil, fmt.Errorf("%w: conversión de %T", ErrUnsupportedOperands, op)
+}
+
+// ToProto convierte el paquete en su mensaje protobuf
+func (b KeyBundle) ToProto() (*labelingpb.KeyBundle, error) {
+	msg := new(labelingpb.KeyBundle)
+	var err error
+
+	if b.PublicKey != nil {
+		if msg.PublicKey, err = b.PublicKey.MarshalBinary(); err != nil {
+			return nil, err
+		}
+	}
+
+	if b.RelinearizationKey != nil {
+		if msg.RelinearizationKey, err = b.RelinearizationKey.MarshalBinary(); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, gk := range b.GaloisKeys {
+		key, err := gk.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		msg.GaloisKeys = append(msg.GaloisKeys, &labelingpb.GaloisKey{GaloisElement: gk.GaloisElement, Key: key})
+	}
+
+	if len(b.EvaluationKeys) > 0 {
+		msg.EvaluationKeys = make(map[string][]byte, len(b.EvaluationKeys))
+		for id, evk := range b.EvaluationKeys {
+			if msg.EvaluationKeys[string(id)], err = evk.MarshalBinary(); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return msg, nil
+}
+
+// KeyBundleFromProto reconstruye un paquete de claves a partir de su mensaje protobuf
+func KeyBundleFromProto(msg *labelingpb.KeyBundle) (*KeyBundle, error) {
+	bundle := new(KeyBundle)
+	if msg == nil {
+		return bundle, nil
+	}
+
+	if len(msg.PublicKey) > 0 {
+		bundle.PublicKey = new(rlwe.PublicKey)
+		if err := bundle.PublicKey.UnmarshalBinary(msg.PublicKey); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(msg.RelinearizationKey) > 0 {
+		bundle.RelinearizationKey = new(rlwe.RelinearizationKey)
+		if err := bundle.RelinearizationKey.UnmarshalBinary(msg.RelinearizationKey); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, entry := range msg.GaloisKeys {
+		if entry == nil {
+			return nil, fmt.Errorf("%w: clave de Galois vacía", ErrMalformed)
+		}
+		gk := new(rlwe.GaloisKey)
+		if err := gk.UnmarshalBinary(entry.Key); err != nil {
+			return nil, err
+		}
+		if gk.GaloisElement != entry.GaloisElement {
+			return nil, fmt.Errorf("labeling: la clave de Galois no corresponde al elemento %d", entry.GaloisElement)
+		}
+		bundle.GaloisKeys = append(bundle.GaloisKeys, gk)
+	}
+
+	if len(msg.EvaluationKeys) > 0 {
+		bundle.EvaluationKeys = make(map[KeyID]*rlwe.EvaluationKey, len(msg.EvaluationKeys))
+		for id, data := range msg.EvaluationKeys {
+			evk := new(rlwe.EvaluationKey)
+			if err := evk.UnmarshalBinary(data); err != nil {
+				return nil, err
+			}
+			bundle.EvaluationKeys[KeyID(id)] = evk
+		}
+	}
+
+	return bundle, nil
+}
+
+// NewEvalRequest prepara la petición de evaluar expression (sintaxis de ParseExpr) sobre las
+// entradas con nombre, con las claves públicas del cliente
+func NewEvalRequest(params Parameters, expression string, inputs map[string]PlaintextLabeledciphertext, keys *KeyBundle) (*labelingpb.EvalRequest, error) {
+	hash, err := params.Hash()
+	if err != nil {
+		return nil, err
+	}
+
+	req := &labelingpb.EvalRequest{
+		ParametersHash: hash[:],
+		Expression:     expression,
+		Inputs:         make(map[string]*labelingpb.LabeledCiphertext, len(inputs)),
+	}
+
+	for name, input := range inputs {
+		if req.Inputs[name], err = input.ToProto(params); err != nil {
+			return nil, err
+		}
+	}
+
+	if keys != nil {
+		if req.Keys, err = keys.ToProto(); err != nil {
+			return nil, err
+		}
+	}
+
+	return req, nil
+}
+
+// EvalProto atiende una EvalRequest: reconstruye las entradas y las claves, evalúa la expresión con
+// EvalExpr y devuelve el resultado. Los errores se devuelven en EvalResponse.Error.
+func EvalProto(params Parameters, req *labelingpb.EvalRequest, opts ...MultiplyOption) *labelingpb.EvalResponse {
+	result, err := evalProto(params, req, opts...)
+	if err != nil {
+		return &labelingpb.EvalResponse{Error: err.Error()}
+	}
+	return &labelingpb.EvalResponse{Result: result}
+}
+
+func evalProto(params Parameters, req *labelingpb.EvalRequest, opts ...MultiplyOption) (*labelingpb.LabeledCiphertext, error) {
+	if req == nil {
+		return nil, fmt.Errorf("labeling: petición de evaluación vacía")
+	}
+	if err := checkParametersHash(params, req.ParametersHash); err != nil {
+		return nil, err
+	}
+
+	inputs := make(map[string]PlaintextLabeledciphertext, len(req.Inputs))
+	for name, msg := range req.Inputs {
+		var err error
+		if inputs[name], err = LabeledciphertextFromProto[PlaintextElements](params, msg); err != nil {
+			return nil, fmt.Errorf("labeling: entrada %q: %w", name, err)
+		}
+	}
+
+	keys, err := KeyBundleFromProto(req.Keys)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := EvalWithBundle(params, req.Expression, inputs, keys, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return operandToProto(params, result)
+}
+
+// EvalWithBundle analiza la expresión source (sintaxis de ParseExpr) y la evalúa con EvalExpr
+// sobre las entradas con nombre, con la clave pública y las claves de evaluación de keys. Es la
+// evaluación común de los servicios, independiente del transporte; keys puede ser nil. Las
+// entradas y las claves se comprueban con Check antes de evaluar: una entrada mal formada o una
+// clave de otros parámetros devuelve ErrMalformed.
+func EvalWithBundle(params Parameters, source string, inputs map[string]PlaintextLabeledciphertext, keys *KeyBundle, opts ...MultiplyOption) (Operand, error) {
+	expr, err := ParseExpr(source)
+	if err != nil {
+		return nil, err
+	}
+
+	for name, input := range inputs {
+		if err := input.Check(params); err != nil {
+			return nil, fmt.Errorf("labeling: entrada %q: %w", name, err)
+		}
+	}
+
+	if keys == nil {
+		keys = new(KeyBundle)
+	}
+	if err := keys.Check(params); err != nil {
+		return nil, fmt.Errorf("labeling: claves: %w", err)
+	}
+
+	// Un *rlwe.PublicKey nil no debe llegar a EvalExpr como interfaz no nil
+	var key rlwe.EncryptionKey
+	if keys.PublicKey != nil {
+		key = keys.PublicKey
+	}
+
+	return EvalExpr(params, expr, inputs, key, keys.EvaluationKeySet(), opts...)
+}
@@ -0,0 +1,199 @@
+// Copyright 2025 Juan Martín Pérez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Pruebas de la conversión a protobuf y de la evaluación de los servicios con
+// claves de otros parámetros.
+
+package labeling
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/tuneinsight/lattigo/v6/core/rlwe"
+	"google.golang.org/protobuf/proto"
+
+	"main.go/labeling/labelingpb"
+)
+
+// evalInputs cifra dos entradas x e y con params
+func evalInputs(t *testing.T, params Parameters, pk rlwe.EncryptionKey) map[string]PlaintextLabeledciphertext {
+	t.Helper()
+
+	inputs := make(map[string]PlaintextLabeledciphertext)
+	for i, name := range []string{"x", "y"} {
+		lc, err := Encrypt(params, pk, broadcast(params, uint64(i+2)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		inputs[name] = lc
+	}
+	return inputs
+}
+
+func TestEvalWithBundle(t *testing.T) {
+	params, err := InsecureTestParameters()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sk, pk := GenerateKeyPair(params)
+	inputs := evalInputs(t, params, pk)
+	bundle := NewKeyBundle(pk.(*rlwe.PublicKey), GenerateMemEvaluationKeySet(GenerateRelinearizationKey(params, sk)))
+
+	result, err := EvalWithBundle(params, "x*y + 1", inputs, bundle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := Decrypt(params, sk, result.(PlaintextLabeledciphertext))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkValues(t, got, broadcast(params, 7))
+}
+
+func TestEvalWithBundleRejectsForeignKeys(t *testing.T) {
+	params, err := InsecureTestParameters()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, pk := GenerateKeyPair(params)
+	inputs := evalInputs(t, params, pk)
+
+	// Mismo anillo con otro número de primos de P, y otro anillo
+	morePrimes, err := NewParametersFromLiteral(10, []int{56, 55, 55, 54}, []int{55, 55}, 0x3ee0001, AllowInsecure())
+	if err != nil {
+		t.Fatal(err)
+	}
+	largerRing, err := NewParametersFromLiteral(11, []int{56, 55, 55, 54}, []int{55}, 0x3ee0001, AllowInsecure())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, other := range []Parameters{morePrimes, largerRing} {
+		sk, pk := GenerateKeyPair(other)
+		rlk := GenerateRelinearizationKey(other, sk)
+		galoisKeys := GenerateGaloisKeysForRotations(other, sk, []int{1})
+
+		bundles := map[string]*KeyBundle{
+			"clave pública":            {PublicKey: pk.(*rlwe.PublicKey)},
+			"clave de relinealización": {RelinearizationKey: rlk},
+			"claves de Galois":         {GaloisKeys: galoisKeys},
+		}
+		for name, bundle := range bundles {
+			_, err := EvalWithBundle(params, "x*y", inputs, bundle)
+			if !errors.Is(err, ErrMalformed) {
+				t.Fatalf("LogN = %d, %s: se esperaba ErrMalformed, se obtuvo %v", other.LogN(), name, err)
+			}
+
+			req, err := NewEvalRequest(params, "rot(x*y, 1)", inputs, bundle)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp := EvalProto(params, req); resp.Error == "" {
+				t.Fatalf("LogN = %d, %s: EvalProto aceptó claves de otros parámetros", other.LogN(), name)
+			}
+		}
+	}
+}
+
+func TestKeyBundleCheckGaloisElement(t *testing.T) {
+	params, err := InsecureTestParameters()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sk, _ := GenerateKeyPair(params)
+
+	galoisKeys := GenerateGaloisKeysForRotations(params, sk, []int{1})
+	bundle := &KeyBundle{GaloisKeys: galoisKeys}
+	if err := bundle.Check(params); err != nil {
+		t.Fatal(err)
+	}
+
+	galoisKeys[0].GaloisElement++
+	if err := bundle.Check(params); !errors.Is(err, ErrMalformed) {
+		t.Fatalf("se esperaba ErrMalformed con un elemento de Galois par, se obtuvo %v", err)
+	}
+}
+
+func TestLabeledciphertextFromProtoRoundTrip(t *testing.T) {
+	params, err := InsecureTestParameters()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sk, pk := GenerateKeyPair(params)
+	lc := evalInputs(t, params, pk)["x"]
+
+	msg, err := lc.ToProto(params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := proto.Marshal(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded := new(labelingpb.LabeledCiphertext)
+	if err := proto.Unmarshal(data, decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := LabeledciphertextFromProto[PlaintextElements](params, decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	values, err := decryptOperand(params, sk, got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkValues(t, values, broadcast(params, 2))
+}
+
+func TestLabeledciphertextFromProtoMalformed(t *testing.T) {
+	params, err := InsecureTestParameters()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, pk := GenerateKeyPair(params)
+	lc := evalInputs(t, params, pk)["x"]
+
+	cases := map[string]func(msg *labelingpb.LabeledCiphertext){
+		"sin términos":       func(msg *labelingpb.LabeledCiphertext) { msg.ElementsB = nil },
+		"término nulo":       func(msg *labelingpb.LabeledCiphertext) { msg.ElementsB[0] = nil },
+		"término sin βs":     func(msg *labelingpb.LabeledCiphertext) { msg.ElementsB[0].Betas = nil },
+		"β corrupto":         func(msg *labelingpb.LabeledCiphertext) { msg.ElementsB[0].Betas[0] = []byte{1, 2, 3} },
+		"forma incorrecta":   func(msg *labelingpb.LabeledCiphertext) { msg.Form = labelingpb.LabeledCiphertext_FORM_OVERFLOW },
+		"elementos A cortos": func(msg *labelingpb.LabeledCiphertext) { msg.ElementsA = msg.ElementsA[1:] },
+	}
+	for name, corrupt := range cases {
+		msg, err := lc.ToProto(params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		corrupt(msg)
+		if _, err := LabeledciphertextFromProto[PlaintextElements](params, msg); !errors.Is(err, ErrMalformed) {
+			t.Fatalf("%s: se esperaba ErrMalformed, se obtuvo %v", name, err)
+		}
+	}
+
+	if _, err := LabeledciphertextFromProto[PlaintextElements](params, nil); !errors.Is(err, ErrMalformed) {
+		t.Fatalf("mensaje nulo: se esperaba ErrMalformed, se obtuvo %v", err)
+	}
+}
+
+func TestKeyBundleFromProtoMalformed(t *testing.T) {
+	msg := &labelingpb.KeyBundle{GaloisKeys: []*labelingpb.GaloisKey{nil}}
+	if _, err := KeyBundleFromProto(msg); !errors.Is(err, ErrMalformed) {
+		t.Fatalf("se esperaba ErrMalformed, se obtuvo %v", err)
+	}
+}
@@ -0,0 +1,132 @@
+// Copyright 2025 Juan Martín Pérez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package labeling
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/tuneinsight/lattigo/v6/core/rlwe"
+)
+
+// ErrNoReEncryptionPath se devuelve cuando no hay ninguna cadena de claves de evaluación entre dos identidades
+var ErrNoReEncryptionPath = errors.New("labeling: no hay claves de evaluación entre las identidades")
+
+// Identity identifica al titular de una clave secreta en un ReEncryptor
+type Identity string
+
+// ReEncryptor guarda las claves de evaluación indexadas por el par (origen, destino) y recifra
+// labeled ciphertexts de una identidad a otra, encadenando claves si no hay una directa (A→B→C).
+// Es seguro para uso concurrente.
+type ReEncryptor struct {
+	params Parameters
+	mu     sync.RWMutex
+	keys   map[Identity]map[Identity]*rlwe.EvaluationKey
+}
+
+// NewReEncryptor crea un registro de claves de evaluación vacío
+func NewReEncryptor(params Parameters) *ReEncryptor {
+	return &ReEncryptor{params: params, keys: make(map[Identity]map[Identity]*rlwe.EvaluationKey)}
+}
+
+// Register guarda la clave de evaluación de from a to, sustituyendo la anterior si existía
+func (r *ReEncryptor) Register(from, to Identity, evk *rlwe.EvaluationKey) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.keys[from] == nil {
+		r.keys[from] = make(map[Identity]*rlwe.EvaluationKey)
+	}
+	r.keys[from][to] = evk
+}
+
+// Revoke elimina la clave de evaluación de from a to
+func (r *ReEncryptor) Revoke(from, to Identity) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.keys[from][to]; !ok {
+		return fmt.Errorf("%w: %s → %s", ErrKeyNotFound, from, to)
+	}
+	delete(r.keys[from], to)
+	return nil
+}
+
+// Path devuelve la cadena más corta de identidades de from a to, ambas incluidas
+func (r *ReEncryptor) Path(from, to Identity) ([]Identity, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.path(from, to)
+}
+
+// path busca en anchura la cadena más corta; requiere tener el cerrojo
+func (r *ReEncryptor) path(from, to Identity) ([]Identity, error) {
+	previous := map[Identity]Identity{from: from}
+	queue := []Identity{from}
+
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		if current == to {
+			path := []Identity{to}
+			for path[len(path)-1] != from {
+				path = append(path, previous[path[len(path)-1]])
+			}
+			slices.Reverse(path)
+			return path, nil
+		}
+
+		// Recorremos los destinos en orden para que el camino no dependa del orden de los mapas
+		next := make([]Identity, 0, len(r.keys[current]))
+		for identity := range r.keys[current] {
+			next = append(next, identity)
+		}
+		slices.Sort(next)
+
+		for _, identity := range next {
+			if _, seen := previous[identity]; !seen {
+				previous[identity] = current
+				queue = append(queue, identity)
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %s → %s", ErrNoReEncryptionPath, from, to)
+}
+
+// ReEncrypt recifra un labeled ciphertext de cualquier forma de la clave de from a la de to,
+// aplicando las claves de evaluación de la cadena más corta. El ruido crece con cada salto.
+func (r *ReEncryptor) ReEncrypt(labeledciphertext Operand, from, to Identity) (Operand, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	path, err := r.path(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	result := labeledciphertext
+	for i := 1; i < len(path); i++ {
+		if result, err = applyEvaluationKeyOperand(r.params, r.keys[path[i-1]][path[i]], result); err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
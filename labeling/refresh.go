@@ -0,0 +1,194 @@
+// Copyright 2025 Juan Martín Pérez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Refresco colectivo.
+//
+// Sustituye al bootstrapping cuando la clave es conjunta: las partes llevan un labeled ciphertext
+// casi agotado de vuelta al nivel máximo sin que ninguna lo descifre sola. El protocolo tiene dos
+// rondas:
+//
+//  1. Cada parte i cifra con la clave pública conjunta una máscara rᵢ uniforme en Z_t, en el nivel
+//     máximo, y la envía al combinador, que la suma al labeled ciphertext (Mask).
+//  2. Las partes descifran colectivamente el resultado, y el combinador obtiene m + R con
+//     R = Σ rᵢ, que no revela m mientras una parte sea honesta. El nuevo labeled ciphertext es
+//     (m + R, −Σ Enc(rᵢ)), con β en el nivel máximo (Finish).
+//
+// El resultado siempre está en forma plaintext, de modo que también sirve para refrescar la forma
+// overflow, una vez plegados sus βs en α con Compact: R solo enmascara α, y descifrar cada β por
+// separado revelaría las máscaras de las entradas.
+
+package labeling
+
+import (
+	"fmt"
+
+	"github.com/tuneinsight/lattigo/v6/core/rlwe"
+	"github.com/tuneinsight/lattigo/v6/schemes/bgv"
+)
+
+// RefreshMask es la máscara cifrada Enc(rᵢ) de una parte en el refresco colectivo
+type RefreshMask struct {
+	ct *rlwe.Ciphertext
+}
+
+// CollectiveRefresh coordina el refresco de labeled ciphertexts cifrados con una clave conjunta
+type CollectiveRefresh struct {
+	params  Parameters
+	pk      *rlwe.PublicKey
+	decrypt *CollectiveDecrypt
+}
+
+// NewCollectiveRefresh inicia el protocolo para la clave pública conjunta pk. sigma es la
+// desviación del ruido de inundación del descifrado colectivo, como en NewCollectiveDecrypt.
+func NewCollectiveRefresh(params Parameters, pk *rlwe.PublicKey, sigma float64) (*CollectiveRefresh, error) {
+	decrypt, err := NewCollectiveDecrypt(params, sigma)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CollectiveRefresh{params: params, pk: pk, decrypt: decrypt}, nil
+}
+
+// GenMask es el primer paso de cada parte: cifra una máscara uniforme en el nivel máximo.
+// La parte no necesita conservar rᵢ.
+func (c *CollectiveRefresh) GenMask() (RefreshMask, error) {
+	offsets, err := sampleOffsets(c.params.MaxSlots(), c.params.PlaintextModulus())
+	if err != nil {
+		return RefreshMask{}, err
+	}
+
+	pt := bgv.NewPlaintext(c.params.Parameters, c.params.MaxLevel())
+	if err := bgv.NewEncoder(c.params.Parameters).Encode(offsets, pt); err != nil {
+		return RefreshMask{}, err
+	}
+
+	ct, err := rlwe.NewEncryptor(c.params, c.pk).EncryptNew(pt)
+	if err != nil {
+		return RefreshMask{}, err
+	}
+
+	return RefreshMask{ct: ct}, nil
+}
+
+// sumMasks devuelve Σ Enc(rᵢ) en el nivel indicado
+func (c *CollectiveRefresh) sumMasks(masks []RefreshMask, level int) (*rlwe.Ciphertext, error) {
+	evaluator := bgv.NewEvaluator(c.params.Parameters, nil)
+
+	var sum *rlwe.Ciphertext
+	for _, mask := range masks {
+		if mask.ct == nil || mask.ct.Level() < level {
+			return nil, fmt.Errorf("labeling: máscara de refresco no válida")
+		}
+
+		ct := mask.ct.CopyNew()
+		ct.Resize(ct.Degree(), level)
+		if sum == nil {
+			sum = ct
+			continue
+		}
+		if err := evaluator.Add(sum, ct, sum); err != nil {
+			return nil, err
+		}
+	}
+
+	return sum, nil
+}
+
+// Mask es el primer paso del combinador: suma las máscaras de todas las partes al labeled ciphertext,
+// en β si está en forma plaintext o en α si está en forma overflow, que no debe tener términos de β.
+// Las partes descifran después colectivamente el resultado con PartialDecrypt.
+func (c *CollectiveRefresh) Mask(labeledciphertext Operand, masks []RefreshMask) (Operand, error) {
+	if len(masks) == 0 {
+		return nil, fmt.Errorf("labeling: CollectiveRefresh requiere al menos una máscara")
+	}
+
+	evaluator := bgv.NewEvaluator(c.params.Parameters, nil)
+
+	switch lc := labeledciphertext.(type) {
+	case PlaintextLabeledciphertext:
+		if len(lc.elementsB) != 1 || len(lc.elementsB[0]) != 1 {
+			return nil, fmt.Errorf("labeling: CollectiveRefresh requiere un único β en forma plaintext")
+		}
+
+		sum, err := c.sumMasks(masks, lc.elementsB[0][0].Level())
+		if err != nil {
+			return nil, err
+		}
+		beta, err := evaluator.AddNew(&lc.elementsB[0][0], sum)
+		if err != nil {
+			return nil, err
+		}
+
+		lc.elementsB = [][]rlwe.Ciphertext{{*beta}}
+		return lc, nil
+
+	case CiphertextLabeledciphertext:
+		if len(lc.elementsB) > 0 {
+			return nil, fmt.Errorf("%w: CollectiveRefresh solo enmascara α y la forma overflow tiene %d términos de β; pliégalos en α con Compact", ErrUnsupportedOperands, len(lc.elementsB))
+		}
+		alpha := (*rlwe.Ciphertext)(lc.elementsA)
+
+		sum, err := c.sumMasks(masks, alpha.Level())
+		if err != nil {
+			return nil, err
+		}
+		masked, err := evaluator.AddNew(alpha, sum)
+		if err != nil {
+			return nil, err
+		}
+
+		lc.elementsA = (*CiphertextElement)(masked)
+		return lc, nil
+	}
+
+	return nil, fmt.Errorf("%w: refresco colectivo de %T", ErrUnsupportedOperands, labeledciphertext)
+}
+
+// PartialDecrypt es el segundo paso de cada parte: su descifrado parcial del labeled ciphertext
+// enmascarado devuelto por Mask
+func (c *CollectiveRefresh) PartialDecrypt(sk *rlwe.SecretKey, masked Operand) (DecryptionShare, error) {
+	return c.decrypt.PartialDecrypt(sk, masked)
+}
+
+// Finish es el segundo paso del combinador: obtiene m + R de los descifrados parciales y devuelve
+// el PlaintextLabeledciphertext (m + R, −Σ Enc(rᵢ)) en el nivel máximo y con la profundidad a 0
+func (c *CollectiveRefresh) Finish(masked Operand, shares []DecryptionShare, masks []RefreshMask) (PlaintextLabeledciphertext, error) {
+	values, err := c.decrypt.Combine(masked, shares)
+	if err != nil {
+		return PlaintextLabeledciphertext{}, err
+	}
+
+	sum, err := c.sumMasks(masks, c.params.MaxLevel())
+	if err != nil {
+		return PlaintextLabeledciphertext{}, err
+	}
+
+	var meta metadata
+	switch lc := masked.(type) {
+	case PlaintextLabeledciphertext:
+		meta = lc.meta
+	case CiphertextLabeledciphertext:
+		meta = lc.meta
+	}
+
+	var result PlaintextLabeledciphertext
+	result.elementsA = PlaintextElements(values)
+	result.elementsB = [][]rlwe.Ciphertext{{*negateCiphertext(c.params, sum)}}
+
+	// β es un cifrado nuevo en el nivel máximo, así que la profundidad vuelve a empezar
+	result.meta = deriveMetadata("CollectiveRefresh", false, meta)
+	result.meta.multiplications = 0
+
+	return result, nil
+}
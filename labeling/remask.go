@@ -0,0 +1,189 @@
+// Copyright 2025 Juan Martín Pérez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Reenmascarado de labeled ciphertexts en forma overflow.
+//
+// Un CiphertextLabeledciphertext no puede volver a la forma plaintext sin descifrar, así que la
+// conversión es un protocolo de dos mensajes con el propietario de la clave secreta, como el cambio
+// de esquema:
+//
+//  1. El evaluador suma a α una máscara aleatoria r que solo él conoce y envía el labeled
+//     ciphertext de m + r al propietario.
+//  2. El propietario lo descifra y responde con m + r, que no revela m, en una de dos formas:
+//     cifrándolo de nuevo como PlaintextLabeledciphertext (AnswerRemask), o en claro si el evaluador
+//     ya tiene un cifrado Enc(b) de una máscara que solo conoce el propietario (AnswerRemaskWithMask),
+//     en cuyo caso la petición incluye el término −Enc(b) y el propietario descifra m − b + r.
+//  3. El evaluador resta r de los elementos A del resultado.
+//
+// Cada Enc(b) solo puede usarse en un reenmascarado: con dos resultados a1 = m1 − b y a2 = m2 − b,
+// el evaluador obtendría a1 − a2 = m1 − m2. RemaskingMask lo hace cumplir como PreparedMask.
+//
+// El resultado tiene un β recién cifrado, así que el circuito puede seguir con Mult sin límite de profundidad.
+
+package labeling
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/tuneinsight/lattigo/v6/core/rlwe"
+	"github.com/tuneinsight/lattigo/v6/schemes/bgv"
+)
+
+// ErrMaskReused se devuelve al usar por segunda vez una máscara de reenmascarado
+var ErrMaskReused = errors.New("labeling: máscara de reenmascarado ya usada")
+
+// RemaskingMask es un cifrado Enc(b) de una máscara que solo conoce el propietario, para
+// NewRemaskWithMask. Cada RemaskingMask solo puede usarse una vez; como con PreparedMask, la
+// comprobación es por objeto, así que quien lo deserialice no debe decodificar el mismo mensaje dos veces.
+type RemaskingMask struct {
+	beta rlwe.Ciphertext
+	used atomic.Bool
+}
+
+// MarshalBinary serializa el cifrado de la máscara para enviarlo al evaluador
+func (m *RemaskingMask) MarshalBinary() ([]byte, error) {
+	return m.beta.MarshalBinary()
+}
+
+// UnmarshalBinary reconstruye el cifrado de la máscara, sin usar
+func (m *RemaskingMask) UnmarshalBinary(data []byte) error {
+	m.used.Store(false)
+	return m.beta.UnmarshalBinary(data)
+}
+
+// Remask guarda el estado del evaluador durante el reenmascarado
+type Remask struct {
+	offsets []uint64
+	mask    *rlwe.Ciphertext
+	meta    metadata
+}
+
+// NewRemask inicia el reenmascarado de un CiphertextLabeledciphertext.
+// Devuelve el estado del evaluador y la petición m + r que debe enviarse al propietario de la clave.
+func NewRemask(params Parameters, labeledciphertext CiphertextLabeledciphertext) (*Remask, CiphertextLabeledciphertext, error) {
+	offsets, err := sampleOffsets(params.MaxSlots(), params.PlaintextModulus())
+	if err != nil {
+		return nil, CiphertextLabeledciphertext{}, err
+	}
+
+	// α ← α + r
+	request, err := AddConstVector(params, labeledciphertext, offsets)
+	if err != nil {
+		return nil, CiphertextLabeledciphertext{}, err
+	}
+
+	return &Remask{offsets: offsets, meta: labeledciphertext.meta}, request, nil
+}
+
+// NewRemaskWithMask inicia el reenmascarado con un cifrado mask = Enc(b) de una máscara que solo
+// conoce el propietario, el devuelto por RemaskMask. La petición es m − b + r y el propietario
+// responde en claro, sin cifrar nada en línea. Cada máscara solo puede usarse una vez: la segunda
+// devuelve ErrMaskReused.
+func NewRemaskWithMask(params Parameters, labeledciphertext CiphertextLabeledciphertext, mask *RemaskingMask) (*Remask, CiphertextLabeledciphertext, error) {
+	if mask.used.Swap(true) {
+		return nil, CiphertextLabeledciphertext{}, ErrMaskReused
+	}
+
+	remask, request, err := NewRemask(params, labeledciphertext)
+	if err != nil {
+		return nil, request, err
+	}
+
+	// β ← [β..., −Enc(b)]
+	elementsB := make([][]rlwe.Ciphertext, len(request.elementsB), len(request.elementsB)+1)
+	copy(elementsB, request.elementsB)
+	request.elementsB = append(elementsB, []rlwe.Ciphertext{*negateCiphertext(params, &mask.beta)})
+
+	remask.mask = &mask.beta
+
+	return remask, request, nil
+}
+
+// RemaskMask es el paso previo del propietario para NewRemaskWithMask: devuelve Enc(b) para una
+// máscara b uniforme en Z_t que no necesita guardar
+func RemaskMask(params Parameters, key rlwe.EncryptionKey) (*RemaskingMask, error) {
+	masks, err := sampleOffsets(params.MaxSlots(), params.PlaintextModulus())
+	if err != nil {
+		return nil, err
+	}
+
+	pt := bgv.NewPlaintext(params.Parameters, params.MaxLevel())
+	if err := bgv.NewEncoder(params.Parameters).Encode(masks, pt); err != nil {
+		return nil, err
+	}
+
+	beta, err := rlwe.NewEncryptor(params, key).EncryptNew(pt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RemaskingMask{beta: *beta}, nil
+}
+
+// AnswerRemask es el paso del propietario de la clave: descifra m + r y lo cifra como PlaintextLabeledciphertext
+func AnswerRemask(params Parameters, sk *rlwe.SecretKey, key rlwe.EncryptionKey, request CiphertextLabeledciphertext) (PlaintextLabeledciphertext, error) {
+	shifted, err := DecryptOverflow(params, sk, request)
+	if err != nil {
+		return PlaintextLabeledciphertext{}, err
+	}
+
+	return Encrypt(params, key, shifted)
+}
+
+// AnswerRemaskWithMask es el paso del propietario de la clave con máscara: descifra y devuelve m − b + r en claro
+func AnswerRemaskWithMask(params Parameters, sk *rlwe.SecretKey, request CiphertextLabeledciphertext) ([]uint64, error) {
+	return DecryptOverflow(params, sk, request)
+}
+
+// finish resta las máscaras del evaluador a los elementos A y construye el resultado con β
+func (r *Remask) finish(params Parameters, elementsA PlaintextElements, beta rlwe.Ciphertext) PlaintextLabeledciphertext {
+	var result PlaintextLabeledciphertext
+
+	t := params.PlaintextModulus()
+	result.elementsA = make(PlaintextElements, len(elementsA))
+	for i, elementA := range elementsA {
+		result.elementsA[i] = elementA % t
+		if i < len(r.offsets) {
+			result.elementsA[i] = (result.elementsA[i] + t - r.offsets[i]) % t
+		}
+	}
+	result.elementsB = [][]rlwe.Ciphertext{{beta}}
+
+	// El β es un cifrado nuevo, así que la profundidad vuelve a empezar
+	result.meta = deriveMetadata("Remask", false, r.meta)
+	result.meta.multiplications = 0
+
+	return result
+}
+
+// Finish resta las máscaras del evaluador y devuelve el PlaintextLabeledciphertext de m
+func (r *Remask) Finish(params Parameters, response PlaintextLabeledciphertext) (PlaintextLabeledciphertext, error) {
+	if r.mask != nil {
+		return PlaintextLabeledciphertext{}, fmt.Errorf("labeling: el reenmascarado con máscara se termina con FinishWithMask")
+	}
+
+	return r.finish(params, response.elementsA, response.elementsB[0][0]), nil
+}
+
+// FinishWithMask resta las máscaras del evaluador a m − b + r y devuelve el PlaintextLabeledciphertext
+// (m − b, Enc(b))
+func (r *Remask) FinishWithMask(params Parameters, response []uint64) (PlaintextLabeledciphertext, error) {
+	if r.mask == nil {
+		return PlaintextLabeledciphertext{}, fmt.Errorf("labeling: el reenmascarado sin máscara se termina con Finish")
+	}
+
+	return r.finish(params, response, *r.mask), nil
+}
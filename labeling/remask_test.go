@@ -0,0 +1,130 @@
+// Copyright 2025 Juan Martín Pérez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Pruebas del reenmascarado de labeled ciphertexts en forma overflow.
+
+package labeling
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/tuneinsight/lattigo/v6/core/rlwe"
+)
+
+// overflowProduct cifra x e y y devuelve x·y en forma overflow junto con el valor esperado
+func overflowProduct(t *testing.T, params Parameters, pk rlwe.EncryptionKey, evk *rlwe.MemEvaluationKeySet) (CiphertextLabeledciphertext, []uint64) {
+	t.Helper()
+
+	x, err := Encrypt(params, pk, broadcast(params, 6))
+	if err != nil {
+		t.Fatal(err)
+	}
+	y, err := Encrypt(params, pk, broadcast(params, 7))
+	if err != nil {
+		t.Fatal(err)
+	}
+	product, err := MultOverflow(params, x, y, pk, evk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return product, broadcast(params, 42)
+}
+
+func TestRemask(t *testing.T) {
+	params, err := InsecureTestParameters()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sk, pk := GenerateKeyPair(params)
+	evk := GenerateMemEvaluationKeySet(GenerateRelinearizationKey(params, sk))
+	lc, want := overflowProduct(t, params, pk, evk)
+
+	remask, request, err := NewRemask(params, lc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	response, err := AnswerRemask(params, sk, pk, request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := remask.Finish(params, response)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := Decrypt(params, sk, result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkValues(t, got, want)
+}
+
+func TestRemaskWithMask(t *testing.T) {
+	params, err := InsecureTestParameters()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sk, pk := GenerateKeyPair(params)
+	evk := GenerateMemEvaluationKeySet(GenerateRelinearizationKey(params, sk))
+	lc, want := overflowProduct(t, params, pk, evk)
+
+	mask, err := RemaskMask(params, pk)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// La máscara viaja serializada hasta el evaluador
+	data, err := mask.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	received := new(RemaskingMask)
+	if err := received.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+
+	remask, request, err := NewRemaskWithMask(params, lc, received)
+	if err != nil {
+		t.Fatal(err)
+	}
+	response, err := AnswerRemaskWithMask(params, sk, request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := remask.FinishWithMask(params, response)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := Decrypt(params, sk, result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkValues(t, got, want)
+
+	// Una segunda petición con la misma máscara revelaría la diferencia entre los dos valores
+	if _, _, err := NewRemaskWithMask(params, lc, received); !errors.Is(err, ErrMaskReused) {
+		t.Fatalf("se esperaba ErrMaskReused, se obtuvo %v", err)
+	}
+
+	// b es uniforme en Z_t: a = m − b no se concentra cerca de m
+	near := 0
+	for _, elementA := range result.elementsA {
+		if diff := (want[0] + params.PlaintextModulus() - elementA) % params.PlaintextModulus(); diff < 1<<14 {
+			near++
+		}
+	}
+	if near > len(result.elementsA)/8 {
+		t.Fatalf("%d de %d elementos A quedan cerca de m", near, len(result.elementsA))
+	}
+}
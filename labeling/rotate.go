@@ -0,0 +1,79 @@
+// Copyright 2025 Juan Martín Pérez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package labeling
+
+import (
+	"fmt"
+
+	"github.com/tuneinsight/lattigo/v6/core/rlwe"
+	"github.com/tuneinsight/lattigo/v6/ring/ringqp"
+	"github.com/tuneinsight/lattigo/v6/schemes/bgv"
+)
+
+// rotateHoisted devuelve las rotaciones de columnas de ct por cada k de ks, descomponiendo una única
+// vez su segundo polinomio en la base RNS y reutilizando la descomposición en todas las rotaciones
+func rotateHoisted(params Parameters, evaluator *bgv.Evaluator, ct *rlwe.Ciphertext, ks []int) ([]*rlwe.Ciphertext, error) {
+	if ct.Degree() != 1 {
+		return nil, fmt.Errorf("labeling: la rotación con hoisting requiere cifrados de grado 1, no %d", ct.Degree())
+	}
+
+	levelQ, levelP := ct.Level(), params.MaxLevelP()
+
+	ringQP := params.RingQP().AtLevel(levelQ, levelP)
+	decomposition := make([]ringqp.Poly, params.BaseRNSDecompositionVectorSize(levelQ, levelP))
+	for i := range decomposition {
+		decomposition[i] = ringQP.NewPoly()
+	}
+	evaluator.DecomposeNTT(levelQ, levelP, levelP+1, ct.Value[1], ct.IsNTT, decomposition)
+
+	rotated := make([]*rlwe.Ciphertext, len(ks))
+	for i, k := range ks {
+		if k == 0 {
+			rotated[i] = ct.CopyNew()
+			continue
+		}
+
+		rotated[i] = rlwe.NewCiphertext(params, 1, levelQ)
+		if err := evaluator.AutomorphismHoisted(levelQ, ct, decomposition, params.GaloisElementForColRotation(k), rotated[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	return rotated, nil
+}
+
+// RotateColumnsMany devuelve todas las rotaciones de columnas de ks de un PlaintextLabeledciphertext,
+// en el mismo orden. La descomposición de β se calcula una sola vez (hoisting), lo que es mucho más
+// rápido que llamar a RotateColumns en un bucle. Admite desplazamientos negativos.
+func RotateColumnsMany(params Parameters, labeledciphertext PlaintextLabeledciphertext, ks []int, evk *rlwe.MemEvaluationKeySet) ([]PlaintextLabeledciphertext, error) {
+	normalized := make([]int, len(ks))
+	for i, k := range ks {
+		normalized[i] = normalizeRotation(params, k)
+	}
+
+	betas, err := rotateHoisted(params, bgv.NewEvaluator(params.Parameters, evk), &labeledciphertext.elementsB[0][0], normalized)
+	if err != nil {
+		return nil, err
+	}
+
+	rotations := make([]PlaintextLabeledciphertext, len(ks))
+	for i, k := range normalized {
+		rotations[i].elementsA = rotateColumnsElementsA(params, labeledciphertext.elementsA, k)
+		rotations[i].elementsB = [][]rlwe.Ciphertext{{*betas[i]}}
+		rotations[i].meta = deriveMetadata("RotateColumnsMany", false, labeledciphertext.meta)
+	}
+
+	return rotations, nil
+}
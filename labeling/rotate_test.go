@@ -0,0 +1,236 @@
+// Copyright 2025 Juan Martín Pérez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Pruebas de las rotaciones en forma plaintext y en forma overflow con varios βs de grados
+// distintos.
+
+package labeling
+
+import (
+	"testing"
+
+	"github.com/tuneinsight/lattigo/v6/core/rlwe"
+)
+
+// rotationColumns son los desplazamientos de columnas que se prueban, negativos incluidos
+var rotationColumns = []int{1, 5, -3}
+
+// mixedDegreeOverflow cifra seis vectores y calcula x·y + (z·w)·(u·y) + s en forma overflow, de
+// modo que los elementos B mezclan términos de un β, de dos y de cuatro. Devuelve también los valores
+// esperados en claro.
+func mixedDegreeOverflow(t *testing.T, params Parameters, pk rlwe.EncryptionKey, evk *rlwe.MemEvaluationKeySet) (CiphertextLabeledciphertext, []uint64) {
+	t.Helper()
+
+	slots := params.MaxSlots()
+	t64 := params.PlaintextModulus()
+	inputs := make([][]uint64, 6)
+	ciphertexts := make([]PlaintextLabeledciphertext, 6)
+	for i := range inputs {
+		inputs[i] = make([]uint64, slots)
+		for j := range inputs[i] {
+			inputs[i][j] = uint64(7*i+j*(i+1)+1) % t64
+		}
+		var err error
+		if ciphertexts[i], err = Encrypt(params, pk, inputs[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	x, y, z, w, u, s := ciphertexts[0], ciphertexts[1], ciphertexts[2], ciphertexts[3], ciphertexts[4], ciphertexts[5]
+
+	xy, err := MultOverflow(params, x, y, pk, evk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw, err := MultOverflow(params, z, w, pk, evk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	uy, err := MultOverflow(params, u, y, pk, evk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zwuy, err := MultOverflowCiphertext(params, zw, uy, evk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lc, err := SumOverflowCiphertext(params, xy, zwuy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lc, err = SumOverflow(params, lc, s); err != nil {
+		t.Fatal(err)
+	}
+
+	degrees := make(map[int]bool)
+	for _, term := range lc.elementsB {
+		degrees[len(term)] = true
+	}
+	if !degrees[1] || !degrees[2] || !degrees[4] {
+		t.Fatalf("se esperaban términos de grado 1, 2 y 4, hay %v", degrees)
+	}
+
+	values := make([]uint64, slots)
+	for j := range values {
+		xy := inputs[0][j] * inputs[1][j] % t64
+		zw := inputs[2][j] * inputs[3][j] % t64
+		uy := inputs[4][j] * inputs[1][j] % t64
+		values[j] = (xy + zw*uy%t64 + inputs[5][j]) % t64
+	}
+
+	return lc, values
+}
+
+// rotationKeys genera las claves de relinealización y de Galois para rotationColumns y el
+// intercambio de filas
+func rotationKeys(params Parameters, sk *rlwe.SecretKey) *rlwe.MemEvaluationKeySet {
+	galEls := append(ColumnRotationGaloisElements(params, rotationColumns...), params.GaloisElementForRowRotation())
+	return GenerateMemEvaluationKeySetWithGalois(GenerateRelinearizationKey(params, sk), GenerateGaloisKeys(params, sk, galEls)...)
+}
+
+// checkOverflow descifra lc y lo compara con want
+func checkOverflow(t *testing.T, params Parameters, sk *rlwe.SecretKey, lc CiphertextLabeledciphertext, want []uint64) {
+	t.Helper()
+
+	got, err := DecryptOverflow(params, sk, lc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkValues(t, got, want)
+}
+
+// rotatedColumns rota cada fila de values k posiciones a la izquierda, como RotateColumns
+func rotatedColumns(values []uint64, k int) []uint64 {
+	half := len(values) / 2
+	result := make([]uint64, len(values))
+	for i := range half {
+		source := ((i+k)%half + half) % half
+		result[i] = values[source]
+		result[half+i] = values[half+source]
+	}
+	return result
+}
+
+// rotatedRows intercambia las dos filas de values, como RotateRows
+func rotatedRows(values []uint64) []uint64 {
+	half := len(values) / 2
+	return append(append([]uint64{}, values[half:]...), values[:half]...)
+}
+
+func TestRotateColumnsOverflow(t *testing.T) {
+	params, err := InsecureTestParameters()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sk, pk := GenerateKeyPair(params)
+	evk := rotationKeys(params, sk)
+	lc, values := mixedDegreeOverflow(t, params, pk, evk)
+	checkOverflow(t, params, sk, lc, values)
+
+	for _, k := range rotationColumns {
+		rotated, err := RotateColumnsOverflow(params, lc, k, evk)
+		if err != nil {
+			t.Fatalf("k = %d: %v", k, err)
+		}
+		if rotated.Terms() != lc.Terms() || rotated.Degree() != lc.Degree() {
+			t.Fatalf("k = %d: la rotación cambia la forma de los βs", k)
+		}
+		checkOverflow(t, params, sk, rotated, rotatedColumns(values, k))
+	}
+
+	// Las rotaciones se componen: 5 y después −3 equivale a 2
+	rotated, err := RotateColumnsOverflow(params, lc, 5, evk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rotated, err = RotateColumnsOverflow(params, rotated, -3, evk); err != nil {
+		t.Fatal(err)
+	}
+	checkOverflow(t, params, sk, rotated, rotatedColumns(values, 2))
+}
+
+func TestRotateRowsOverflow(t *testing.T) {
+	params, err := InsecureTestParameters()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sk, pk := GenerateKeyPair(params)
+	evk := rotationKeys(params, sk)
+	lc, values := mixedDegreeOverflow(t, params, pk, evk)
+
+	rotated, err := RotateRowsOverflow(params, lc, evk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rotated.Terms() != lc.Terms() || rotated.Degree() != lc.Degree() {
+		t.Fatal("la rotación cambia la forma de los βs")
+	}
+	checkOverflow(t, params, sk, rotated, rotatedRows(values))
+
+	// Dos rotaciones de filas devuelven el vector original
+	if rotated, err = RotateRowsOverflow(params, rotated, evk); err != nil {
+		t.Fatal(err)
+	}
+	checkOverflow(t, params, sk, rotated, values)
+
+	// Rotación de columnas seguida de rotación de filas
+	columns, err := RotateColumnsOverflow(params, lc, 1, evk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rotated, err = RotateRowsOverflow(params, columns, evk); err != nil {
+		t.Fatal(err)
+	}
+	checkOverflow(t, params, sk, rotated, rotatedRows(rotatedColumns(values, 1)))
+}
+
+func TestRotatePlaintextForm(t *testing.T) {
+	params, err := InsecureTestParameters()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sk, pk := GenerateKeyPair(params)
+	evk := rotationKeys(params, sk)
+
+	values := make([]uint64, params.MaxSlots())
+	for i := range values {
+		values[i] = uint64(3*i+1) % params.PlaintextModulus()
+	}
+	lc, err := Encrypt(params, pk, values)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Los elementos A se permutan en claro y β con el automorfismo; ambos deben coincidir
+	for _, k := range rotationColumns {
+		rotated, err := RotateColumns(params, lc, k, evk)
+		if err != nil {
+			t.Fatalf("k = %d: %v", k, err)
+		}
+		got, err := decryptOperand(params, sk, rotated)
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkValues(t, got, rotatedColumns(values, k))
+	}
+
+	rotated, err := RotateRows(params, lc, evk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := decryptOperand(params, sk, rotated)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkValues(t, got, rotatedRows(values))
+}
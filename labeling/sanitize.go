@@ -0,0 +1,261 @@
+// Copyright 2025 Juan Martín Pérez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Saneamiento de labeled ciphertexts.
+//
+// El ruido de un cifrado y los elementos A en texto plano dependen de las operaciones que lo
+// produjeron, así que un resultado devuelto tal cual puede revelar la estructura del circuito.
+// Sanitize suma a cada componente cifrada un cifrado de cero con la clave pública y un ruido de
+// inundación mucho mayor que el acumulado, que lo oculta estadísticamente. En forma plaintext
+// además reenmascara los elementos A: a' = a + r y β' = β + Enc(−r), con r uniforme.
+//
+// La forma de los elementos B en forma overflow (número de términos y de βs por término) no se
+// oculta; si es necesario, el resultado puede compactarse o reenmascararse antes.
+//
+// FloodNoise es la variante para el propietario de la clave: ajusta el ruido de inundación al ruido
+// medido y a un nivel de seguridad estadística antes de entregar un valor intermedio.
+
+package labeling
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+
+	"github.com/tuneinsight/lattigo/v6/core/rlwe"
+	"github.com/tuneinsight/lattigo/v6/ring"
+	"github.com/tuneinsight/lattigo/v6/schemes/bgv"
+	"github.com/tuneinsight/lattigo/v6/utils/sampling"
+)
+
+// DefaultFloodingSigma es la desviación por defecto del ruido de inundación
+const DefaultFloodingSigma = 1 << 30
+
+// SanitizeOption modifica el ruido de inundación de Sanitize
+type SanitizeOption func(*sanitizeOptions)
+
+type sanitizeOptions struct {
+	sigma float64
+}
+
+// WithFloodingSigma fija la desviación del ruido de inundación. Para λ bits de seguridad
+// estadística debe superar en λ bits al ruido del cifrado, sin agotar el presupuesto de ruido.
+func WithFloodingSigma(sigma float64) SanitizeOption {
+	return func(o *sanitizeOptions) {
+		o.sigma = sigma
+	}
+}
+
+// floodingSampler muestrea el ruido de inundación gaussiano de desviación sigma
+type floodingSampler struct {
+	params  Parameters
+	sampler ring.Sampler
+}
+
+// newFloodingSampler crea el muestreador de ruido de inundación
+func newFloodingSampler(params Parameters, sigma float64) (*floodingSampler, error) {
+	prng, err := sampling.NewPRNG()
+	if err != nil {
+		return nil, err
+	}
+
+	sampler, err := ring.NewSampler(prng, params.RingQ(), ring.DiscreteGaussian{Sigma: sigma, Bound: math.Ceil(6 * sigma)}, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return &floodingSampler{params: params, sampler: sampler}, nil
+}
+
+// flood suma ruido de inundación al primer polinomio de ct, en su mismo dominio
+func (f *floodingSampler) flood(ct *rlwe.Ciphertext) {
+	ringQ := f.params.RingQ().AtLevel(ct.Level())
+
+	noise := f.sampler.AtLevel(ct.Level()).ReadNew()
+	if ct.IsNTT {
+		ringQ.NTT(noise, noise)
+	}
+	ringQ.Add(ct.Value[0], noise, ct.Value[0])
+}
+
+// Sanitize rerandomiza todas las componentes cifradas del labeled ciphertext, y en forma plaintext
+// también los elementos A, para que el resultado no revele nada sobre las operaciones que lo
+// produjeron. Los metadatos se reinician: solo conservan las etiquetas y una única entrada
+// "Sanitize" en el historial, sin profundidad ni límites de forma heredados.
+//
+// Consume el presupuesto de ruido equivalente a sigma. Sin la clave secreta no puede medirse el
+// ruido acumulado, así que se comprueba que el ruido de inundación, más el de un cifrado nuevo, cabe
+// en el módulo del nivel de cada componente; si no, devuelve ErrFloodingBudget. Es una condición
+// necesaria: un ruido acumulado cercano al límite puede seguir impidiendo el descifrado, y en ese
+// caso el propietario de la clave debe usar FloodNoise, que lo mide.
+func Sanitize[T PlaintextElements | *CiphertextElement](params Parameters, pk rlwe.EncryptionKey, labeledciphertext Labeledciphertext[T], opts ...SanitizeOption) (Labeledciphertext[T], error) {
+	options := sanitizeOptions{sigma: DefaultFloodingSigma}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	// El resultado suma al ruido de cada componente el de Enc(0) y el de inundación
+	freshBits := math.Log2(2 * 6 * math.Sqrt(params.NoiseFreshPK()))
+	for i, ct := range labeledciphertext.ciphertexts() {
+		if err := checkFloodingBudget(params, fmt.Sprintf("la componente %d", i), freshBits, capacityBits(params, ct.Level()), options.sigma); err != nil {
+			return labeledciphertext, err
+		}
+	}
+
+	flooding, err := newFloodingSampler(params, options.sigma)
+	if err != nil {
+		return labeledciphertext, err
+	}
+
+	encryptor := rlwe.NewEncryptor(params, pk)
+	evaluator := bgv.NewEvaluator(params.Parameters, nil)
+
+	// ct + Enc(0) + e con e de desviación sigma
+	rerandomize := func(ct *rlwe.Ciphertext) (*rlwe.Ciphertext, error) {
+		// Enc(0) debe llevar los metadatos de ct (dominio, escala, codificación) para poder sumarse
+		zero := rlwe.NewCiphertext(params, 1, ct.Level())
+		*zero.MetaData = *ct.MetaData
+		if err := encryptor.EncryptZero(zero); err != nil {
+			return nil, err
+		}
+		flooding.flood(zero)
+		return evaluator.AddNew(ct, zero)
+	}
+
+	var sanitized Labeledciphertext[T]
+
+	switch elementsA := any(labeledciphertext.elementsA).(type) {
+	case PlaintextElements:
+		t := params.PlaintextModulus()
+		offsets, err := sampleOffsets(len(elementsA), t)
+		if err != nil {
+			return labeledciphertext, err
+		}
+
+		// a' ← a + r
+		sanitizedA := make(PlaintextElements, len(elementsA))
+		negatedOffsets := make([]uint64, params.MaxSlots())
+		for i, elementA := range elementsA {
+			sanitizedA[i] = (elementA + offsets[i]) % t
+			if i < len(negatedOffsets) {
+				negatedOffsets[i] = (t - offsets[i]) % t
+			}
+		}
+
+		// β' ← β + Enc(−r) + e
+		beta := &labeledciphertext.elementsB[0][0]
+		pt := bgv.NewPlaintext(params.Parameters, beta.Level())
+		if err := bgv.NewEncoder(params.Parameters).Encode(negatedOffsets, pt); err != nil {
+			return labeledciphertext, err
+		}
+		mask, err := encryptor.EncryptNew(pt)
+		if err != nil {
+			return labeledciphertext, err
+		}
+		flooding.flood(mask)
+
+		sanitizedBeta, err := evaluator.AddNew(beta, mask)
+		if err != nil {
+			return labeledciphertext, err
+		}
+
+		sanitized.elementsA = any(sanitizedA).(T)
+		sanitized.elementsB = [][]rlwe.Ciphertext{{*sanitizedBeta}}
+
+	case *CiphertextElement:
+		if sanitized, err = mapCiphertexts(labeledciphertext, rerandomize); err != nil {
+			return labeledciphertext, err
+		}
+	}
+
+	// El historial, la profundidad y los límites revelarían el circuito; solo se conservan las etiquetas
+	sanitized.meta = metadata{operations: []string{"Sanitize"}, labels: slices.Clone(labeledciphertext.meta.labels)}
+
+	return sanitized, nil
+}
+
+// ErrFloodingBudget se devuelve cuando el ruido de inundación necesario agotaría el presupuesto de
+// ruido y el labeled ciphertext dejaría de poder descifrarse
+var ErrFloodingBudget = errors.New("labeling: el ruido de inundación excede el presupuesto de ruido")
+
+// capacityBits devuelve log2(Q/2) al nivel level, la cota del ruido que NoiseBudget reparte entre
+// NoiseBits y BudgetBits
+func capacityBits(params Parameters, level int) float64 {
+	bits := -1.0
+	for _, qi := range params.Q()[:level+1] {
+		bits += math.Log2(float64(qi))
+	}
+	return bits
+}
+
+// checkFloodingBudget devuelve ErrFloodingBudget si el ruido de inundación de desviación sigma, de
+// cota 6σ, sumado a un ruido de noiseBits bits, no deja descifrar una componente con capacityBits
+// bits de capacidad. El descifrado recupera m mientras el ruido quede por debajo de Q/(2t), así que
+// el mensaje ocupa log2(t) bits de la capacidad.
+func checkFloodingBudget(params Parameters, name string, noiseBits, capacityBits, sigma float64) error {
+	available := capacityBits - math.Log2(float64(params.PlaintextModulus()))
+	if needed := math.Log2(6*sigma + math.Exp2(noiseBits)); needed >= available {
+		return fmt.Errorf("%w: %s necesita %.1f bits y solo tiene %.1f", ErrFloodingBudget, name, needed, available)
+	}
+	return nil
+}
+
+// FloodingSigma devuelve la desviación del ruido de inundación que oculta un ruido de noiseBits bits
+// con lambda bits de seguridad estadística: la distancia estadística entre el resultado y uno con
+// ruido independiente del circuito es del orden de 2^-lambda.
+func FloodingSigma(noiseBits float64, lambda int) float64 {
+	return math.Exp2(noiseBits + float64(lambda))
+}
+
+// FloodNoise es el paso previo a entregar un valor intermedio a una parte semiconfiable, por ejemplo
+// un labeled ciphertext cuyo descifrado parcial se va a compartir. Mide con la clave secreta el ruido
+// de cada componente cifrada y añade ruido de inundación de desviación FloodingSigma(ruido, lambda).
+// Devuelve ErrFloodingBudget si alguna componente dejaría de poder descifrarse.
+func FloodNoise[T any](params Parameters, sk *rlwe.SecretKey, labeledciphertext Labeledciphertext[T], lambda int) (Labeledciphertext[T], error) {
+	report, err := NoiseBudget(params, sk, labeledciphertext)
+	if err != nil {
+		return labeledciphertext, err
+	}
+
+	noiseBits := 0.0
+	for _, component := range report.Components {
+		noiseBits = math.Max(noiseBits, component.NoiseBits)
+	}
+	sigma := FloodingSigma(noiseBits, lambda)
+
+	for _, component := range report.Components {
+		if err := checkFloodingBudget(params, component.Name, component.NoiseBits, component.NoiseBits+component.BudgetBits, sigma); err != nil {
+			return labeledciphertext, err
+		}
+	}
+
+	flooding, err := newFloodingSampler(params, sigma)
+	if err != nil {
+		return labeledciphertext, err
+	}
+
+	flooded, err := mapCiphertexts(labeledciphertext, func(ct *rlwe.Ciphertext) (*rlwe.Ciphertext, error) {
+		ctOut := ct.CopyNew()
+		flooding.flood(ctOut)
+		return ctOut, nil
+	})
+	if err != nil {
+		return labeledciphertext, err
+	}
+
+	flooded.meta = deriveMetadata("FloodNoise", false, labeledciphertext.meta)
+
+	return flooded, nil
+}
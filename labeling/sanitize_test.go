@@ -0,0 +1,127 @@
+// Copyright 2025 Juan Martín Pérez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Pruebas del saneamiento: el resultado descifra igual y no conserva el historial del circuito.
+
+package labeling
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestSanitizeResetsMetadata(t *testing.T) {
+	params, err := InsecureTestParameters()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sk, pk := GenerateKeyPair(params)
+	evk := GenerateMemEvaluationKeySet(GenerateRelinearizationKey(params, sk))
+
+	labelKey, err := GenerateLabelKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	registry := NewLabelRegistry()
+	x, err := EncryptLabeled(params, pk, labelKey, registry, "x", broadcast(params, 3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	y, err := EncryptLabeled(params, pk, labelKey, registry, "y", broadcast(params, 5))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	product, err := Mult(params, x, y, pk, evk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if product, err = Sum(params.Parameters, product, x); err != nil {
+		t.Fatal(err)
+	}
+
+	sanitized, err := Sanitize(params, pk, product)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := Decrypt(params, sk, sanitized)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkValues(t, got, broadcast(params, 18))
+
+	if ops := sanitized.Operations(); !slices.Equal(ops, []string{"Sanitize"}) {
+		t.Fatalf("el historial revela el circuito: %v", ops)
+	}
+	if sanitized.Multiplications() != 0 {
+		t.Fatalf("la profundidad revela el circuito: %d", sanitized.Multiplications())
+	}
+	if labels := sanitized.Labels(); !slices.Equal(labels, []Label{"x", "y"}) {
+		t.Fatalf("se esperaban las etiquetas x e y, hay %v", labels)
+	}
+
+	// Los metadatos serializados tampoco conservan el historial
+	data, err := sanitized.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded PlaintextLabeledciphertext
+	if err := decoded.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if ops := decoded.Operations(); !slices.Equal(ops, []string{"Sanitize"}) {
+		t.Fatalf("el historial serializado revela el circuito: %v", ops)
+	}
+
+	// Un cifrado saneado ya no es un cifrado recién etiquetado
+	single, err := Sanitize(params, pk, x)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := DecryptLabeled(params, labelKey, single); err == nil {
+		t.Fatal("DecryptLabeled aceptó un labeled ciphertext saneado")
+	}
+}
+
+func TestSanitizeFloodingBudget(t *testing.T) {
+	params, err := InsecureTestParameters()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sk, pk := GenerateKeyPair(params)
+
+	x, err := Encrypt(params, pk, broadcast(params, 7))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if x, err = DropLevel(x, x.Level()); err != nil {
+		t.Fatal(err)
+	}
+
+	// En el último nivel 2^30 no deja sitio al mensaje
+	if _, err := Sanitize(params, pk, x); !errors.Is(err, ErrFloodingBudget) {
+		t.Fatalf("se esperaba ErrFloodingBudget, se obtuvo %v", err)
+	}
+
+	sanitized, err := Sanitize(params, pk, x, WithFloodingSigma(1<<20))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := Decrypt(params, sk, sanitized)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkValues(t, got, broadcast(params, 7))
+}
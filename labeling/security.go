@@ -0,0 +1,79 @@
+// Copyright 2025 Juan Martín Pérez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package labeling
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Niveles de seguridad contemplados por el estándar de cifrado homomórfico
+const (
+	SecurityLevel128 = 128
+	SecurityLevel192 = 192
+	SecurityLevel256 = 256
+)
+
+// ErrInsecureParameters se devuelve cuando los parámetros no alcanzan el nivel de seguridad exigido
+var ErrInsecureParameters = errors.New("labeling: parámetros inseguros")
+
+// maxLogQP contiene el tamaño máximo de log2(QP) para cada LogN y nivel de seguridad
+// según las tablas del estándar de cifrado homomórfico (secreto ternario, ataque clásico)
+var maxLogQP = map[int]map[int]int{
+	10: {SecurityLevel128: 27, SecurityLevel192: 19, SecurityLevel256: 14},
+	11: {SecurityLevel128: 54, SecurityLevel192: 37, SecurityLevel256: 29},
+	12: {SecurityLevel128: 109, SecurityLevel192: 75, SecurityLevel256: 58},
+	13: {SecurityLevel128: 218, SecurityLevel192: 152, SecurityLevel256: 118},
+	14: {SecurityLevel128: 438, SecurityLevel192: 305, SecurityLevel256: 237},
+	15: {SecurityLevel128: 881, SecurityLevel192: 611, SecurityLevel256: 476},
+	16: {SecurityLevel128: 1747, SecurityLevel192: 1214, SecurityLevel256: 941},
+}
+
+// MaxLogQP devuelve el máximo log2(QP) admisible para un LogN y un nivel de seguridad,
+// o 0 si el estándar no contempla esa combinación
+func MaxLogQP(logN int, security int) int {
+	return maxLogQP[logN][security]
+}
+
+// securityLevel compara LogN y log2(QP) con las tablas del estándar. Se usa el tamaño real de QP:
+// los primos NTT-friendly pueden quedar por encima de 2^LogQi, y la suma de los tamaños nominales
+// subestimaría el módulo.
+func securityLevel(logN int, logQP float64) int {
+	for _, security := range []int{SecurityLevel256, SecurityLevel192, SecurityLevel128} {
+		if bound := MaxLogQP(logN, security); bound != 0 && logQP <= float64(bound) {
+			return security
+		}
+	}
+	return 0
+}
+
+// validateSecurity devuelve ErrInsecureParameters si LogN y log2(QP) no alcanzan minBits bits
+func validateSecurity(logN int, logQP float64, minBits int) error {
+	if level := securityLevel(logN, logQP); level < minBits {
+		return fmt.Errorf("%w: LogN=%d y log2(QP)=%.2f ofrecen %d bits, se exigen %d", ErrInsecureParameters, logN, logQP, level, minBits)
+	}
+	return nil
+}
+
+// SecurityLevel estima los bits de seguridad de los parámetros comparando LogN y log2(QP)
+// con las tablas del estándar. Devuelve 0 si no se alcanzan ni siquiera 128 bits.
+func (p Parameters) SecurityLevel() int {
+	return securityLevel(p.LogN(), p.LogQP())
+}
+
+// Validate comprueba que los parámetros ofrecen al menos minBits bits de seguridad
+func (p Parameters) Validate(minBits int) error {
+	return validateSecurity(p.LogN(), p.LogQP(), minBits)
+}
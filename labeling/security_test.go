@@ -0,0 +1,56 @@
+// Copyright 2025 Juan Martín Pérez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Pruebas del nivel de seguridad de los conjuntos predefinidos, medido sobre el log2(QP) real.
+
+package labeling
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPresetsSecurityLevel(t *testing.T) {
+	presets := []struct {
+		name     string
+		literal  ParametersLiteral
+		security int
+	}{
+		{"PN13QP218", ParamsPN13QP218, SecurityLevel128},
+		{"PN14QP438", ParamsPN14QP438, SecurityLevel128},
+		{"PN15QP880", ParamsPN15QP880, SecurityLevel128},
+		{"PN14QP305", ParamsPN14QP305, SecurityLevel192},
+		{"PN15QP611", ParamsPN15QP611, SecurityLevel192},
+		{"PN14QP237", ParamsPN14QP237, SecurityLevel256},
+		{"PN15QP476", ParamsPN15QP476, SecurityLevel256},
+	}
+
+	for _, preset := range presets {
+		params, err := NewParametersFromPreset(preset.literal, WithMinSecurity(preset.security))
+		if err != nil {
+			t.Fatalf("%s: %v", preset.name, err)
+		}
+		if level := params.SecurityLevel(); level != preset.security {
+			t.Fatalf("%s: log2(QP) = %.4f ofrece %d bits, se esperaban %d", preset.name, params.LogQP(), level, preset.security)
+		}
+	}
+}
+
+func TestValidateRealLogQP(t *testing.T) {
+	// Los tamaños nominales suman 219 bits, uno más que la cota de 128 bits para LogN = 13
+	_, err := NewParametersFromLiteral(13, []int{58, 55, 50}, []int{56}, 0x3ee0001)
+	if !errors.Is(err, ErrInsecureParameters) {
+		t.Fatalf("se esperaba ErrInsecureParameters, se obtuvo %v", err)
+	}
+}
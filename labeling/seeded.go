@@ -0,0 +1,280 @@
+// Copyright 2025 Juan Martín Pérez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Representaciones con semilla.
+//
+// Un cifrado RLWE con clave secreta es (c0, c1) = (−c1·s + m + e, c1) con c1 uniforme, y una clave
+// pública es (−a·s + e, a) con a uniforme. Si el polinomio uniforme se deriva de una semilla, basta
+// con transmitir la semilla y el primer polinomio: el receptor regenera el segundo con el mismo
+// PRNG. Así se reduce aproximadamente a la mitad el tamaño de las claves públicas y de los cifrados
+// frescos de las máscaras. Tras la primera operación homomórfica el segundo polinomio deja de ser
+// uniforme y la representación con semilla ya no es posible.
+
+package labeling
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/tuneinsight/lattigo/v6/core/rlwe"
+	"github.com/tuneinsight/lattigo/v6/multiparty"
+	"github.com/tuneinsight/lattigo/v6/utils/sampling"
+)
+
+// SeedSize es el tamaño en bytes de las semillas de las representaciones compactas
+const SeedSize = 32
+
+// newSeed genera una semilla aleatoria de SeedSize bytes
+func newSeed() ([]byte, error) {
+	seed := make([]byte, SeedSize)
+	if _, err := rand.Read(seed); err != nil {
+		return nil, err
+	}
+	return seed, nil
+}
+
+// SeededCiphertext es un cifrado fresco con clave secreta en el que c1 se sustituye por la
+// semilla de la que se deriva
+type SeededCiphertext struct {
+	// Seed es la semilla del PRNG que genera c1
+	Seed []byte
+	// Body es un cifrado de grado 0 que contiene c0 y los metadatos del cifrado original
+	Body *rlwe.Ciphertext
+}
+
+// EncryptSeededNew cifra pt con la clave secreta usando una semilla nueva para c1
+func EncryptSeededNew(params Parameters, sk *rlwe.SecretKey, pt *rlwe.Plaintext) (SeededCiphertext, error) {
+	seed, err := newSeed()
+	if err != nil {
+		return SeededCiphertext{}, err
+	}
+
+	prng, err := sampling.NewKeyedPRNG(seed)
+	if err != nil {
+		return SeededCiphertext{}, err
+	}
+
+	ct, err := rlwe.NewEncryptor(params, sk).WithPRNG(prng).EncryptNew(pt)
+	if err != nil {
+		return SeededCiphertext{}, err
+	}
+
+	// Descartamos c1, que el receptor regenera a partir de la semilla
+	body := ct.CopyNew()
+	body.Value = body.Value[:1]
+
+	return SeededCiphertext{Seed: seed, Body: body}, nil
+}
+
+// Expand reconstruye el cifrado completo (c0, c1) regenerando c1 a partir de la semilla
+func (s SeededCiphertext) Expand(params Parameters) (*rlwe.Ciphertext, error) {
+	if s.Body == nil || len(s.Body.Value) != 1 {
+		return nil, fmt.Errorf("labeling: SeededCiphertext requiere un cuerpo de grado 0")
+	}
+
+	prng, err := sampling.NewKeyedPRNG(s.Seed)
+	if err != nil {
+		return nil, err
+	}
+
+	// Cifrar cero con la clave nula produce (e, c1) con el mismo c1 que el emisor
+	ct := rlwe.NewCiphertext(params, 1, s.Body.Level())
+	if err := rlwe.NewEncryptor(params, rlwe.NewSecretKey(params)).WithPRNG(prng).EncryptZero(ct); err != nil {
+		return nil, err
+	}
+
+	ct.Value[0] = *s.Body.Value[0].CopyNew()
+	*ct.MetaData = *s.Body.MetaData
+
+	return ct, nil
+}
+
+// MarshalBinary serializa el cifrado: la semilla seguida del cuerpo
+func (s SeededCiphertext) MarshalBinary() ([]byte, error) {
+	if len(s.Seed) != SeedSize || s.Body == nil {
+		return nil, fmt.Errorf("labeling: SeededCiphertext incompleto")
+	}
+
+	body, err := s.Body.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+
+	return append(append([]byte{}, s.Seed...), body...), nil
+}
+
+// UnmarshalBinary reconstruye el cifrado a partir de su serialización binaria
+func (s *SeededCiphertext) UnmarshalBinary(data []byte) error {
+	if len(data) < SeedSize {
+		return fmt.Errorf("labeling: serialización de SeededCiphertext demasiado corta")
+	}
+
+	body := new(rlwe.Ciphertext)
+	if err := body.UnmarshalBinary(data[SeedSize:]); err != nil {
+		return err
+	}
+
+	s.Seed = append([]byte{}, data[:SeedSize]...)
+	s.Body = body
+
+	return nil
+}
+
+// SeededLabeledciphertext es un PlaintextLabeledciphertext fresco cuyo β se guarda con semilla
+type SeededLabeledciphertext struct {
+	elementsA PlaintextElements
+	beta      SeededCiphertext
+}
+
+// EncryptSeeded cifra un vector como Encrypt, pero con la clave secreta y β en forma compacta.
+// Solo la clave secreta permite regenerar c1, por lo que no existe una variante con clave pública.
+func EncryptSeeded(params Parameters, sk *rlwe.SecretKey, value []uint64) (SeededLabeledciphertext, error) {
+	elementsA, maskPlaintext, err := maskValue(params, value)
+	if err != nil {
+		return SeededLabeledciphertext{}, err
+	}
+
+	// β ← Enc(sk, b) con c1 derivado de la semilla
+	beta, err := EncryptSeededNew(params, sk, maskPlaintext)
+	if err != nil {
+		return SeededLabeledciphertext{}, err
+	}
+
+	return SeededLabeledciphertext{elementsA: elementsA, beta: beta}, nil
+}
+
+// Expand reconstruye el PlaintextLabeledciphertext completo para operar con él
+func (s SeededLabeledciphertext) Expand(params Parameters) (PlaintextLabeledciphertext, error) {
+	beta, err := s.beta.Expand(params)
+	if err != nil {
+		return PlaintextLabeledciphertext{}, err
+	}
+
+	var labeledciphertext PlaintextLabeledciphertext
+	labeledciphertext.elementsA = append(PlaintextElements{}, s.elementsA...)
+	labeledciphertext.elementsB = [][]rlwe.Ciphertext{{*beta}}
+	labeledciphertext.meta = deriveMetadata("EncryptSeeded", false)
+
+	return labeledciphertext, nil
+}
+
+// MarshalBinary serializa el labeled ciphertext: el número de elementos A en 4 bytes, los
+// elementos A en 8 bytes cada uno y β con semilla
+func (s SeededLabeledciphertext) MarshalBinary() ([]byte, error) {
+	beta, err := s.beta.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+
+	data := binary.LittleEndian.AppendUint32(nil, uint32(len(s.elementsA)))
+	for _, elementA := range s.elementsA {
+		data = binary.LittleEndian.AppendUint64(data, elementA)
+	}
+
+	return append(data, beta...), nil
+}
+
+// UnmarshalBinary reconstruye el labeled ciphertext a partir de su serialización binaria
+func (s *SeededLabeledciphertext) UnmarshalBinary(data []byte) error {
+	if len(data) < 4 {
+		return fmt.Errorf("labeling: serialización de SeededLabeledciphertext demasiado corta")
+	}
+
+	n := int(binary.LittleEndian.Uint32(data))
+	data = data[4:]
+	if len(data) < 8*n {
+		return fmt.Errorf("labeling: serialización de SeededLabeledciphertext demasiado corta")
+	}
+
+	elementsA := make(PlaintextElements, n)
+	for i := range elementsA {
+		elementsA[i] = binary.LittleEndian.Uint64(data[8*i:])
+	}
+
+	var beta SeededCiphertext
+	if err := beta.UnmarshalBinary(data[8*n:]); err != nil {
+		return err
+	}
+
+	s.elementsA = elementsA
+	s.beta = beta
+
+	return nil
+}
+
+// SeededPublicKey es una clave pública en la que a se sustituye por la semilla de la que se deriva
+type SeededPublicKey struct {
+	// Seed es la semilla del PRNG que genera a
+	Seed []byte
+	// Share contiene −a·s + e
+	Share multiparty.PublicKeyGenShare
+}
+
+// GenerateSeededPublicKey genera la clave pública de sk en forma compacta con una semilla nueva
+func GenerateSeededPublicKey(params Parameters, sk *rlwe.SecretKey) (SeededPublicKey, error) {
+	seed, err := newSeed()
+	if err != nil {
+		return SeededPublicKey{}, err
+	}
+
+	// Una clave pública es el caso de una sola parte de la generación conjunta
+	keyGen, err := NewCollectiveKeyGen(params, seed)
+	if err != nil {
+		return SeededPublicKey{}, err
+	}
+
+	return SeededPublicKey{Seed: seed, Share: keyGen.GenShare(sk)}, nil
+}
+
+// Expand reconstruye la clave pública completa (−a·s + e, a) regenerando a a partir de la semilla
+func (s SeededPublicKey) Expand(params Parameters) (*rlwe.PublicKey, error) {
+	keyGen, err := NewCollectiveKeyGen(params, s.Seed)
+	if err != nil {
+		return nil, err
+	}
+
+	return keyGen.Aggregate([]multiparty.PublicKeyGenShare{s.Share})
+}
+
+// MarshalBinary serializa la clave: la semilla seguida de −a·s + e
+func (s SeededPublicKey) MarshalBinary() ([]byte, error) {
+	if len(s.Seed) != SeedSize {
+		return nil, fmt.Errorf("labeling: SeededPublicKey incompleta")
+	}
+
+	share, err := s.Share.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+
+	return append(append([]byte{}, s.Seed...), share...), nil
+}
+
+// UnmarshalBinary reconstruye la clave a partir de su serialización binaria
+func (s *SeededPublicKey) UnmarshalBinary(data []byte) error {
+	if len(data) < SeedSize {
+		return fmt.Errorf("labeling: serialización de SeededPublicKey demasiado corta")
+	}
+
+	var share multiparty.PublicKeyGenShare
+	if err := share.UnmarshalBinary(data[SeedSize:]); err != nil {
+		return err
+	}
+
+	s.Seed = append([]byte{}, data[:SeedSize]...)
+	s.Share = share
+
+	return nil
+}
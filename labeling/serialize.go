@@ -0,0 +1,280 @@
+// Copyright 2025 Juan Martín Pérez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Serialización de labeled ciphertexts.
+//
+// El formato empieza por la cabecera "LBLC", la versión (1 byte) y la forma (0 plaintext,
+// 1 overflow), y sigue con las dimensiones explícitas de cada parte:
+//
+//	elementos A   plaintext: número (4 bytes) y cada elemento (8 bytes)
+//	              overflow:  longitud (8 bytes) y α serializado
+//	elementos B   número de términos (4 bytes); por término, número de βs (4 bytes) y cada β
+//	              como longitud (8 bytes) y cifrado serializado
+//	metadatos     profundidad, maxDegree y maxTerms (4 bytes cada uno), el historial de
+//	              operaciones y las etiquetas, cada lista como número (4 bytes) y cadenas con
+//	              su longitud (4 bytes)
+//
+// Todos los enteros van en little endian.
+
+package labeling
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/tuneinsight/lattigo/v6/core/rlwe"
+)
+
+// labeledciphertextMagic identifica la serialización de un labeled ciphertext
+var labeledciphertextMagic = []byte("LBLC")
+
+// labeledciphertextVersion es la versión del formato de serialización
+const labeledciphertextVersion = 1
+
+const (
+	formPlaintext byte = 0
+	formOverflow  byte = 1
+)
+
+// byteReader lee campos consecutivos de una serialización; el primer error se conserva y las
+// lecturas siguientes no hacen nada
+type byteReader struct {
+	data []byte
+	what string
+	err  error
+}
+
+func (r *byteReader) next(n int) []byte {
+	if r.err != nil || n < 0 || n > len(r.data) {
+		if r.err == nil {
+			r.err = fmt.Errorf("labeling: %s truncada", r.what)
+		}
+		return nil
+	}
+	b := r.data[:n]
+	r.data = r.data[n:]
+	return b
+}
+
+func (r *byteReader) uint16() int {
+	if b := r.next(2); b != nil {
+		return int(binary.LittleEndian.Uint16(b))
+	}
+	return 0
+}
+
+func (r *byteReader) uint32() int {
+	if b := r.next(4); b != nil {
+		return int(binary.LittleEndian.Uint32(b))
+	}
+	return 0
+}
+
+// bytes lee un campo precedido de su longitud en 8 bytes
+func (r *byteReader) bytes() []byte {
+	b := r.next(8)
+	if b == nil {
+		return nil
+	}
+	length := binary.LittleEndian.Uint64(b)
+	if length > uint64(len(r.data)) {
+		return r.next(-1)
+	}
+	return r.next(int(length))
+}
+
+// string lee una cadena precedida de su longitud en 4 bytes
+func (r *byteReader) string() string {
+	return string(r.next(r.uint32()))
+}
+
+// ciphertextInto lee un cifrado precedido de su longitud en 8 bytes sobre ct, reutilizando sus
+// polinomios si tienen el tamaño adecuado
+func (r *byteReader) ciphertextInto(ct *rlwe.Ciphertext) {
+	data := r.bytes()
+	if r.err == nil {
+		if err := ct.UnmarshalBinary(data); err != nil {
+			r.err = err
+		}
+	}
+}
+
+// appendCiphertext añade un cifrado precedido de su longitud en 8 bytes
+func appendCiphertext(data []byte, ct *rlwe.Ciphertext) ([]byte, error) {
+	content, err := ct.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	data = binary.LittleEndian.AppendUint64(data, uint64(len(content)))
+	return append(data, content...), nil
+}
+
+// appendStrings añade una lista de cadenas con su número y la longitud de cada una
+func appendStrings[S ~string](data []byte, values []S) []byte {
+	data = binary.LittleEndian.AppendUint32(data, uint32(len(values)))
+	for _, value := range values {
+		data = binary.LittleEndian.AppendUint32(data, uint32(len(value)))
+		data = append(data, value...)
+	}
+	return data
+}
+
+// MarshalBinary serializa el labeled ciphertext completo: elementos A, todos los βs de cada
+// término y los metadatos
+func (lc Labeledciphertext[T]) MarshalBinary() ([]byte, error) {
+	data := append(append([]byte{}, labeledciphertextMagic...), labeledciphertextVersion)
+
+	var err error
+	switch elementsA := any(lc.elementsA).(type) {
+	case PlaintextElements:
+		data = append(data, formPlaintext)
+		data = binary.LittleEndian.AppendUint32(data, uint32(len(elementsA)))
+		for _, elementA := range elementsA {
+			data = binary.LittleEndian.AppendUint64(data, elementA)
+		}
+
+	case *CiphertextElement:
+		if elementsA == nil {
+			return nil, fmt.Errorf("labeling: labeled ciphertext sin α")
+		}
+		data = append(data, formOverflow)
+		if data, err = appendCiphertext(data, (*rlwe.Ciphertext)(elementsA)); err != nil {
+			return nil, err
+		}
+
+	default:
+		return nil, fmt.Errorf("%w: serialización de %T", ErrUnsupportedOperands, lc.elementsA)
+	}
+
+	data = binary.LittleEndian.AppendUint32(data, uint32(len(lc.elementsB)))
+	for _, term := range lc.elementsB {
+		data = binary.LittleEndian.AppendUint32(data, uint32(len(term)))
+		for j := range term {
+			if data, err = appendCiphertext(data, &term[j]); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	data = binary.LittleEndian.AppendUint32(data, uint32(lc.meta.multiplications))
+	data = binary.LittleEndian.AppendUint32(data, uint32(lc.meta.maxDegree))
+	data = binary.LittleEndian.AppendUint32(data, uint32(lc.meta.maxTerms))
+	data = appendStrings(data, lc.meta.operations)
+	data = appendStrings(data, lc.meta.labels)
+
+	return data, nil
+}
+
+// UnmarshalBinary reconstruye el labeled ciphertext a partir de su serialización binaria. La forma
+// serializada debe coincidir con la del tipo: PlaintextLabeledciphertext o
+// CiphertextLabeledciphertext. Como no conoce los parámetros, los datos de origen no confiable deben
+// comprobarse con Check antes de operar con ellos, o leerse con UnmarshalWire, que lo hace.
+func (lc *Labeledciphertext[T]) UnmarshalBinary(data []byte) error {
+	return lc.unmarshal(data, nil)
+}
+
+// unmarshal decodifica el labeled ciphertext tomando los cifrados de pool, o reservándolos si pool
+// es nil. Si la serialización no es válida, los cifrados tomados vuelven al pool.
+func (lc *Labeledciphertext[T]) unmarshal(data []byte, pool *CiphertextPool) (err error) {
+	header := len(labeledciphertextMagic) + 2
+	if len(data) < header || string(data[:len(labeledciphertextMagic)]) != string(labeledciphertextMagic) {
+		return fmt.Errorf("labeling: la serialización no es un labeled ciphertext")
+	}
+	if version := data[len(labeledciphertextMagic)]; version != labeledciphertextVersion {
+		return fmt.Errorf("labeling: versión de labeled ciphertext %d no soportada", version)
+	}
+	form := data[len(labeledciphertextMagic)+1]
+	r := &byteReader{data: data[header:], what: "serialización de labeled ciphertext"}
+
+	var result Labeledciphertext[T]
+
+	defer func() {
+		if err != nil && pool != nil {
+			pool.release(result.ciphertexts())
+		}
+	}()
+
+	switch any(result.elementsA).(type) {
+	case PlaintextElements:
+		if form != formPlaintext {
+			return fmt.Errorf("labeling: se esperaba un labeled ciphertext en forma plaintext")
+		}
+		n := r.uint32()
+		values := r.next(8 * n)
+		elementsA := make(PlaintextElements, 0)
+		if r.err == nil {
+			elementsA = make(PlaintextElements, n)
+			for i := range elementsA {
+				elementsA[i] = binary.LittleEndian.Uint64(values[8*i:])
+			}
+		}
+		result.elementsA = any(elementsA).(T)
+
+	case *CiphertextElement:
+		if form != formOverflow {
+			return fmt.Errorf("labeling: se esperaba un labeled ciphertext en forma overflow")
+		}
+		alpha := pool.Get()
+		result.elementsA = any((*CiphertextElement)(alpha)).(T)
+		r.ciphertextInto(alpha)
+
+	default:
+		return fmt.Errorf("%w: serialización de %T", ErrUnsupportedOperands, result.elementsA)
+	}
+
+	for range r.uint32() {
+		if r.err != nil {
+			return r.err
+		}
+		result.elementsB = append(result.elementsB, nil)
+		term := &result.elementsB[len(result.elementsB)-1]
+		for range r.uint32() {
+			if r.err != nil {
+				return r.err
+			}
+			*term = append(*term, *pool.Get())
+			r.ciphertextInto(&(*term)[len(*term)-1])
+		}
+	}
+
+	result.meta.multiplications = r.uint32()
+	result.meta.maxDegree = r.uint32()
+	result.meta.maxTerms = r.uint32()
+	for range r.uint32() {
+		if r.err != nil {
+			return r.err
+		}
+		result.meta.operations = append(result.meta.operations, r.string())
+	}
+	for range r.uint32() {
+		if r.err != nil {
+			return r.err
+		}
+		result.meta.labels = append(result.meta.labels, Label(r.string()))
+	}
+
+	if r.err != nil {
+		return r.err
+	}
+	if len(r.data) != 0 {
+		return fmt.Errorf("labeling: datos sobrantes tras el labeled ciphertext")
+	}
+	if form == formPlaintext && (len(result.elementsB) != 1 || len(result.elementsB[0]) != 1) {
+		return fmt.Errorf("labeling: un labeled ciphertext en forma plaintext requiere un único β")
+	}
+
+	*lc = result
+
+	return nil
+}
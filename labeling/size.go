@@ -0,0 +1,143 @@
+// Copyright 2025 Juan Martín Pérez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Tamaños serializados y en memoria.
+//
+// BinarySize devuelve exactamente la longitud de MarshalBinary sin serializar nada, de modo que un
+// servicio puede aplicar límites de tamaño o reservar espacio antes de escribir. Las claves de
+// lattigo y rlwe.MemEvaluationKeySet ya tienen su propio BinarySize. MemoryFootprint es una
+// estimación de la memoria que ocupa un labeled ciphertext una vez cargado, para planificar el
+// almacenamiento de conjuntos de datos cifrados.
+
+package labeling
+
+import (
+	"unsafe"
+
+	"github.com/tuneinsight/lattigo/v6/core/rlwe"
+)
+
+// stringsBinarySize es el tamaño de una lista de cadenas escrita con appendStrings
+func stringsBinarySize[S ~string](values []S) int {
+	size := 4
+	for _, value := range values {
+		size += 4 + len(value)
+	}
+	return size
+}
+
+// BinarySize devuelve la longitud en bytes de MarshalBinary
+func (lc Labeledciphertext[T]) BinarySize() int {
+	size := len(labeledciphertextMagic) + 2
+
+	switch elementsA := any(lc.elementsA).(type) {
+	case PlaintextElements:
+		size += 4 + 8*len(elementsA)
+	case *CiphertextElement:
+		if elementsA != nil {
+			size += 8 + (*rlwe.Ciphertext)(elementsA).BinarySize()
+		}
+	}
+
+	size += 4
+	for _, term := range lc.elementsB {
+		size += 4
+		for j := range term {
+			size += 8 + term[j].BinarySize()
+		}
+	}
+
+	size += 3 * 4
+	size += stringsBinarySize(lc.meta.operations)
+	size += stringsBinarySize(lc.meta.labels)
+
+	return size
+}
+
+// BinarySize devuelve la longitud en bytes de MarshalBinary
+func (b KeyBundle) BinarySize() int {
+	// Cada registro lleva el tipo (1 byte) y la longitud (8 bytes)
+	const record = 1 + 8
+
+	size := len(keyBundleMagic) + 1
+
+	if b.PublicKey != nil {
+		size += record + b.PublicKey.BinarySize()
+	}
+	if b.RelinearizationKey != nil {
+		size += record + b.RelinearizationKey.BinarySize()
+	}
+	for _, gk := range b.GaloisKeys {
+		size += record + gk.BinarySize()
+	}
+	for id, evk := range b.EvaluationKeys {
+		size += record + 4 + len(id) + evk.BinarySize()
+	}
+
+	return size
+}
+
+// ciphertextFootprint estima la memoria de un cifrado: los coeficientes de sus polinomios y las
+// cabeceras de los slices
+func ciphertextFootprint(ct *rlwe.Ciphertext) int {
+	const sliceHeader = int(unsafe.Sizeof([]uint64{}))
+
+	size := int(unsafe.Sizeof(*ct))
+	for _, poly := range ct.Value {
+		size += int(unsafe.Sizeof(poly))
+		for _, coeffs := range poly.Coeffs {
+			size += sliceHeader + 8*cap(coeffs)
+		}
+	}
+	if ct.MetaData != nil {
+		size += int(unsafe.Sizeof(*ct.MetaData))
+	}
+
+	return size
+}
+
+// MemoryFootprint estima la memoria en bytes que ocupa el labeled ciphertext: elementos A, todos
+// los βs y los metadatos. Cuenta toda la memoria alcanzable desde el labeled ciphertext, también la
+// que comparte con otros (βs, metadatos de lattigo o cadenas del historial reutilizados), por lo que
+// la suma sobre un conjunto es una cota superior. No incluye parámetros ni claves.
+func MemoryFootprint[T PlaintextElements | *CiphertextElement](lc Labeledciphertext[T]) int {
+	const stringHeader = int(unsafe.Sizeof(""))
+
+	size := int(unsafe.Sizeof(lc))
+
+	switch elementsA := any(lc.elementsA).(type) {
+	case PlaintextElements:
+		size += 8 * cap(elementsA)
+	case *CiphertextElement:
+		if elementsA != nil {
+			size += ciphertextFootprint((*rlwe.Ciphertext)(elementsA))
+		}
+	}
+
+	for _, term := range lc.elementsB {
+		size += int(unsafe.Sizeof(term))
+		for j := range term {
+			size += ciphertextFootprint(&term[j])
+		}
+	}
+
+	for _, operation := range lc.meta.operations {
+		size += stringHeader + len(operation)
+	}
+	for _, label := range lc.meta.labels {
+		size += stringHeader + len(label)
+	}
+
+	return size
+}
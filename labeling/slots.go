@@ -0,0 +1,113 @@
+// Copyright 2025 Juan Martín Pérez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package labeling
+
+import (
+	"fmt"
+
+	"github.com/tuneinsight/lattigo/v6/core/rlwe"
+)
+
+// MaskSlots multiplica slot a slot por una máscara pública, normalmente de 0 y 1, dejando a 0 los
+// slots no seleccionados tanto en los elementos A como en β. Los slots más allá de la máscara quedan a 0.
+func MaskSlots(params Parameters, labeledciphertext PlaintextLabeledciphertext, mask []uint64) (PlaintextLabeledciphertext, error) {
+	result, err := mulPlain(params, labeledciphertext, mask)
+	if err != nil {
+		return labeledciphertext, err
+	}
+
+	result.meta = deriveMetadata("MaskSlots", false, labeledciphertext.meta)
+
+	return result, nil
+}
+
+// ExtractSlots conserva solo los slots indicados y deja el resto a 0
+func ExtractSlots(params Parameters, labeledciphertext PlaintextLabeledciphertext, indices []int) (PlaintextLabeledciphertext, error) {
+	mask := make([]uint64, params.MaxSlots())
+	for _, index := range indices {
+		if index < 0 || index >= len(mask) {
+			return labeledciphertext, fmt.Errorf("labeling: slot %d fuera de rango [0, %d)", index, len(mask))
+		}
+		mask[index] = 1
+	}
+
+	result, err := mulPlain(params, labeledciphertext, mask)
+	if err != nil {
+		return labeledciphertext, err
+	}
+
+	result.meta = deriveMetadata("ExtractSlots", false, labeledciphertext.meta)
+
+	return result, nil
+}
+
+// Select elige slot a slot entre dos labeled ciphertexts según una máscara pública de 0 y 1:
+// mask·a + (1 − mask)·b, calculado como b + mask·(a − b) con una sola multiplicación por texto plano
+func Select(params Parameters, labeledciphertextTrue, labeledciphertextFalse PlaintextLabeledciphertext, mask []uint64) (PlaintextLabeledciphertext, error) {
+	diff, err := Sub(params, labeledciphertextTrue, labeledciphertextFalse)
+	if err != nil {
+		return diff, err
+	}
+
+	// La máscara se extiende con 0 para que los slots sin máscara tomen el valor de b
+	if diff, err = mulPlain(params, diff, mask); err != nil {
+		return diff, err
+	}
+
+	result, err := Sum(params.Parameters, labeledciphertextFalse, diff)
+	if err != nil {
+		return result, err
+	}
+
+	result.meta = deriveMetadata("Select", false, labeledciphertextTrue.meta, labeledciphertextFalse.meta)
+
+	return result, nil
+}
+
+// SelectEncrypted elige slot a slot entre dos labeled ciphertexts según un selector cifrado de 0 y 1,
+// calculando b + m·(a − b). El producto pasa por Multiply, así que consume una multiplicación y puede
+// devolver el resultado en forma overflow si se agota el presupuesto de profundidad.
+func SelectEncrypted(params Parameters, labeledciphertextTrue, labeledciphertextFalse, labeledciphertextMask PlaintextLabeledciphertext, key rlwe.EncryptionKey, evk *rlwe.MemEvaluationKeySet, opts ...MultiplyOption) (Operand, error) {
+	diff, err := Sub(params, labeledciphertextTrue, labeledciphertextFalse)
+	if err != nil {
+		return nil, err
+	}
+
+	product, err := Multiply(params, labeledciphertextMask, diff, key, evk, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	metas := []metadata{labeledciphertextTrue.meta, labeledciphertextFalse.meta, labeledciphertextMask.meta}
+
+	switch product := product.(type) {
+	case PlaintextLabeledciphertext:
+		result, err := Sum(params.Parameters, labeledciphertextFalse, product)
+		if err != nil {
+			return nil, err
+		}
+		result.meta = deriveMetadata("SelectEncrypted", true, metas...)
+		return result, nil
+	case CiphertextLabeledciphertext:
+		result, err := SumOverflow(params, product, labeledciphertextFalse)
+		if err != nil {
+			return nil, err
+		}
+		result.meta = deriveMetadata("SelectEncrypted", true, metas...)
+		return result, nil
+	}
+
+	return nil, fmt.Errorf("%w: SelectEncrypted(%T)", ErrUnsupportedOperands, product)
+}
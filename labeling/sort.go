@@ -0,0 +1,76 @@
+// Copyright 2025 Juan Martín Pérez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package labeling
+
+import (
+	"fmt"
+
+	"github.com/tuneinsight/lattigo/v6/core/rlwe"
+)
+
+// batcherPairs devuelve los comparadores de la red de ordenación par-impar de Batcher para n entradas
+func batcherPairs(n int) [][2]int {
+	var pairs [][2]int
+	for p := 1; p < n; p <<= 1 {
+		for k := p; k >= 1; k >>= 1 {
+			for j := k % p; j+k < n; j += 2 * k {
+				for i := range min(k, n-j-k) {
+					if (i+j)/(2*p) == (i+j+k)/(2*p) {
+						pairs = append(pairs, [2]int{i + j, i + j + k})
+					}
+				}
+			}
+		}
+	}
+	return pairs
+}
+
+// SortNetwork ordena slot a slot, de menor a mayor, una lista pequeña de labeled ciphertexts con
+// entradas en [0, bound), con la cota de WithBound, evaluando una red par-impar de Batcher. Cada
+// comparador calcula el mínimo con Min y el máximo como a + b − min, así que consume la profundidad
+// de un único polinomio.
+// Los resultados intermedios deben seguir en modo texto plano: si la profundidad se agota devuelve
+// ErrInsufficientDepth.
+func SortNetwork(params Parameters, labeledciphertexts []PlaintextLabeledciphertext, key rlwe.EncryptionKey, evk *rlwe.MemEvaluationKeySet, opts ...MultiplyOption) ([]PlaintextLabeledciphertext, error) {
+	sorted := make([]PlaintextLabeledciphertext, len(labeledciphertexts))
+	copy(sorted, labeledciphertexts)
+
+	for _, pair := range batcherPairs(len(sorted)) {
+		a, b := sorted[pair[0]], sorted[pair[1]]
+
+		minimum, err := Min(params, a, b, key, evk, opts...)
+		if err != nil {
+			return nil, err
+		}
+		lo, ok := minimum.(PlaintextLabeledciphertext)
+		if !ok {
+			return nil, fmt.Errorf("%w: SortNetwork necesita que cada comparador quede en modo texto plano", ErrInsufficientDepth)
+		}
+
+		// max(a, b) = a + b − min(a, b)
+		sum, err := Sum(params.Parameters, a, b)
+		if err != nil {
+			return nil, err
+		}
+		hi, err := Sub(params, sum, lo)
+		if err != nil {
+			return nil, err
+		}
+
+		sorted[pair[0]], sorted[pair[1]] = lo, hi
+	}
+
+	return sorted, nil
+}
@@ -0,0 +1,166 @@
+// Copyright 2025 Juan Martín Pérez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package labeling
+
+import (
+	"fmt"
+
+	"github.com/tuneinsight/lattigo/v6/core/rlwe"
+)
+
+// Histogram acumula conteos por categoría a partir de indicadores cifrados: para cada lote de registros
+// se recibe un labeled ciphertext por categoría con un 1 en los slots de los registros que pertenecen a
+// ella. Los lotes se suman slot a slot con Sum y al final InnerSum reduce cada categoría a su total.
+type Histogram struct {
+	params  Parameters
+	buckets []PlaintextLabeledciphertext
+	batches int
+}
+
+// NewHistogram crea un histograma vacío con el número de categorías indicado
+func NewHistogram(params Parameters, buckets int) *Histogram {
+	return &Histogram{params: params, buckets: make([]PlaintextLabeledciphertext, buckets)}
+}
+
+// Add acumula un lote de indicadores, uno por categoría
+func (h *Histogram) Add(indicators []PlaintextLabeledciphertext) error {
+	if len(indicators) != len(h.buckets) {
+		return fmt.Errorf("labeling: %d indicadores para un histograma de %d categorías", len(indicators), len(h.buckets))
+	}
+
+	for i, indicator := range indicators {
+		if h.batches == 0 {
+			h.buckets[i] = indicator
+			continue
+		}
+
+		var err error
+		if h.buckets[i], err = Sum(h.params.Parameters, h.buckets[i], indicator); err != nil {
+			return err
+		}
+	}
+	h.batches++
+
+	return nil
+}
+
+// Batches devuelve el número de lotes acumulados
+func (h *Histogram) Batches() int {
+	return h.batches
+}
+
+// Counts devuelve, por categoría, un labeled ciphertext con su conteo total replicado en todos los
+// slots. evk debe incluir las claves de InnerSumGaloisElements. Los conteos son exactos mientras no
+// superen t.
+func (h *Histogram) Counts(evk *rlwe.MemEvaluationKeySet) ([]PlaintextLabeledciphertext, error) {
+	if h.batches == 0 {
+		return nil, fmt.Errorf("labeling: histograma vacío")
+	}
+
+	counts := make([]PlaintextLabeledciphertext, len(h.buckets))
+	for i, bucket := range h.buckets {
+		var err error
+		if counts[i], err = InnerSum(h.params, bucket, evk); err != nil {
+			return nil, err
+		}
+	}
+
+	return counts, nil
+}
+
+// Scaled es un resultado cifrado que debe dividirse por Divisor tras descifrar, ya que en Z_t no hay
+// división exacta
+type Scaled struct {
+	Value   Operand
+	Divisor uint64
+}
+
+// Mean calcula slot a slot la media de una lista de labeled ciphertexts como Σxᵢ con divisor n
+func Mean(params Parameters, labeledciphertexts []PlaintextLabeledciphertext) (Scaled, error) {
+	sum, err := sumAll(params, labeledciphertexts)
+	if err != nil {
+		return Scaled{}, err
+	}
+
+	return Scaled{Value: sum, Divisor: uint64(len(labeledciphertexts))}, nil
+}
+
+// Variance calcula slot a slot la varianza poblacional como n·Σxᵢ² − (Σxᵢ)² con divisor n².
+// Los cuadrados pasan por Multiply, así que pueden quedar en forma overflow si se agota el presupuesto
+// de profundidad; en ese caso las sumas se hacen con las variantes overflow. El numerador debe ser
+// menor que t para que el resultado sea exacto.
+func Variance(params Parameters, labeledciphertexts []PlaintextLabeledciphertext, key rlwe.EncryptionKey, evk *rlwe.MemEvaluationKeySet, opts ...MultiplyOption) (Scaled, error) {
+	sum, err := sumAll(params, labeledciphertexts)
+	if err != nil {
+		return Scaled{}, err
+	}
+
+	// Σxᵢ²
+	var sumSquares Operand
+	for i, labeledciphertext := range labeledciphertexts {
+		square, err := Multiply(params, labeledciphertext, labeledciphertext, key, evk, opts...)
+		if err != nil {
+			return Scaled{}, err
+		}
+
+		if i == 0 {
+			sumSquares = square
+		} else if sumSquares, err = Add(params, sumSquares, square); err != nil {
+			return Scaled{}, err
+		}
+	}
+
+	n := uint64(len(labeledciphertexts))
+
+	// n·Σxᵢ²
+	scaled, err := scaleOperand(params, sumSquares, n)
+	if err != nil {
+		return Scaled{}, err
+	}
+
+	// −(Σxᵢ)²
+	squareSum, err := Multiply(params, sum, sum, key, evk, opts...)
+	if err != nil {
+		return Scaled{}, err
+	}
+	negated, err := negateOperand(params, squareSum)
+	if err != nil {
+		return Scaled{}, err
+	}
+
+	numerator, err := Add(params, scaled, negated)
+	if err != nil {
+		return Scaled{}, err
+	}
+
+	return Scaled{Value: numerator, Divisor: n * n}, nil
+}
+
+// sumAll suma una lista no vacía de PlaintextLabeledciphertext
+func sumAll(params Parameters, labeledciphertexts []PlaintextLabeledciphertext) (PlaintextLabeledciphertext, error) {
+	if len(labeledciphertexts) == 0 {
+		return PlaintextLabeledciphertext{}, fmt.Errorf("labeling: lista de labeled ciphertexts vacía")
+	}
+
+	sum := labeledciphertexts[0]
+	for _, labeledciphertext := range labeledciphertexts[1:] {
+		var err error
+		if sum, err = Sum(params.Parameters, sum, labeledciphertext); err != nil {
+			return sum, err
+		}
+	}
+
+	return sum, nil
+}
@@ -0,0 +1,145 @@
+// Copyright 2025 Juan Martín Pérez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package labeling
+
+import (
+	"github.com/tuneinsight/lattigo/v6/core/rlwe"
+	"github.com/tuneinsight/lattigo/v6/schemes/bgv"
+)
+
+// negateTerms devuelve una copia de los términos de β con cada producto negado.
+// Basta con negar el primer β de cada término, y la negación es exacta, sin añadir ruido.
+func negateTerms(params Parameters, terms [][]rlwe.Ciphertext) [][]rlwe.Ciphertext {
+	negated := make([][]rlwe.Ciphertext, len(terms))
+	for i := range terms {
+		negated[i] = make([]rlwe.Ciphertext, len(terms[i]))
+		copy(negated[i], terms[i])
+		if len(terms[i]) == 0 {
+			continue
+		}
+
+		negated[i][0] = *negateCiphertext(params, &terms[i][0])
+	}
+	return negated
+}
+
+// negateCiphertext devuelve −ct negando cada polinomio del cifrado
+func negateCiphertext(params Parameters, ct *rlwe.Ciphertext) *rlwe.Ciphertext {
+	negated := ct.CopyNew()
+	ringQ := params.RingQ().AtLevel(negated.Level())
+	for k := range negated.Value {
+		ringQ.Neg(negated.Value[k], negated.Value[k])
+	}
+	return negated
+}
+
+// Negate devuelve el inverso aditivo de un labeled ciphertext: (t − a, −β) en modo texto plano
+// y (−α, −β) en forma overflow. Es exacto y no consume ruido.
+func Negate[T PlaintextElements | *CiphertextElement](params Parameters, labeledciphertext Labeledciphertext[T]) (Labeledciphertext[T], error) {
+	var negated Labeledciphertext[T]
+
+	switch elementsA := any(labeledciphertext.elementsA).(type) {
+	case PlaintextElements:
+		t := params.PlaintextModulus()
+		negatedA := make(PlaintextElements, len(elementsA))
+		for i, elementA := range elementsA {
+			negatedA[i] = (t - elementA%t) % t
+		}
+		negated.elementsA = any(negatedA).(T)
+
+		// En modo texto plano solo hay un β
+		negated.elementsB = [][]rlwe.Ciphertext{{*negateCiphertext(params, &labeledciphertext.elementsB[0][0])}}
+	case *CiphertextElement:
+		negated.elementsA = any((*CiphertextElement)(negateCiphertext(params, (*rlwe.Ciphertext)(elementsA)))).(T)
+		negated.elementsB = negateTerms(params, labeledciphertext.elementsB)
+	}
+
+	negated.meta = deriveMetadata("Negate", false, labeledciphertext.meta)
+
+	return negated, nil
+}
+
+// Sub resta dos PlaintextLabeledciphertext: (a1 − a2, β1 − β2)
+func Sub(params Parameters, labeledciphertext1, labeledciphertext2 PlaintextLabeledciphertext) (PlaintextLabeledciphertext, error) {
+	var labeledciphertextDiff PlaintextLabeledciphertext
+
+	// a ← a1 − a2 mod t
+	t := params.PlaintextModulus()
+	labeledciphertextDiff.elementsA = make(PlaintextElements, len(labeledciphertext1.elementsA))
+	for i := range labeledciphertext1.elementsA {
+		labeledciphertextDiff.elementsA[i] = (labeledciphertext1.elementsA[i] + t - labeledciphertext2.elementsA[i]%t) % t
+	}
+
+	// β ← β1 − β2
+	diff, err := bgv.NewEvaluator(params.Parameters, nil).SubNew(&labeledciphertext1.elementsB[0][0], &labeledciphertext2.elementsB[0][0])
+	if err != nil {
+		return labeledciphertextDiff, err
+	}
+	labeledciphertextDiff.elementsB = [][]rlwe.Ciphertext{{*diff}}
+
+	labeledciphertextDiff.meta = deriveMetadata("Sub", false, labeledciphertext1.meta, labeledciphertext2.meta)
+
+	return labeledciphertextDiff, nil
+}
+
+// SubOverflow resta un PlaintextLabeledciphertext a un CiphertextLabeledciphertext:
+// α ← α1 − a2 y los términos de β2 se añaden negados
+func SubOverflow(params Parameters, labeledciphertext1 CiphertextLabeledciphertext, labeledciphertext2 PlaintextLabeledciphertext) (CiphertextLabeledciphertext, error) {
+	var labeledciphertextDiff CiphertextLabeledciphertext
+
+	meta := deriveMetadata("SubOverflow", false, labeledciphertext1.meta, labeledciphertext2.meta)
+	if err := meta.checkLimits(max(labeledciphertext1.Degree(), labeledciphertext2.Degree()), len(labeledciphertext1.elementsB)+len(labeledciphertext2.elementsB)); err != nil {
+		return labeledciphertextDiff, err
+	}
+
+	alpha, err := bgv.NewEvaluator(params.Parameters, nil).SubNew((*rlwe.Ciphertext)(labeledciphertext1.elementsA), []uint64(labeledciphertext2.elementsA))
+	if err != nil {
+		return labeledciphertextDiff, err
+	}
+	labeledciphertextDiff.elementsA = (*CiphertextElement)(alpha)
+
+	// β ← [β1, −β2]
+	labeledciphertextDiff.elementsB = append(labeledciphertextDiff.elementsB, labeledciphertext1.elementsB...)
+	labeledciphertextDiff.elementsB = append(labeledciphertextDiff.elementsB, negateTerms(params, labeledciphertext2.elementsB)...)
+
+	labeledciphertextDiff.meta = meta
+
+	return labeledciphertextDiff, nil
+}
+
+// SubOverflowCiphertext resta dos CiphertextLabeledciphertext:
+// α ← α1 − α2 y los términos de β2 se añaden negados
+func SubOverflowCiphertext(params Parameters, labeledciphertext1, labeledciphertext2 CiphertextLabeledciphertext) (CiphertextLabeledciphertext, error) {
+	var labeledciphertextDiff CiphertextLabeledciphertext
+
+	meta := deriveMetadata("SubOverflowCiphertext", false, labeledciphertext1.meta, labeledciphertext2.meta)
+	if err := meta.checkLimits(max(labeledciphertext1.Degree(), labeledciphertext2.Degree()), len(labeledciphertext1.elementsB)+len(labeledciphertext2.elementsB)); err != nil {
+		return labeledciphertextDiff, err
+	}
+
+	alpha, err := bgv.NewEvaluator(params.Parameters, nil).SubNew((*rlwe.Ciphertext)(labeledciphertext1.elementsA), (*rlwe.Ciphertext)(labeledciphertext2.elementsA))
+	if err != nil {
+		return labeledciphertextDiff, err
+	}
+	labeledciphertextDiff.elementsA = (*CiphertextElement)(alpha)
+
+	// β ← [β1, −β2]
+	labeledciphertextDiff.elementsB = append(labeledciphertextDiff.elementsB, labeledciphertext1.elementsB...)
+	labeledciphertextDiff.elementsB = append(labeledciphertextDiff.elementsB, negateTerms(params, labeledciphertext2.elementsB)...)
+
+	labeledciphertextDiff.meta = meta
+
+	return labeledciphertextDiff, nil
+}
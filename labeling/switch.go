@@ -0,0 +1,222 @@
+// Copyright 2025 Juan Martín Pérez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Cambio de esquema entre labeled ciphertexts enteros (BGV) y aproximados (CKKS).
+//
+// No existe un cambio de esquema no interactivo entre BGV y CKKS, así que la conversión es un
+// protocolo de dos mensajes con el propietario de la clave secreta:
+//
+//  1. El evaluador colapsa el labeled ciphertext en un único cifrado de m, le suma una máscara
+//     aleatoria s que solo él conoce y envía Enc(m + s) al propietario.
+//  2. El propietario descifra m + s, que no revela m, y lo cifra de nuevo en el otro esquema.
+//  3. El evaluador resta s de los elementos A del resultado, sin tocar ningún cifrado.
+//
+// Al pasar de BGV a CKKS el resultado es exacto si los valores están en [0, bound), ya que s se
+// toma en [0, t - bound) para que m + s no desborde el módulo t.
+//
+// Por qué no se revela m en ese sentido:
+//
+//   - El propietario solo ve m + s, con s uniforme en [0, t - bound) e independiente de m, así que
+//     dos valores de [0, bound) le resultan indistinguibles salvo una distancia estadística
+//     bound / (t - bound): unos log2(t / bound) bits de seguridad estadística.
+//   - El evaluador conoce s y recibe (m + s − b, Enc(b)), con b la máscara de EncryptFloat, que el
+//     propietario toma uniforme en [−B, B) con B = 2^MaskBits y que el evaluador no puede obtener
+//     de Enc(b). Tras restar s le queda m − b, cuya distribución para dos valores de [0, bound)
+//     difiere como mucho en bound / (2B): unos MaskBits + 1 − log2(bound) bits de seguridad
+//     estadística. Por eso bound debe ser menor que B, y conviene que sea mucho menor.
+//
+// Al pasar de CKKS a BGV los valores deben estar en (−bound, bound), y s se toma entero y uniforme en
+// [bound, t − bound) para que m + s quede en (0, t) sin reducirse. CKKS no permite reducir módulo t
+// ni redondear sobre el cifrado, así que el propietario ve m + s como número real antes de redondear:
+//
+//   - La parte entera de m + s oculta m como en el otro sentido, salvo una distancia estadística
+//     2·bound / (t − 2·bound): unos log2(t / (2·bound)) bits de seguridad estadística.
+//   - La parte fraccionaria de m + s es la de m, porque s es entero. El propietario la conoce, y
+//     con ella cualquier información que lleven los decimales de m (por ejemplo, si m es entero).
+//     Una parte fraccionaria aleatoria en s la ocultaría, pero el evaluador no podría restarla tras
+//     el redondeo y el resultado dejaría de ser el entero más cercano. Si los decimales son
+//     sensibles, deben eliminarse antes del cambio o no usarse este protocolo.
+
+package labeling
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+
+	"github.com/tuneinsight/lattigo/v6/core/rlwe"
+	"github.com/tuneinsight/lattigo/v6/ring"
+	"github.com/tuneinsight/lattigo/v6/schemes/bgv"
+	"github.com/tuneinsight/lattigo/v6/schemes/ckks"
+	"github.com/tuneinsight/lattigo/v6/utils/sampling"
+)
+
+// IntToFloatSwitch guarda las máscaras del evaluador durante el cambio de BGV a CKKS
+type IntToFloatSwitch struct {
+	offsets []uint64
+}
+
+// FloatToIntSwitch guarda las máscaras del evaluador durante el cambio de CKKS a BGV
+type FloatToIntSwitch struct {
+	offsets []uint64
+}
+
+// sampleOffsets genera n máscaras uniformes en [0, upper)
+func sampleOffsets(n int, upper uint64) ([]uint64, error) {
+	prng, err := sampling.NewPRNG()
+	if err != nil {
+		return nil, err
+	}
+
+	offsets := make([]uint64, n)
+	mask := uint64(1<<bits.Len64(upper) - 1)
+	for i := range offsets {
+		offsets[i] = ring.RandUniform(prng, upper, mask)
+	}
+
+	return offsets, nil
+}
+
+// NewIntToFloatSwitch inicia el cambio de un PlaintextLabeledciphertext a CKKS.
+// bound debe ser menor que t y que la cota 2^MaskBits de las máscaras de floatParams, que son las
+// que ocultan m al evaluador al final del protocolo.
+// Devuelve el estado del evaluador y el cifrado Enc(m + s) que debe enviarse al propietario de la clave.
+func NewIntToFloatSwitch(params Parameters, floatParams FloatParameters, labeledciphertext PlaintextLabeledciphertext, bound uint64) (*IntToFloatSwitch, *rlwe.Ciphertext, error) {
+	if bound == 0 || bound >= params.PlaintextModulus() {
+		return nil, nil, fmt.Errorf("labeling: cota %d fuera de rango para t = %d", bound, params.PlaintextModulus())
+	}
+	if bound >= 1<<floatParams.MaskBits() {
+		return nil, nil, fmt.Errorf("labeling: la cota %d no queda oculta por máscaras de %d bits", bound, floatParams.MaskBits())
+	}
+
+	offsets, err := sampleOffsets(len(labeledciphertext.elementsA), params.PlaintextModulus()-bound)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// a + s
+	shifted := make([]uint64, len(labeledciphertext.elementsA))
+	for i, elementA := range labeledciphertext.elementsA {
+		shifted[i] = (elementA + offsets[i]) % params.PlaintextModulus()
+	}
+
+	// β + a + s = Enc(m + s)
+	request, err := bgv.NewEvaluator(params.Parameters, nil).AddNew(&labeledciphertext.elementsB[0][0], shifted)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &IntToFloatSwitch{offsets: offsets}, request, nil
+}
+
+// AnswerIntToFloatSwitch es el paso del propietario de la clave: descifra m + s con la clave BGV
+// y lo cifra como FloatLabeledciphertext con la clave CKKS
+func AnswerIntToFloatSwitch(params Parameters, floatParams FloatParameters, sk *rlwe.SecretKey, floatKey rlwe.EncryptionKey, request *rlwe.Ciphertext) (FloatLabeledciphertext, error) {
+	shifted := make([]uint64, params.MaxSlots())
+	if err := bgv.NewEncoder(params.Parameters).Decode(rlwe.NewDecryptor(params, sk).DecryptNew(request), shifted); err != nil {
+		return FloatLabeledciphertext{}, err
+	}
+
+	values := make([]float64, floatParams.MaxSlots())
+	for i := range min(len(values), len(shifted)) {
+		values[i] = float64(shifted[i])
+	}
+
+	return EncryptFloat(floatParams, floatKey, values)
+}
+
+// Finish resta las máscaras del evaluador y devuelve el FloatLabeledciphertext de m
+func (s *IntToFloatSwitch) Finish(response FloatLabeledciphertext) FloatLabeledciphertext {
+	var result FloatLabeledciphertext
+
+	result.elementsA = make(FloatElements, len(response.elementsA))
+	for i, elementA := range response.elementsA {
+		result.elementsA[i] = elementA
+		if i < len(s.offsets) {
+			result.elementsA[i] -= float64(s.offsets[i])
+		}
+	}
+	result.elementsB = response.elementsB
+	result.meta = deriveMetadata("SwitchToFloat", false, response.meta)
+
+	return result
+}
+
+// NewFloatToIntSwitch inicia el cambio de un FloatLabeledciphertext a BGV.
+// Los valores deben estar en (−bound, bound), con 2·bound menor que t; se redondean al entero más
+// cercano y se reducen módulo t. El propietario de la clave ve la parte fraccionaria de cada valor
+// (ver la cabecera del fichero).
+// Devuelve el estado del evaluador y el cifrado Enc(m + s) que debe enviarse al propietario de la clave.
+func NewFloatToIntSwitch(params Parameters, floatParams FloatParameters, labeledciphertext FloatLabeledciphertext, bound uint64) (*FloatToIntSwitch, *rlwe.Ciphertext, error) {
+	if bound == 0 || bound >= params.PlaintextModulus()/2 {
+		return nil, nil, fmt.Errorf("labeling: cota %d fuera de rango para t = %d", bound, params.PlaintextModulus())
+	}
+
+	// s ∈ [bound, t − bound)
+	offsets, err := sampleOffsets(len(labeledciphertext.elementsA), params.PlaintextModulus()-2*bound)
+	if err != nil {
+		return nil, nil, err
+	}
+	for i := range offsets {
+		offsets[i] += bound
+	}
+
+	// a + s
+	shifted := make([]float64, len(labeledciphertext.elementsA))
+	for i, elementA := range labeledciphertext.elementsA {
+		shifted[i] = elementA + float64(offsets[i])
+	}
+
+	// β + a + s = Enc(m + s)
+	request, err := ckks.NewEvaluator(floatParams.Parameters, nil).AddNew(&labeledciphertext.elementsB[0][0], shifted)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &FloatToIntSwitch{offsets: offsets}, request, nil
+}
+
+// AnswerFloatToIntSwitch es el paso del propietario de la clave: descifra m + s con la clave CKKS,
+// lo redondea y lo cifra como PlaintextLabeledciphertext con la clave BGV
+func AnswerFloatToIntSwitch(params Parameters, floatParams FloatParameters, floatSk *rlwe.SecretKey, key rlwe.EncryptionKey, request *rlwe.Ciphertext) (PlaintextLabeledciphertext, error) {
+	shifted := make([]float64, floatParams.MaxSlots())
+	if err := ckks.NewEncoder(floatParams.Parameters).Decode(rlwe.NewDecryptor(floatParams, floatSk).DecryptNew(request), shifted); err != nil {
+		return PlaintextLabeledciphertext{}, err
+	}
+
+	t := int64(params.PlaintextModulus())
+	values := make([]uint64, params.MaxSlots())
+	for i := range min(len(values), len(shifted)) {
+		values[i] = uint64(((int64(math.Round(shifted[i])) % t) + t) % t)
+	}
+
+	return Encrypt(params, key, values)
+}
+
+// Finish resta las máscaras del evaluador y devuelve el PlaintextLabeledciphertext de m
+func (s *FloatToIntSwitch) Finish(params Parameters, response PlaintextLabeledciphertext) PlaintextLabeledciphertext {
+	var result PlaintextLabeledciphertext
+
+	result.elementsA = make(PlaintextElements, len(response.elementsA))
+	for i, elementA := range response.elementsA {
+		result.elementsA[i] = elementA
+		if i < len(s.offsets) {
+			result.elementsA[i] = (elementA + params.PlaintextModulus() - s.offsets[i]) % params.PlaintextModulus()
+		}
+	}
+	result.elementsB = response.elementsB
+	result.meta = deriveMetadata("SwitchToInt", false, response.meta)
+
+	return result
+}
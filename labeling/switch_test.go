@@ -0,0 +1,115 @@
+// Copyright 2025 Juan Martín Pérez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Pruebas del cambio de esquema entre BGV y CKKS en los dos sentidos.
+
+package labeling
+
+import (
+	"math"
+	"testing"
+)
+
+// switchParameters devuelve parámetros BGV y CKKS inseguros del mismo anillo
+func switchParameters(t *testing.T) (Parameters, FloatParameters) {
+	t.Helper()
+
+	params, err := InsecureTestParameters()
+	if err != nil {
+		t.Fatal(err)
+	}
+	floatParams, err := NewFloatParametersFromLiteral(10, []int{55, 40}, []int{55}, 20, AllowInsecure())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return params, floatParams
+}
+
+func TestIntToFloatSwitch(t *testing.T) {
+	params, floatParams := switchParameters(t)
+	sk, pk := GenerateKeyPair(params)
+	floatSk, floatPk := GenerateKeyPair(floatParams)
+
+	const bound = 1 << 16
+	values := make([]uint64, params.MaxSlots())
+	for i := range values {
+		values[i] = uint64(i*37) % bound
+	}
+	lc, err := Encrypt(params, pk, values)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	state, request, err := NewIntToFloatSwitch(params, floatParams, lc, bound)
+	if err != nil {
+		t.Fatal(err)
+	}
+	response, err := AnswerIntToFloatSwitch(params, floatParams, sk, floatPk, request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := DecryptFloat(floatParams, floatSk, state.Finish(response))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range floatParams.MaxSlots() {
+		if math.Abs(got[i]-float64(values[i])) > 0.01 {
+			t.Fatalf("slot %d: se esperaba %d y se obtuvo %f", i, values[i], got[i])
+		}
+	}
+}
+
+func TestFloatToIntSwitch(t *testing.T) {
+	params, floatParams := switchParameters(t)
+	sk, pk := GenerateKeyPair(params)
+	floatSk, floatPk := GenerateKeyPair(floatParams)
+
+	// Valores negativos y con decimales: se redondean y se reducen módulo t
+	const bound = 1 << 16
+	values := make([]float64, floatParams.MaxSlots())
+	want := make([]uint64, params.MaxSlots())
+	t64 := int64(params.PlaintextModulus())
+	for i := range values {
+		values[i] = float64(i%200-100)*3.25 + 0.1
+		want[i] = uint64((int64(math.Round(values[i]))%t64 + t64) % t64)
+	}
+	lc, err := EncryptFloat(floatParams, floatPk, values)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	state, request, err := NewFloatToIntSwitch(params, floatParams, lc, bound)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, offset := range state.offsets {
+		if offset < bound || offset >= params.PlaintextModulus()-bound {
+			t.Fatalf("máscara %d = %d fuera de [bound, t − bound)", i, offset)
+		}
+	}
+
+	response, err := AnswerFloatToIntSwitch(params, floatParams, floatSk, pk, request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := Decrypt(params, sk, state.Finish(params, response))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkValues(t, got[:len(values)], want[:len(values)])
+
+	if _, _, err := NewFloatToIntSwitch(params, floatParams, lc, params.PlaintextModulus()/2); err == nil {
+		t.Fatal("se aceptó una cota que no deja sitio a las máscaras")
+	}
+}
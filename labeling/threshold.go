@@ -0,0 +1,106 @@
+// Copyright 2025 Juan Martín Pérez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package labeling
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/tuneinsight/lattigo/v6/core/rlwe"
+	"github.com/tuneinsight/lattigo/v6/multiparty"
+)
+
+// ThresholdShare es la parte de Shamir de la clave secreta que recibe una parte, identificada por su
+// punto público. Con t partes cualesquiera de las n se puede descifrar, de modo que perder hasta
+// n − t partes no impide recuperar los datos.
+type ThresholdShare struct {
+	Point multiparty.ShamirPublicPoint
+	Share multiparty.ShamirSecretShare
+}
+
+// IssueThresholdShares reparte una clave secreta en len(points) partes de Shamir con umbral threshold.
+// La clave puede ser la de un único propietario o la parte aditiva sᵢ de una parte de CollectiveKeyGen;
+// en el segundo caso cada parte recibe una parte de cada emisor y las suma con AggregateThresholdShares.
+// Los puntos deben ser distintos y no nulos.
+func IssueThresholdShares(params Parameters, sk *rlwe.SecretKey, threshold int, points []multiparty.ShamirPublicPoint) ([]ThresholdShare, error) {
+	if threshold < 1 || threshold > len(points) {
+		return nil, fmt.Errorf("labeling: umbral %d fuera de rango para %d partes", threshold, len(points))
+	}
+	for i, point := range points {
+		if point == 0 || slices.Contains(points[:i], point) {
+			return nil, fmt.Errorf("labeling: punto de Shamir %d nulo o repetido", point)
+		}
+	}
+
+	thresholdizer := multiparty.NewThresholdizer(params)
+	polynomial, err := thresholdizer.GenShamirPolynomial(threshold, sk)
+	if err != nil {
+		return nil, err
+	}
+
+	shares := make([]ThresholdShare, len(points))
+	for i, point := range points {
+		shares[i] = ThresholdShare{Point: point, Share: thresholdizer.AllocateThresholdSecretShare()}
+		thresholdizer.GenShamirSecretShare(point, polynomial, &shares[i].Share)
+	}
+
+	return shares, nil
+}
+
+// AggregateThresholdShares suma las partes que una misma parte ha recibido de cada emisor
+func AggregateThresholdShares(params Parameters, shares []ThresholdShare) (ThresholdShare, error) {
+	if len(shares) == 0 {
+		return ThresholdShare{}, fmt.Errorf("labeling: AggregateThresholdShares requiere al menos una parte")
+	}
+
+	thresholdizer := multiparty.NewThresholdizer(params)
+
+	aggregated := ThresholdShare{Point: shares[0].Point, Share: thresholdizer.AllocateThresholdSecretShare()}
+	for _, share := range shares {
+		if share.Point != aggregated.Point {
+			return ThresholdShare{}, fmt.Errorf("labeling: partes para los puntos %d y %d", aggregated.Point, share.Point)
+		}
+		if err := thresholdizer.AggregateShares(aggregated.Share, share.Share, &aggregated.Share); err != nil {
+			return ThresholdShare{}, err
+		}
+	}
+
+	return aggregated, nil
+}
+
+// ThresholdPartialDecrypt es el paso de cada parte activa en el descifrado con umbral: convierte su
+// parte de Shamir en una parte aditiva respecto de las partes activas y calcula con ella el descifrado
+// parcial. Todas las partes activas deben usar la misma lista active, con al menos threshold puntos,
+// y el combinador termina con Combine.
+func (c *CollectiveDecrypt) ThresholdPartialDecrypt(share ThresholdShare, active []multiparty.ShamirPublicPoint, threshold int, labeledciphertext Operand) (DecryptionShare, error) {
+	if len(active) < threshold {
+		return DecryptionShare{}, fmt.Errorf("labeling: %d partes activas para un umbral de %d", len(active), threshold)
+	}
+	if !slices.Contains(active, share.Point) {
+		return DecryptionShare{}, fmt.Errorf("labeling: el punto %d no está entre las partes activas", share.Point)
+	}
+
+	others := slices.DeleteFunc(slices.Clone(active), func(point multiparty.ShamirPublicPoint) bool {
+		return point == share.Point
+	})
+
+	sk := rlwe.NewSecretKey(c.params)
+	combiner := multiparty.NewCombiner(c.params.Parameters.Parameters, share.Point, others, threshold)
+	if err := combiner.GenAdditiveShare(active, share.Point, share.Share, sk); err != nil {
+		return DecryptionShare{}, err
+	}
+
+	return c.PartialDecrypt(sk, labeledciphertext)
+}
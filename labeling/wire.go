@@ -0,0 +1,509 @@
+// Copyright 2025 Juan Martín Pérez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Formato de intercambio.
+//
+// Todo artefacto serializado que sale del proceso (labeled ciphertexts, claves, KeyBundles,
+// pruebas...) puede envolverse en un contenedor estable:
+//
+//	"LBWF" | versión (1 byte) | tipo de contenido (1 byte) | indicadores (1 byte) |
+//	hash de los parámetros (32 bytes) | longitud del contenido (8 bytes) | contenido |
+//	etiqueta de integridad (0 o 32 bytes)
+//
+// El contenido es la serialización binaria propia de cada tipo, que tiene su propia versión. La
+// cabecera permite rechazar antes de decodificar nada un artefacto de otro tipo o generado con
+// otros parámetros, y su versión permite que versiones futuras cambien el formato sin dejar de
+// leer los contenedores antiguos.
+//
+// Los dos bits bajos de los indicadores son la compresión del contenido (0 ninguna, 1 zstd). La
+// compresión es transparente: el contenido se descomprime al leer el contenedor, hasta 256 MiB salvo
+// que se indique otro límite con WithMaxPayloadSize. Los cifrados con pocos módulos, por ejemplo tras
+// el cambio de módulo, se comprimen de forma apreciable.
+//
+// Los dos bits siguientes son la protección de integridad (0 ninguna, 1 SHA-256, 2 HMAC-SHA256).
+// La etiqueta cubre la cabecera y el contenido almacenado, y se comprueba antes de descomprimir o
+// decodificar nada, de modo que un artefacto dañado o alterado no llega a usarse en una
+// evaluación. Un contenedor con otros indicadores se rechaza.
+
+package labeling
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/tuneinsight/lattigo/v6/core/rlwe"
+)
+
+var (
+	// ErrParametersMismatch se devuelve cuando un contenedor se generó con otros parámetros
+	ErrParametersMismatch = errors.New("labeling: el contenedor se generó con otros parámetros")
+	// ErrPayloadType se devuelve cuando el contenido del contenedor no es del tipo esperado
+	ErrPayloadType = errors.New("labeling: tipo de contenido inesperado")
+	// ErrIntegrity se devuelve cuando la suma de control o el MAC de un contenedor no coinciden
+	ErrIntegrity = errors.New("labeling: el contenedor está dañado o ha sido alterado")
+)
+
+// containerMagic identifica un contenedor del formato de intercambio
+var containerMagic = []byte("LBWF")
+
+// containerVersion es la versión del formato de contenedor que se escribe
+const containerVersion = 1
+
+// containerHeaderSize es el tamaño de la cabecera de la versión 1
+const containerHeaderSize = 4 + 3 + sha256.Size + 8
+
+// defaultMaxPayloadSize limita por defecto el tamaño de un contenido descomprimido. Basta para
+// cualquier labeled ciphertext y para conjuntos de claves de tamaño moderado; los mayores se leen con
+// WithMaxPayloadSize.
+const defaultMaxPayloadSize = 256 << 20
+
+// Compression es el algoritmo de compresión del contenido de un contenedor
+type Compression byte
+
+const (
+	// CompressionNone guarda el contenido tal cual
+	CompressionNone Compression = 0
+	// CompressionZstd comprime el contenido con zstd
+	CompressionZstd Compression = 1
+)
+
+// compressionMask son los bits de los indicadores que codifican la compresión
+const compressionMask = 0x03
+
+var (
+	zstdEncoder = sync.OnceValues(func() (*zstd.Encoder, error) {
+		return zstd.NewWriter(nil)
+	})
+)
+
+// compress comprime payload con el algoritmo indicado
+func compress(compression Compression, payload []byte) ([]byte, error) {
+	switch compression {
+	case CompressionNone:
+		return payload, nil
+	case CompressionZstd:
+		encoder, err := zstdEncoder()
+		if err != nil {
+			return nil, err
+		}
+		return encoder.EncodeAll(payload, nil), nil
+	}
+	return nil, fmt.Errorf("labeling: compresión %d no soportada", compression)
+}
+
+// decompress deshace compress sin producir más de maxSize bytes, de modo que un contenido pequeño
+// que se descomprime en uno enorme se rechaza sin llegar a reservarlo
+func decompress(compression Compression, data []byte, maxSize int64) ([]byte, error) {
+	switch compression {
+	case CompressionNone:
+		return append([]byte{}, data...), nil
+	case CompressionZstd:
+		decoder, err := zstd.NewReader(bytes.NewReader(data), zstd.WithDecoderConcurrency(1), zstd.WithDecoderMaxMemory(uint64(maxSize)))
+		if err != nil {
+			return nil, err
+		}
+		defer decoder.Close()
+
+		payload, err := io.ReadAll(io.LimitReader(decoder, maxSize+1))
+		if err != nil {
+			return nil, fmt.Errorf("labeling: contenido comprimido no válido: %w", err)
+		}
+		if int64(len(payload)) > maxSize {
+			return nil, fmt.Errorf("labeling: el contenido descomprimido supera %d bytes", maxSize)
+		}
+		return payload, nil
+	}
+	return nil, fmt.Errorf("labeling: compresión %d no soportada", compression)
+}
+
+// PayloadType identifica el tipo del contenido de un contenedor. Los valores son estables.
+type PayloadType byte
+
+const (
+	PayloadPlaintextLabeledciphertext PayloadType = iota + 1
+	PayloadCiphertextLabeledciphertext
+	PayloadSeededLabeledciphertext
+	PayloadSeededCiphertext
+	PayloadSeededPublicKey
+	PayloadPublicKey
+	PayloadRelinearizationKey
+	PayloadGaloisKey
+	PayloadEvaluationKey
+	PayloadKeyBundle
+	PayloadDecryptionProof
+	PayloadAttestation
+)
+
+// String devuelve el nombre del tipo de contenido
+func (t PayloadType) String() string {
+	switch t {
+	case PayloadPlaintextLabeledciphertext:
+		return "PlaintextLabeledciphertext"
+	case PayloadCiphertextLabeledciphertext:
+		return "CiphertextLabeledciphertext"
+	case PayloadSeededLabeledciphertext:
+		return "SeededLabeledciphertext"
+	case PayloadSeededCiphertext:
+		return "SeededCiphertext"
+	case PayloadSeededPublicKey:
+		return "SeededPublicKey"
+	case PayloadPublicKey:
+		return "PublicKey"
+	case PayloadRelinearizationKey:
+		return "RelinearizationKey"
+	case PayloadGaloisKey:
+		return "GaloisKey"
+	case PayloadEvaluationKey:
+		return "EvaluationKey"
+	case PayloadKeyBundle:
+		return "KeyBundle"
+	case PayloadDecryptionProof:
+		return "DecryptionProof"
+	case PayloadAttestation:
+		return "Attestation"
+	}
+	return fmt.Sprintf("PayloadType(%d)", byte(t))
+}
+
+// payloadTypeOf devuelve el tipo de contenido de un valor o de un puntero a él
+func payloadTypeOf(v any) (PayloadType, error) {
+	switch v.(type) {
+	case PlaintextLabeledciphertext, *PlaintextLabeledciphertext:
+		return PayloadPlaintextLabeledciphertext, nil
+	case CiphertextLabeledciphertext, *CiphertextLabeledciphertext:
+		return PayloadCiphertextLabeledciphertext, nil
+	case SeededLabeledciphertext, *SeededLabeledciphertext:
+		return PayloadSeededLabeledciphertext, nil
+	case SeededCiphertext, *SeededCiphertext:
+		return PayloadSeededCiphertext, nil
+	case SeededPublicKey, *SeededPublicKey:
+		return PayloadSeededPublicKey, nil
+	case rlwe.PublicKey, *rlwe.PublicKey:
+		return PayloadPublicKey, nil
+	case rlwe.RelinearizationKey, *rlwe.RelinearizationKey:
+		return PayloadRelinearizationKey, nil
+	case rlwe.GaloisKey, *rlwe.GaloisKey:
+		return PayloadGaloisKey, nil
+	case rlwe.EvaluationKey, *rlwe.EvaluationKey:
+		return PayloadEvaluationKey, nil
+	case KeyBundle, *KeyBundle:
+		return PayloadKeyBundle, nil
+	case DecryptionProof, *DecryptionProof:
+		return PayloadDecryptionProof, nil
+	case Attestation, *Attestation:
+		return PayloadAttestation, nil
+	}
+	return 0, fmt.Errorf("labeling: el tipo %T no tiene formato de intercambio", v)
+}
+
+// Container es un artefacto serializado con su cabecera
+type Container struct {
+	// Version es la versión del formato de contenedor
+	Version byte
+	// Type es el tipo del contenido
+	Type PayloadType
+	// Compression es la compresión con la que se escribe o se leyó el contenido
+	Compression Compression
+	// Integrity es la protección de integridad con la que se escribe o se leyó el contenedor
+	Integrity Integrity
+	// ParametersHash es Parameters.Hash() de los parámetros con los que se generó
+	ParametersHash [sha256.Size]byte
+	// Payload es la serialización binaria del contenido, sin comprimir
+	Payload []byte
+}
+
+// Integrity es la protección de integridad de un contenedor
+type Integrity byte
+
+const (
+	// IntegrityNone no añade protección
+	IntegrityNone Integrity = 0
+	// IntegrityChecksum añade un SHA-256 que detecta la corrupción en el transporte
+	IntegrityChecksum Integrity = 1
+	// IntegrityMAC añade un HMAC-SHA256 con una clave compartida, que además detecta la alteración
+	IntegrityMAC Integrity = 2
+)
+
+// integrityShift y integrityMask son los bits de los indicadores que codifican la integridad
+const (
+	integrityShift = 2
+	integrityMask  = 0x03 << integrityShift
+)
+
+// integrityTag calcula la etiqueta de integridad de la cabecera y el contenido almacenado
+func integrityTag(integrity Integrity, macKey, data []byte) ([]byte, error) {
+	switch integrity {
+	case IntegrityNone:
+		return nil, nil
+	case IntegrityChecksum:
+		sum := sha256.Sum256(data)
+		return sum[:], nil
+	case IntegrityMAC:
+		if len(macKey) == 0 {
+			return nil, fmt.Errorf("%w: falta la clave del MAC", ErrIntegrity)
+		}
+		mac := hmac.New(sha256.New, macKey)
+		mac.Write(data)
+		return mac.Sum(nil), nil
+	}
+	return nil, fmt.Errorf("labeling: protección de integridad %d no soportada", integrity)
+}
+
+// wireOptions son las opciones de escritura y lectura de un contenedor
+type wireOptions struct {
+	compression    Compression
+	integrity      Integrity
+	macKey         []byte
+	maxPayloadSize int64
+}
+
+// WireOption modifica la escritura o la lectura de un contenedor
+type WireOption func(*wireOptions)
+
+// WithCompression comprime el contenido del contenedor
+func WithCompression(compression Compression) WireOption {
+	return func(o *wireOptions) {
+		o.compression = compression
+	}
+}
+
+// WithChecksum añade al contenedor un SHA-256 de la cabecera y el contenido
+func WithChecksum() WireOption {
+	return func(o *wireOptions) {
+		o.integrity = IntegrityChecksum
+	}
+}
+
+// WithMAC añade al contenedor un HMAC-SHA256 con key al escribir, y lo exige al leer: un
+// contenedor sin MAC se rechaza
+func WithMAC(key []byte) WireOption {
+	return func(o *wireOptions) {
+		o.integrity = IntegrityMAC
+		o.macKey = key
+	}
+}
+
+// WithMaxPayloadSize fija el tamaño máximo en bytes del contenido descomprimido al leer un
+// contenedor, en lugar de los 256 MiB por defecto
+func WithMaxPayloadSize(n int64) WireOption {
+	return func(o *wireOptions) {
+		o.maxPayloadSize = n
+	}
+}
+
+func newWireOptions(opts []WireOption) wireOptions {
+	options := wireOptions{maxPayloadSize: defaultMaxPayloadSize}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return options
+}
+
+// NewContainer serializa v y lo envuelve en un contenedor para params, con la compresión y la
+// integridad de las opciones
+func NewContainer(params Parameters, v encoding.BinaryMarshaler, opts ...WireOption) (*Container, error) {
+	payloadType, err := payloadTypeOf(v)
+	if err != nil {
+		return nil, err
+	}
+
+	hash, err := params.Hash()
+	if err != nil {
+		return nil, err
+	}
+
+	payload, err := v.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+
+	options := newWireOptions(opts)
+
+	return &Container{
+		Version:        containerVersion,
+		Type:           payloadType,
+		Compression:    options.compression,
+		Integrity:      options.integrity,
+		ParametersHash: hash,
+		Payload:        payload,
+	}, nil
+}
+
+// Open comprueba que el contenedor corresponde a params y al tipo de v, y decodifica en v el
+// contenido. Los labeled ciphertexts se comprueban además con Check.
+func (c Container) Open(params Parameters, v encoding.BinaryUnmarshaler) error {
+	hash, err := params.Hash()
+	if err != nil {
+		return err
+	}
+	if hash != c.ParametersHash {
+		return ErrParametersMismatch
+	}
+
+	payloadType, err := payloadTypeOf(v)
+	if err != nil {
+		return err
+	}
+	if payloadType != c.Type {
+		return fmt.Errorf("%w: se esperaba %v y el contenedor tiene %v", ErrPayloadType, payloadType, c.Type)
+	}
+
+	if err := v.UnmarshalBinary(c.Payload); err != nil {
+		return err
+	}
+
+	if checker, ok := v.(interface{ Check(Parameters) error }); ok {
+		return checker.Check(params)
+	}
+	return nil
+}
+
+// encode serializa el contenedor con la cabecera de su versión, comprimiendo el contenido y
+// añadiendo la etiqueta de integridad
+func (c Container) encode(macKey []byte) ([]byte, error) {
+	if c.Version != containerVersion {
+		return nil, fmt.Errorf("labeling: versión de contenedor %d no soportada para escritura", c.Version)
+	}
+
+	payload, err := compress(c.Compression, c.Payload)
+	if err != nil {
+		return nil, err
+	}
+
+	flags := byte(c.Compression) | byte(c.Integrity)<<integrityShift
+
+	data := make([]byte, 0, containerHeaderSize+len(payload)+sha256.Size)
+	data = append(data, containerMagic...)
+	data = append(data, c.Version, byte(c.Type), flags)
+	data = append(data, c.ParametersHash[:]...)
+	data = binary.LittleEndian.AppendUint64(data, uint64(len(payload)))
+	data = append(data, payload...)
+
+	tag, err := integrityTag(c.Integrity, macKey, data)
+	if err != nil {
+		return nil, err
+	}
+	return append(data, tag...), nil
+}
+
+// decode lee la cabecera, comprueba la etiqueta de integridad con la clave del MAC de options y
+// descomprime el contenido hasta el tamaño máximo de options
+func (c *Container) decode(data []byte, options wireOptions) error {
+	if len(data) < len(containerMagic)+1 || string(data[:len(containerMagic)]) != string(containerMagic) {
+		return fmt.Errorf("labeling: la serialización no es un contenedor")
+	}
+
+	var container Container
+	container.Version = data[len(containerMagic)]
+
+	switch container.Version {
+	case 1:
+		if len(data) < containerHeaderSize {
+			return fmt.Errorf("labeling: cabecera de contenedor truncada")
+		}
+		container.Type = PayloadType(data[5])
+		flags := data[6]
+		if flags&^(compressionMask|integrityMask) != 0 {
+			return fmt.Errorf("labeling: indicadores de contenedor desconocidos %#x", flags)
+		}
+		container.Compression = Compression(flags & compressionMask)
+		container.Integrity = Integrity((flags & integrityMask) >> integrityShift)
+		copy(container.ParametersHash[:], data[7:])
+
+		tagSize := 0
+		if container.Integrity != IntegrityNone {
+			tagSize = sha256.Size
+		}
+
+		length := binary.LittleEndian.Uint64(data[7+sha256.Size:])
+		if length != uint64(len(data)-containerHeaderSize-tagSize) || len(data) < containerHeaderSize+tagSize {
+			return fmt.Errorf("labeling: longitud de contenido incorrecta")
+		}
+
+		// La integridad se comprueba antes de descomprimir o decodificar nada
+		body := data[:len(data)-tagSize]
+		expected, err := integrityTag(container.Integrity, options.macKey, body)
+		if err != nil {
+			return err
+		}
+		if !hmac.Equal(expected, data[len(body):]) {
+			return ErrIntegrity
+		}
+
+		payload, err := decompress(container.Compression, body[containerHeaderSize:], options.maxPayloadSize)
+		if err != nil {
+			return err
+		}
+		container.Payload = payload
+
+	default:
+		return fmt.Errorf("labeling: versión de contenedor %d no soportada", container.Version)
+	}
+
+	*c = container
+
+	return nil
+}
+
+// MarshalBinary serializa el contenedor. Un contenedor con IntegrityMAC necesita la clave, así que
+// debe escribirse con MarshalWire.
+func (c Container) MarshalBinary() ([]byte, error) {
+	return c.encode(nil)
+}
+
+// UnmarshalBinary lee la cabecera y el contenido del contenedor, comprobando la suma de control y
+// descomprimiéndolo hasta 256 MiB, sin decodificarlo. Un contenedor con IntegrityMAC necesita la
+// clave, y uno mayor WithMaxPayloadSize, así que deben leerse con UnmarshalWire.
+func (c *Container) UnmarshalBinary(data []byte) error {
+	return c.decode(data, newWireOptions(nil))
+}
+
+// MarshalWire serializa v en un contenedor para params. WithCompression comprime el contenido, y
+// WithChecksum o WithMAC añaden protección de integridad.
+func MarshalWire(params Parameters, v encoding.BinaryMarshaler, opts ...WireOption) ([]byte, error) {
+	container, err := NewContainer(params, v, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return container.encode(newWireOptions(opts).macKey)
+}
+
+// UnmarshalWire decodifica en v un contenedor generado con MarshalWire. Devuelve
+// ErrParametersMismatch o ErrPayloadType sin decodificar el contenido si la cabecera no
+// corresponde a params o al tipo de v, y ErrIntegrity si el contenedor está dañado o alterado.
+// Con WithMAC exige que el contenedor lleve un MAC válido con esa clave, y WithMaxPayloadSize
+// cambia el tamaño máximo del contenido descomprimido.
+func UnmarshalWire(params Parameters, data []byte, v encoding.BinaryUnmarshaler, opts ...WireOption) error {
+	options := newWireOptions(opts)
+
+	var container Container
+	if err := container.decode(data, options); err != nil {
+		return err
+	}
+
+	// Sin esta comprobación, quitar el MAC de un contenedor bastaría para saltarse la verificación
+	if options.integrity == IntegrityMAC && container.Integrity != IntegrityMAC {
+		return fmt.Errorf("%w: el contenedor no tiene MAC", ErrIntegrity)
+	}
+
+	return container.Open(params, v)
+}